@@ -0,0 +1,166 @@
+/*
+	adiantum.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package adiantum implements the Adiantum length-preserving tweakable encryption mode
+	(Crowley and Biggers, "Adiantum: length-preserving encryption for entry-level processors", 2018).
+
+	Adiantum is built from XChaCha12, NH, Poly1305 and a single AES-256 block per message,
+	so it is fast on devices without AES acceleration. It is a wide-block mode accepting any message of at least 16 bytes,
+	and offers the same sector-style API as the CTS codec.
+*/
+package adiantum
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	// KeySize is the size of an Adiantum key.
+	KeySize = 32
+
+	blockSize = aes.BlockSize
+)
+
+// Cipher is an Adiantum cipher. It is safe for concurrent use.
+type Cipher struct {
+	key      [KeySize]byte // the XChaCha12 key
+	block    cipher.Block  // AES-256 with K_E
+	tweakH   []byte        // Poly1305 key K_T for the tweak
+	messageH *nhpoly1305   // NH-Poly1305 key K_M, K_N for the message
+}
+
+// New creates an Adiantum cipher with a 32-byte key.
+func New(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("adiantum: invalid key length %d", len(key))
+	}
+	c := new(Cipher)
+	copy(c.key[:], key)
+
+	// derive the subkeys from the XChaCha12 keystream with the nonce 1 || 0...
+	derived := make([]byte, 32+16+nhpolyKeyLen)
+	var nonce [24]byte
+	nonce[0] = 1
+	xchachaXORKeyStream(derived, derived, key, nonce[:], chachaRounds)
+
+	var err error
+	if c.block, err = aes.NewCipher(derived[:32]); err != nil {
+		return nil, err
+	}
+	c.tweakH = derived[32:48]
+	c.messageH = newNHPoly1305(derived[48:])
+	return c, nil
+}
+
+// Encrypt encrypts src with a tweak of any length into dst.
+// src must be at least 16 bytes long. dst and src must overlap entirely or not at all.
+func (c *Cipher) Encrypt(dst, src, tweak []byte) {
+	c.crypt(dst, src, tweak, true)
+}
+
+// Decrypt decrypts src with a tweak of any length into dst.
+// src must be at least 16 bytes long. dst and src must overlap entirely or not at all.
+func (c *Cipher) Decrypt(dst, src, tweak []byte) {
+	c.crypt(dst, src, tweak, false)
+}
+
+// EncryptSector encrypts a sector, with the sector number as a 32-byte little-endian tweak.
+func (c *Cipher) EncryptSector(dst, src []byte, sectorNum uint64) {
+	var tweak [32]byte
+	binary.LittleEndian.PutUint64(tweak[:], sectorNum)
+	c.Encrypt(dst, src, tweak[:])
+}
+
+// DecryptSector decrypts a sector, with the sector number as a 32-byte little-endian tweak.
+func (c *Cipher) DecryptSector(dst, src []byte, sectorNum uint64) {
+	var tweak [32]byte
+	binary.LittleEndian.PutUint64(tweak[:], sectorNum)
+	c.Decrypt(dst, src, tweak[:])
+}
+
+func (c *Cipher) crypt(dst, src, tweak []byte, encrypt bool) {
+	textlen := len(src)
+	if textlen < blockSize {
+		panic(fmt.Errorf("adiantum: data size too small; must be at least %d bytes", blockSize))
+	}
+	if len(dst) < textlen {
+		panic(fmt.Errorf("adiantum: output smaller than input"))
+	}
+
+	// the bulk part goes through XChaCha12, the last 16 bytes through AES
+	bulklen := textlen - blockSize
+	var r [blockSize]byte
+	copy(r[:], src[bulklen:])
+
+	// P_M = P_R + H(T, P_L)
+	lo, hi := c.hash(tweak, src[:bulklen])
+	add128(&r, lo, hi)
+
+	// C_M = E(P_M); C_M is also the stream cipher nonce
+	var nonce [24]byte
+	if encrypt {
+		c.block.Encrypt(r[:], r[:])
+		copy(nonce[:], r[:])
+	} else {
+		copy(nonce[:], r[:])
+		c.block.Decrypt(r[:], r[:])
+	}
+
+	// C_L = P_L xor XChaCha12(C_M || 1 || 0...)
+	nonce[blockSize] = 1
+	xchachaXORKeyStream(dst[:bulklen], src[:bulklen], c.key[:], nonce[:], chachaRounds)
+
+	// C_R = C_M - H(T, C_L)
+	lo, hi = c.hash(tweak, dst[:bulklen])
+	sub128(&r, lo, hi)
+	copy(dst[bulklen:textlen], r[:])
+}
+
+// the hash H(T, L) = Poly1305_KT(bitlen(L) || T) + NH-Poly1305(pad(L)) mod 2^128
+func (c *Cipher) hash(tweak, bulk []byte) (lo, hi uint64) {
+	p := newPoly1305(c.tweakH)
+	var header [16]byte
+	binary.LittleEndian.PutUint64(header[:], uint64(len(bulk))*8)
+	p.update(header[:])
+	p.update(tweak)
+	lo, hi = p.sum()
+
+	// the message is zero-padded to a multiple of 16 bytes
+	full := len(bulk) / 16 * 16
+	m := bulk
+	if full < len(bulk) {
+		m = make([]byte, full+16)
+		copy(m, bulk)
+	}
+	mlo, mhi := c.messageH.hash(m)
+
+	var carry uint64
+	lo, carry = bits.Add64(lo, mlo, 0)
+	hi, _ = bits.Add64(hi, mhi, carry)
+	return
+}
+
+// add a 128-bit value to a little-endian block
+func add128(b *[blockSize]byte, lo, hi uint64) {
+	var carry uint64
+	blo, carry := bits.Add64(binary.LittleEndian.Uint64(b[:8]), lo, 0)
+	bhi, _ := bits.Add64(binary.LittleEndian.Uint64(b[8:]), hi, carry)
+	binary.LittleEndian.PutUint64(b[:8], blo)
+	binary.LittleEndian.PutUint64(b[8:], bhi)
+}
+
+// subtract a 128-bit value from a little-endian block
+func sub128(b *[blockSize]byte, lo, hi uint64) {
+	var borrow uint64
+	blo, borrow := bits.Sub64(binary.LittleEndian.Uint64(b[:8]), lo, 0)
+	bhi, _ := bits.Sub64(binary.LittleEndian.Uint64(b[8:]), hi, borrow)
+	binary.LittleEndian.PutUint64(b[:8], blo)
+	binary.LittleEndian.PutUint64(b[8:], bhi)
+}
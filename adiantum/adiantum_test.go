@@ -0,0 +1,111 @@
+package adiantum_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/adiantum"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+func TestAdiantum(t *testing.T) {
+
+	key := make([]byte, adiantum.KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	c, err := adiantum.New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adiantum.New(key[:16]); err == nil {
+		t.Errorf("invalid key accepted")
+	}
+	tweak := make([]byte, 32)
+
+	lengths := []int{16, 17, 31, 32, 33, 100, 512, 1023, 1024, 1025, 4096, 4111}
+	for _, n := range lengths {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		enc := make([]byte, n)
+		c.Encrypt(enc, data, tweak)
+
+		// a single bit flip at either end changes the whole ciphertext
+		for _, pos := range []int{0, n - 1} {
+			data[pos] ^= 1
+			enc2 := make([]byte, n)
+			c.Encrypt(enc2, data, tweak)
+			data[pos] ^= 1
+			for j := 0; j < n; j += 16 {
+				e := j + 16
+				if e > n {
+					e = n
+				}
+				if bytes.Equal(enc[j:e], enc2[j:e]) {
+					t.Errorf("bit flip not diffused: length %d, pos %d, offset %d", n, pos, j)
+				}
+			}
+		}
+
+		// in-place decryption
+		c.Decrypt(enc, enc, tweak)
+		if !bytes.Equal(enc, data) {
+			t.Errorf("round trip failed: length %d", n)
+		}
+
+		// sectors
+		c.EncryptSector(enc, data, 3)
+		c.DecryptSector(enc, enc, 3)
+		if !bytes.Equal(enc, data) {
+			t.Errorf("sector round trip failed: length %d", n)
+		}
+	}
+}
+
+// a test vector of the reference implementation, github.com/google/adiantum, test_vectors/ours/Adiantum
+type referenceVector struct {
+	Description string `json:"description"`
+	Input       struct {
+		Key   vectors.HexBytes `json:"key_hex"`
+		Tweak vectors.HexBytes `json:"tweak_hex"`
+	} `json:"input"`
+	Plaintext  vectors.HexBytes `json:"plaintext_hex"`
+	Ciphertext vectors.HexBytes `json:"ciphertext_hex"`
+}
+
+// the known answers of the reference implementation; a subset of Adiantum_XChaCha12_32_AES256.json,
+// two vectors of each message and tweak length
+func TestReferenceVectors(t *testing.T) {
+
+	b, err := ioutil.ReadFile("testdata/Adiantum_XChaCha12_32_AES256.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests []referenceVector
+	if err := json.Unmarshal(b, &tests); err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) == 0 {
+		t.Fatal("no test vectors")
+	}
+	for i, v := range tests {
+		c, err := adiantum.New(v.Input.Key)
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		out := make([]byte, len(v.Plaintext))
+		c.Encrypt(out, v.Plaintext, v.Input.Tweak)
+		if !bytes.Equal(out, v.Ciphertext) {
+			t.Errorf("vector %d (%s, %d bytes, tweak %d bytes): encryption mismatch\n got %x\nwant %x",
+				i, v.Description, len(v.Plaintext), len(v.Input.Tweak), out, v.Ciphertext)
+		}
+		c.Decrypt(out, v.Ciphertext, v.Input.Tweak)
+		if !bytes.Equal(out, v.Plaintext) {
+			t.Errorf("vector %d (%s, %d bytes, tweak %d bytes): decryption mismatch", i, v.Description, len(v.Plaintext), len(v.Input.Tweak))
+		}
+	}
+}
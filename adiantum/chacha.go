@@ -0,0 +1,97 @@
+/*
+	chacha.go
+	2026-10, github.com/mixcode
+*/
+
+package adiantum
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const chachaRounds = 12 // XChaCha12
+
+// the ChaCha constant "expand 32-byte k"
+var sigma = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+func quarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d = bits.RotateLeft32(d^a, 16)
+	c += d
+	b = bits.RotateLeft32(b^c, 12)
+	a += b
+	d = bits.RotateLeft32(d^a, 8)
+	c += d
+	b = bits.RotateLeft32(b^c, 7)
+	return a, b, c, d
+}
+
+// chachaPermute applies the ChaCha rounds to a state, without the final addition
+func chachaPermute(x *[16]uint32, rounds int) {
+	for i := 0; i < rounds; i += 2 {
+		// column round
+		x[0], x[4], x[8], x[12] = quarterRound(x[0], x[4], x[8], x[12])
+		x[1], x[5], x[9], x[13] = quarterRound(x[1], x[5], x[9], x[13])
+		x[2], x[6], x[10], x[14] = quarterRound(x[2], x[6], x[10], x[14])
+		x[3], x[7], x[11], x[15] = quarterRound(x[3], x[7], x[11], x[15])
+		// diagonal round
+		x[0], x[5], x[10], x[15] = quarterRound(x[0], x[5], x[10], x[15])
+		x[1], x[6], x[11], x[12] = quarterRound(x[1], x[6], x[11], x[12])
+		x[2], x[7], x[8], x[13] = quarterRound(x[2], x[7], x[8], x[13])
+		x[3], x[4], x[9], x[14] = quarterRound(x[3], x[4], x[9], x[14])
+	}
+}
+
+// chachaBlock writes a 64-byte keystream block of an initial state into out
+func chachaBlock(out []byte, state *[16]uint32, rounds int) {
+	x := *state
+	chachaPermute(&x, rounds)
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i]+state[i])
+	}
+}
+
+// hchacha derives a subkey from a key and the first 16 bytes of an extended nonce
+func hchacha(key, nonce []byte, rounds int) [8]uint32 {
+	var x [16]uint32
+	copy(x[:4], sigma[:])
+	for i := 0; i < 8; i++ {
+		x[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	for i := 0; i < 4; i++ {
+		x[12+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	chachaPermute(&x, rounds)
+	return [8]uint32{x[0], x[1], x[2], x[3], x[12], x[13], x[14], x[15]}
+}
+
+// xchachaXORKeyStream XORs src with the XChaCha keystream of a 32-byte key and a 24-byte nonce, starting at block 0
+func xchachaXORKeyStream(dst, src, key, nonce []byte, rounds int) {
+	subkey := hchacha(key, nonce, rounds)
+
+	// the state of the original ChaCha: 64-bit block counter and 64-bit nonce
+	var state [16]uint32
+	copy(state[:4], sigma[:])
+	copy(state[4:12], subkey[:])
+	state[14] = binary.LittleEndian.Uint32(nonce[16:])
+	state[15] = binary.LittleEndian.Uint32(nonce[20:])
+
+	var ks [64]byte
+	for len(src) > 0 {
+		chachaBlock(ks[:], &state, rounds)
+		n := len(src)
+		if n > len(ks) {
+			n = len(ks)
+		}
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ ks[i]
+		}
+		dst, src = dst[n:], src[n:]
+
+		state[12]++
+		if state[12] == 0 {
+			state[13]++
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+	nh.go
+	2026-10, github.com/mixcode
+*/
+
+package adiantum
+
+import (
+	"encoding/binary"
+)
+
+const (
+	nhPasses     = 4
+	nhStride     = 2
+	nhMessageLen = 1024                                     // bytes hashed by NH at once
+	nhKeyLen     = nhMessageLen + nhStride*2*(nhPasses-1)*4 // 1072 bytes
+	nhHashLen    = 8 * nhPasses                             // NH output size
+	nhpolyKeyLen = 16 + nhKeyLen                            // Poly1305 key and NH key
+)
+
+// nh hashes a message of at most nhMessageLen bytes, a multiple of 16, with the NH key (as 32-bit words)
+func nh(key []uint32, m []byte, out []byte) {
+	var sums [nhPasses]uint64
+	for ; len(m) > 0; m = m[16:] {
+		m0 := binary.LittleEndian.Uint32(m[0:])
+		m1 := binary.LittleEndian.Uint32(m[4:])
+		m2 := binary.LittleEndian.Uint32(m[8:])
+		m3 := binary.LittleEndian.Uint32(m[12:])
+		for i := range sums {
+			k := key[4*i:]
+			sums[i] += uint64(m0+k[0]) * uint64(m2+k[2])
+			sums[i] += uint64(m1+k[1]) * uint64(m3+k[3])
+		}
+		key = key[4:]
+	}
+	for i, s := range sums {
+		binary.LittleEndian.PutUint64(out[8*i:], s)
+	}
+}
+
+// nhpoly1305 is the NH-Poly1305 hash of a message padded to a multiple of 16 bytes
+type nhpoly1305 struct {
+	polyKey []byte
+	nhKey   []uint32
+}
+
+func newNHPoly1305(key []byte) *nhpoly1305 {
+	h := &nhpoly1305{
+		polyKey: key[:16],
+		nhKey:   make([]uint32, nhKeyLen/4),
+	}
+	for i := range h.nhKey {
+		h.nhKey[i] = binary.LittleEndian.Uint32(key[16+4*i:])
+	}
+	return h
+}
+
+// hash hashes m, whose length must be a multiple of 16, into a 128-bit value
+func (h *nhpoly1305) hash(m []byte) (lo, hi uint64) {
+	p := newPoly1305(h.polyKey)
+	var out [nhHashLen]byte
+	for len(m) > 0 {
+		n := len(m)
+		if n > nhMessageLen {
+			n = nhMessageLen
+		}
+		nh(h.nhKey, m[:n], out[:])
+		p.update(out[:])
+		m = m[n:]
+	}
+	return p.sum()
+}
@@ -0,0 +1,87 @@
+/*
+	poly1305.go
+	2026-10, github.com/mixcode
+*/
+
+package adiantum
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// poly1305 evaluates the Poly1305 polynomial modulo 2^130-5.
+// Adiantum uses it as a universal hash without the final addition of the one-time key s.
+type poly1305 struct {
+	r0, r1     uint64 // clamped key
+	h0, h1, h2 uint64 // accumulator
+}
+
+func newPoly1305(key []byte) *poly1305 {
+	return &poly1305{
+		r0: binary.LittleEndian.Uint64(key[0:8]) & 0x0ffffffc0fffffff,
+		r1: binary.LittleEndian.Uint64(key[8:16]) & 0x0ffffffc0ffffffc,
+	}
+}
+
+// block adds a 16-byte block with the given high bit, and multiplies by r
+func (p *poly1305) block(m []byte, hibit uint64) {
+	var c uint64
+	p.h0, c = bits.Add64(p.h0, binary.LittleEndian.Uint64(m[0:8]), 0)
+	p.h1, c = bits.Add64(p.h1, binary.LittleEndian.Uint64(m[8:16]), c)
+	p.h2 += c + hibit
+
+	// h * r; h2 is at most a few bits, and r is clamped so no product overflows
+	h0r0hi, h0r0lo := bits.Mul64(p.h0, p.r0)
+	h1r0hi, h1r0lo := bits.Mul64(p.h1, p.r0)
+	h0r1hi, h0r1lo := bits.Mul64(p.h0, p.r1)
+	h1r1hi, h1r1lo := bits.Mul64(p.h1, p.r1)
+	h2r0 := p.h2 * p.r0
+	h2r1 := p.h2 * p.r1
+
+	m1lo, c := bits.Add64(h1r0lo, h0r1lo, 0)
+	m1hi, _ := bits.Add64(h1r0hi, h0r1hi, c)
+	m2lo, c := bits.Add64(h2r0, h1r1lo, 0)
+	m2hi, _ := bits.Add64(0, h1r1hi, c)
+
+	t0 := h0r0lo
+	t1, c := bits.Add64(m1lo, h0r0hi, 0)
+	t2, c := bits.Add64(m2lo, m1hi, c)
+	t3, _ := bits.Add64(h2r1, m2hi, c)
+
+	// reduce modulo 2^130-5: the bits above 2^130 are multiplied by 5 and added back
+	p.h0, p.h1, p.h2 = t0, t1, t2&3
+	cclo, cchi := t2&^3, t3
+	p.h0, c = bits.Add64(p.h0, cclo, 0)
+	p.h1, c = bits.Add64(p.h1, cchi, c)
+	p.h2 += c
+	cclo, cchi = cclo>>2|cchi<<62, cchi>>2
+	p.h0, c = bits.Add64(p.h0, cclo, 0)
+	p.h1, c = bits.Add64(p.h1, cchi, c)
+	p.h2 += c
+}
+
+// update hashes a message; a final partial block is padded with a one byte and zeros, as in standard Poly1305
+func (p *poly1305) update(m []byte) {
+	for ; len(m) >= 16; m = m[16:] {
+		p.block(m, 1)
+	}
+	if len(m) > 0 {
+		var b [16]byte
+		copy(b[:], m)
+		b[len(m)] = 1
+		p.block(b[:], 0)
+	}
+}
+
+// sum returns the fully reduced accumulator modulo 2^128, as two little-endian words
+func (p *poly1305) sum() (lo, hi uint64) {
+	// subtract 2^130-5 if the accumulator is not smaller
+	t0, b := bits.Sub64(p.h0, 0xfffffffffffffffb, 0)
+	t1, b := bits.Sub64(p.h1, 0xffffffffffffffff, b)
+	_, b = bits.Sub64(p.h2, 3, b)
+	if b == 0 {
+		return t0, t1
+	}
+	return p.h0, p.h1
+}
@@ -0,0 +1,63 @@
+package adiantum
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+	"testing"
+)
+
+func TestPrimitives(t *testing.T) {
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	key := unhex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+
+	// RFC 7539 2.3.2, ChaCha20 block function
+	var state [16]uint32
+	copy(state[:4], sigma[:])
+	for i := 0; i < 8; i++ {
+		state[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	state[12] = 1
+	nonce := unhex("000000090000004a00000000")
+	for i := 0; i < 3; i++ {
+		state[13+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	out := make([]byte, 64)
+	chachaBlock(out, &state, 20)
+	expect := "10f1e7e4d13b5915500fdd1fa32071c4c7d1f4c733c068030422aa9ac3d46c4e" +
+		"d2826446079faa0914c2d705d98b02a2b5129cd1de164eb9cbd083e8a2503c4e"
+	if hex.EncodeToString(out) != expect {
+		t.Errorf("chacha20: expected %s, got %x", expect, out)
+	}
+
+	// draft-irtf-cfrg-xchacha 2.2.1, HChaCha20
+	sub := hchacha(key, unhex("000000090000004a0000000031415927"), 20)
+	for i, w := range sub {
+		binary.LittleEndian.PutUint32(out[4*i:], w)
+	}
+	expect = "82413b4227b27bfed30e42508a877d73a0f9e4d58a74a853c12ec41326d3ecdc"
+	if hex.EncodeToString(out[:32]) != expect {
+		t.Errorf("hchacha20: expected %s, got %x", expect, out[:32])
+	}
+
+	// RFC 7539 2.5.2, Poly1305 with the final addition of s
+	pkey := unhex("85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b")
+	p := newPoly1305(pkey)
+	p.update([]byte("Cryptographic Forum Research Group"))
+	lo, hi := p.sum()
+	var c uint64
+	lo, c = bits.Add64(lo, binary.LittleEndian.Uint64(pkey[16:]), 0)
+	hi, _ = bits.Add64(hi, binary.LittleEndian.Uint64(pkey[24:]), c)
+	binary.LittleEndian.PutUint64(out[0:], lo)
+	binary.LittleEndian.PutUint64(out[8:], hi)
+	expect = "a8061dc1305136c6c22b8baf0c0127a9"
+	if hex.EncodeToString(out[:16]) != expect {
+		t.Errorf("poly1305: expected %s, got %x", expect, out[:16])
+	}
+}
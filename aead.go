@@ -0,0 +1,209 @@
+/*
+	aead.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/mixcode/golib-cbccts/cmac"
+)
+
+// cbcctsAEAD is an authenticated encryption with CBC-CTS and a MAC (HMAC or CMAC), composed in encrypt-then-MAC order.
+type cbcctsAEAD struct {
+	block cipher.Block
+	mode  Format
+	mac   func() hash.Hash // returns a keyed MAC
+}
+
+// NewAEAD creates a cipher.AEAD which encrypts the plaintext in CBC-CTS mode and authenticates the ciphertext with HMAC.
+//
+// The nonce is one block long. The CBC IV is the nonce encrypted with the block cipher, as recommended in NIST SP 800-38A Appendix C,
+// so a simple message counter is safe to use as a nonce. A nonce must never be used twice with the same key.
+//
+// The tag is HMAC(macKey, additionalData || nonce || ciphertext || bit length of additionalData), the layout of RFC 7518.
+// macKey must be independent of the block cipher key.
+//
+// Seal panics if the plaintext is shorter than one block, unless it is empty.
+func NewAEAD(block cipher.Block, f Format, h func() hash.Hash, macKey []byte) (cipher.AEAD, error) {
+	if len(macKey) == 0 {
+		return nil, errors.New("cbccts: empty MAC key")
+	}
+	key := append([]byte(nil), macKey...)
+	return newAEAD(block, f, func() hash.Hash { return hmac.New(h, key) })
+}
+
+// NewCMACAEAD creates a cipher.AEAD like NewAEAD, but authenticates the ciphertext with CMAC of macBlock instead of HMAC.
+// This allows an authenticated encryption only with block ciphers, e.g. two AES keys derived from a single master key.
+// macBlock must be keyed independently of block.
+func NewCMACAEAD(block cipher.Block, f Format, macBlock cipher.Block) (cipher.AEAD, error) {
+	bs := macBlock.BlockSize()
+	if bs != 8 && bs != 16 {
+		return nil, errors.New("cbccts: unsupported block size for CMAC")
+	}
+	return newAEAD(block, f, func() hash.Hash { return cmac.New(macBlock) })
+}
+
+// create an AEAD with a keyed MAC
+func newAEAD(block cipher.Block, f Format, mac func() hash.Hash) (cipher.AEAD, error) {
+	if f < CS1 || f > CS3 {
+		return nil, errors.New("cbccts: invalid mode")
+	}
+	return &cbcctsAEAD{
+		block: block,
+		mode:  f,
+		mac:   mac,
+	}, nil
+}
+
+func (a *cbcctsAEAD) NonceSize() int {
+	return a.block.BlockSize()
+}
+
+func (a *cbcctsAEAD) Overhead() int {
+	return a.mac().Size()
+}
+
+func (a *cbcctsAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != a.NonceSize() {
+		panic("cbccts: incorrect nonce length given to AEAD")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	ciphertext, tag := out[:len(plaintext)], out[len(plaintext):]
+
+	NewCBCCTSEncrypter(a.block, a.iv(nonce), a.mode).CryptBlocks(ciphertext, plaintext)
+	copy(tag, a.tag(nonce, ciphertext, additionalData))
+	return ret
+}
+
+func (a *cbcctsAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != a.NonceSize() {
+		panic("cbccts: incorrect nonce length given to AEAD")
+	}
+	tagsz := a.Overhead()
+	if len(ciphertext) < tagsz {
+		return nil, ErrAuthentication
+	}
+	body, tag := ciphertext[:len(ciphertext)-tagsz], ciphertext[len(ciphertext)-tagsz:]
+	if len(body) > 0 && len(body) < a.block.BlockSize() {
+		return nil, ErrAuthentication
+	}
+
+	// verify before decryption
+	if subtle.ConstantTimeCompare(tag, a.tag(nonce, body, additionalData)) != 1 {
+		return nil, ErrAuthentication
+	}
+
+	ret, out := sliceForAppend(dst, len(body))
+	NewCBCCTSDecrypter(a.block, a.iv(nonce), a.mode).CryptBlocks(out, body)
+	return ret, nil
+}
+
+// committingAEAD appends a key commitment to the ciphertext of an AEAD.
+type committingAEAD struct {
+	cipher.AEAD
+	mac    func() hash.Hash // HMAC keyed with the commitment key
+	keyLen []byte           // the length of the master key, bound into the commitment
+}
+
+// NewCommittingAEAD creates a key-committing cipher.AEAD from a single master key.
+//
+// The encryption key, the MAC key and a commitment key are derived from key with HKDF, each under its own label and the length of key.
+// Each ciphertext carries a commitment HMAC(commitment key, label || key length || nonce), which is checked before the tag.
+// A ciphertext therefore decrypts under one key only, also among keys of different lengths such as K and K || 0...,
+// which protects systems deriving keys from passwords from partitioning-oracle style attacks.
+//
+// newCipher creates the block cipher (e.g. aes.NewCipher) with a key of the same length as key.
+// The output size of h must not be smaller than the key.
+func NewCommittingAEAD(newCipher func([]byte) (cipher.Block, error), key []byte, f Format, h func() hash.Hash) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, errors.New("cbccts: empty key")
+	}
+	if h().Size() < len(key) {
+		return nil, errors.New("cbccts: hash size too small for the key")
+	}
+	var keyLen [2]byte
+	binary.BigEndian.PutUint16(keyLen[:], uint16(len(key)))
+	prk := hkdf.Extract(h, key, nil)
+	derive := func(label string, n int) []byte {
+		out := make([]byte, n)
+		if _, err := io.ReadFull(hkdf.Expand(h, prk, append([]byte(label), keyLen[:]...)), out); err != nil {
+			panic(err) // n is within the limit of HKDF
+		}
+		return out
+	}
+
+	block, err := newCipher(derive("cbccts encryption key", len(key)))
+	if err != nil {
+		return nil, err
+	}
+	inner, err := NewAEAD(block, f, h, derive("cbccts MAC key", h().Size()))
+	if err != nil {
+		return nil, err
+	}
+	commitKey := derive("cbccts commitment key", h().Size())
+	return &committingAEAD{
+		AEAD:   inner,
+		mac:    func() hash.Hash { return hmac.New(h, commitKey) },
+		keyLen: keyLen[:],
+	}, nil
+}
+
+func (a *committingAEAD) Overhead() int {
+	return a.AEAD.Overhead() + a.mac().Size()
+}
+
+func (a *committingAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	ret := a.AEAD.Seal(dst, nonce, plaintext, additionalData)
+	return append(ret, a.commitment(nonce)...)
+}
+
+func (a *committingAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	csz := a.mac().Size()
+	if len(ciphertext) < csz {
+		return nil, ErrAuthentication
+	}
+	body, commitment := ciphertext[:len(ciphertext)-csz], ciphertext[len(ciphertext)-csz:]
+	if subtle.ConstantTimeCompare(commitment, a.commitment(nonce)) != 1 {
+		return nil, ErrAuthentication
+	}
+	return a.AEAD.Open(dst, nonce, body, additionalData)
+}
+
+// compute the key commitment for a nonce
+func (a *committingAEAD) commitment(nonce []byte) []byte {
+	m := a.mac()
+	m.Write([]byte("cbccts key commitment"))
+	m.Write(a.keyLen)
+	m.Write(nonce)
+	return m.Sum(nil)
+}
+
+// derive the CBC IV from a nonce
+func (a *cbcctsAEAD) iv(nonce []byte) []byte {
+	iv := make([]byte, a.block.BlockSize())
+	a.block.Encrypt(iv, nonce)
+	return iv
+}
+
+// compute the authentication tag
+func (a *cbcctsAEAD) tag(nonce, ciphertext, additionalData []byte) []byte {
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(len(additionalData))*8)
+	m := a.mac()
+	m.Write(additionalData)
+	m.Write(nonce)
+	m.Write(ciphertext)
+	m.Write(al[:])
+	return m.Sum(nil)
+}
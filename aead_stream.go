@@ -0,0 +1,121 @@
+/*
+	aead_stream.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// OpenStream reads a sealed message from r, and writes the plaintext to w only after the whole message is authenticated.
+// The message is buffered in memory. Nothing is written to w if the authentication fails.
+func OpenStream(a cipher.AEAD, w io.Writer, nonce []byte, r io.Reader, additionalData []byte) error {
+	sealed, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	pt, err := a.Open(sealed[:0], nonce, sealed, additionalData)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(pt)
+	return err
+}
+
+// OpenReaderAt opens a sealed message of size bytes in src in two passes, without buffering it:
+// the first pass verifies the tag, and the second pass decrypts and writes the plaintext to w.
+// No plaintext is written to w before the tag verifies.
+// src must not change between the passes, e.g. a file open for reading that no one else writes.
+// a must be an AEAD made by NewAEAD, NewCMACAEAD or NewCommittingAEAD.
+func OpenReaderAt(a cipher.AEAD, w io.Writer, nonce []byte, src io.ReaderAt, size int64, additionalData []byte) error {
+	switch c := a.(type) {
+	case *cbcctsAEAD:
+		return c.openReaderAt(w, nonce, src, size, additionalData)
+	case *committingAEAD:
+		csz := int64(c.mac().Size())
+		if size < csz {
+			return ErrAuthentication
+		}
+		commitment := make([]byte, csz)
+		if _, err := src.ReadAt(commitment, size-csz); err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare(commitment, c.commitment(nonce)) != 1 {
+			return ErrAuthentication
+		}
+		return OpenReaderAt(c.AEAD, w, nonce, src, size-csz, additionalData)
+	}
+	return errors.New("cbccts: not an AEAD of this package")
+}
+
+func (a *cbcctsAEAD) openReaderAt(w io.Writer, nonce []byte, src io.ReaderAt, size int64, additionalData []byte) error {
+	if len(nonce) != a.NonceSize() {
+		panic("cbccts: incorrect nonce length given to AEAD")
+	}
+	blocksz := int64(a.block.BlockSize())
+	tagsz := int64(a.Overhead())
+	if size < tagsz {
+		return ErrAuthentication
+	}
+	bodylen := size - tagsz
+	if bodylen > 0 && bodylen < blocksz {
+		return ErrAuthentication
+	}
+
+	// the first pass: verify the tag, with the layout of tag()
+	tag := make([]byte, tagsz)
+	if _, err := src.ReadAt(tag, bodylen); err != nil {
+		return err
+	}
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(len(additionalData))*8)
+	m := a.mac()
+	m.Write(additionalData)
+	m.Write(nonce)
+	if _, err := io.Copy(m, io.NewSectionReader(src, 0, bodylen)); err != nil {
+		return err
+	}
+	m.Write(al[:])
+	if subtle.ConstantTimeCompare(tag, m.Sum(nil)) != 1 {
+		return ErrAuthentication
+	}
+	if bodylen == 0 {
+		return nil
+	}
+
+	// the second pass: the aligned prefix in CBC chunks, then the last two blocks in CBC-CTS
+	dec := NewCBCCTSDecrypter(a.block, a.iv(nonce), a.mode).(*Decrypter)
+	prefix := bodylen - bodylen%blocksz - 2*blocksz
+	if prefix < 0 {
+		prefix = 0
+	}
+	buf := make([]byte, fileSegmentSize)
+	for off := int64(0); off < prefix; {
+		n := prefix - off
+		if n > int64(len(buf)) {
+			n = int64(len(buf))
+		}
+		chunk := buf[:n]
+		if _, err := src.ReadAt(chunk, off); err != nil {
+			return err
+		}
+		dec.chain(chunk, chunk)
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+		off += n
+	}
+	tail := buf[:bodylen-prefix]
+	if _, err := src.ReadAt(tail, prefix); err != nil {
+		return err
+	}
+	dec.CryptBlocks(tail, tail)
+	_, err := w.Write(tail)
+	return err
+}
@@ -0,0 +1,54 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestOpenReaderAt(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	macBlock, _ := aes.NewCipher(bytes.Repeat([]byte{1}, 16))
+	hmacAEAD, _ := cbccts.NewAEAD(ac, cbccts.CS3, sha256.New, []byte("MAC key"))
+	cmacAEAD, _ := cbccts.NewCMACAEAD(ac, cbccts.CS1, macBlock)
+	committing, _ := cbccts.NewCommittingAEAD(aes.NewCipher, make([]byte, 32), cbccts.CS2, sha256.New)
+	nonce := make([]byte, aes.BlockSize)
+	ad := []byte("additional data")
+
+	for _, a := range []cipher.AEAD{hmacAEAD, cmacAEAD, committing} {
+		for _, n := range []int{0, 16, 17, 32, 100, 4<<20 + 40, 9<<20 + 7} {
+			pt := make([]byte, n)
+			rand.Read(pt)
+			sealed := a.Seal(nil, nonce, pt, ad)
+
+			var out bytes.Buffer
+			if err := cbccts.OpenReaderAt(a, &out, nonce, bytes.NewReader(sealed), int64(len(sealed)), ad); err != nil {
+				t.Fatalf("%T %d bytes: %v", a, n, err)
+			}
+			if !bytes.Equal(out.Bytes(), pt) {
+				t.Fatalf("%T %d bytes: decryption failed", a, n)
+			}
+
+			out.Reset()
+			if err := cbccts.OpenStream(a, &out, nonce, bytes.NewReader(sealed), ad); err != nil || !bytes.Equal(out.Bytes(), pt) {
+				t.Fatalf("%T %d bytes: OpenStream failed: %v", a, n, err)
+			}
+
+			// nothing is released from a forged message
+			sealed[len(sealed)/2] ^= 1
+			out.Reset()
+			if err := cbccts.OpenReaderAt(a, &out, nonce, bytes.NewReader(sealed), int64(len(sealed)), ad); err != cbccts.ErrAuthentication || out.Len() != 0 {
+				t.Errorf("%T %d bytes: forgery not detected: %v, %d bytes released", a, n, err, out.Len())
+			}
+			if err := cbccts.OpenStream(a, &out, nonce, bytes.NewReader(sealed), ad); err != cbccts.ErrAuthentication || out.Len() != 0 {
+				t.Errorf("%T %d bytes: forgery not detected by OpenStream", a, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/cmac"
+)
+
+func TestAEAD(t *testing.T) {
+
+	key := make([]byte, 0x20)
+	macKey := make([]byte, 0x20)
+	for i := range key {
+		key[i] = byte(i)
+		macKey[i] = byte(0x80 + i)
+	}
+	ac, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cbccts.NewAEAD(ac, 0, sha256.New, macKey); err == nil {
+		t.Errorf("invalid mode accepted")
+	}
+	if _, err := cbccts.NewAEAD(ac, cbccts.CS3, sha256.New, nil); err == nil {
+		t.Errorf("empty MAC key accepted")
+	}
+
+	nonce := make([]byte, aes.BlockSize)
+	nonce[len(nonce)-1] = 1
+	ad := []byte("associated data")
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		a, err := cbccts.NewAEAD(ac, mode, sha256.New, macKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.NonceSize() != aes.BlockSize || a.Overhead() != sha256.Size {
+			t.Fatalf("unexpected nonce or tag size")
+		}
+
+		lengths := []int{0}
+		for n := aes.BlockSize; n < 4*aes.BlockSize; n++ {
+			lengths = append(lengths, n)
+		}
+		for _, n := range lengths {
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(i * 3)
+			}
+
+			sealed := a.Seal(nil, nonce, data, ad)
+			if len(sealed) != n+a.Overhead() {
+				t.Fatalf("invalid ciphertext length: mode %d, length %d", mode, n)
+			}
+
+			// check the documented composition
+			iv := make([]byte, aes.BlockSize)
+			ac.Encrypt(iv, nonce)
+			body := make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(ac, iv, mode).CryptBlocks(body, data)
+			m := hmac.New(sha256.New, macKey)
+			m.Write(ad)
+			m.Write(nonce)
+			m.Write(body)
+			binary.Write(m, binary.BigEndian, uint64(len(ad)*8))
+			if !bytes.Equal(sealed, m.Sum(body)) {
+				t.Errorf("unexpected ciphertext: mode %d, length %d", mode, n)
+			}
+
+			opened, err := a.Open(nil, nonce, sealed, ad)
+			if err != nil || !bytes.Equal(opened, data) {
+				t.Errorf("open failed: mode %d, length %d, %v", mode, n, err)
+			}
+
+			// in-place operation
+			buf := append(make([]byte, 0, n+a.Overhead()), data...)
+			buf = a.Seal(buf[:0], nonce, buf, ad)
+			if !bytes.Equal(buf, sealed) {
+				t.Errorf("in-place seal failed: mode %d, length %d", mode, n)
+			}
+			buf, err = a.Open(buf[:0], nonce, buf, ad)
+			if err != nil || !bytes.Equal(buf, data) {
+				t.Errorf("in-place open failed: mode %d, length %d, %v", mode, n, err)
+			}
+
+			// tampering must be detected
+			if _, err := a.Open(nil, nonce, sealed, nil); err != cbccts.ErrAuthentication {
+				t.Errorf("altered associated data not detected: mode %d, length %d", mode, n)
+			}
+			for _, pos := range []int{0, len(sealed) / 2, len(sealed) - 1} {
+				sealed[pos] ^= 1
+				if _, err := a.Open(nil, nonce, sealed, ad); err != cbccts.ErrAuthentication {
+					t.Errorf("altered ciphertext not detected: mode %d, length %d, pos %d", mode, n, pos)
+				}
+				sealed[pos] ^= 1
+			}
+			if _, err := a.Open(nil, nonce, sealed[:len(sealed)-1], ad); err != cbccts.ErrAuthentication {
+				t.Errorf("truncated ciphertext not detected: mode %d, length %d", mode, n)
+			}
+		}
+	}
+}
+
+func TestCMACAEAD(t *testing.T) {
+
+	key := make([]byte, 0x20)
+	macKey := make([]byte, 0x10)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	for i := range macKey {
+		macKey[i] = byte(0x80 + i)
+	}
+	ac, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mc, err := aes.NewCipher(macKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := cbccts.NewCMACAEAD(ac, cbccts.CS3, mc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Overhead() != aes.BlockSize {
+		t.Fatalf("unexpected tag size %d", a.Overhead())
+	}
+
+	nonce := make([]byte, aes.BlockSize)
+	ad := []byte("associated data")
+	data := make([]byte, 3*aes.BlockSize+5)
+	for i := range data {
+		data[i] = byte(i * 3)
+	}
+
+	sealed := a.Seal(nil, nonce, data, ad)
+
+	// check the documented composition
+	iv := make([]byte, aes.BlockSize)
+	ac.Encrypt(iv, nonce)
+	body := make([]byte, len(data))
+	cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3).CryptBlocks(body, data)
+	m := cmac.New(mc)
+	m.Write(ad)
+	m.Write(nonce)
+	m.Write(body)
+	binary.Write(m, binary.BigEndian, uint64(len(ad)*8))
+	if !bytes.Equal(sealed, m.Sum(body)) {
+		t.Errorf("unexpected ciphertext")
+	}
+
+	opened, err := a.Open(nil, nonce, sealed, ad)
+	if err != nil || !bytes.Equal(opened, data) {
+		t.Errorf("open failed: %v", err)
+	}
+	sealed[0] ^= 1
+	if _, err := a.Open(nil, nonce, sealed, ad); err != cbccts.ErrAuthentication {
+		t.Errorf("altered ciphertext not detected")
+	}
+}
+
+func TestCommittingAEAD(t *testing.T) {
+
+	key := make([]byte, 0x20)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	otherKey := append([]byte(nil), key...)
+	otherKey[0] ^= 1
+
+	a, err := cbccts.NewCommittingAEAD(aes.NewCipher, key, cbccts.CS3, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cbccts.NewCommittingAEAD(aes.NewCipher, otherKey, cbccts.CS3, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Overhead() != 2*sha256.Size {
+		t.Fatalf("unexpected overhead %d", a.Overhead())
+	}
+
+	nonce := make([]byte, a.NonceSize())
+	ad := []byte("associated data")
+	for _, n := range []int{0, aes.BlockSize, 3*aes.BlockSize + 5} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i * 3)
+		}
+
+		sealed := a.Seal(nil, nonce, data, ad)
+		if len(sealed) != n+a.Overhead() {
+			t.Fatalf("invalid ciphertext length: length %d", n)
+		}
+		opened, err := a.Open(nil, nonce, sealed, ad)
+		if err != nil || !bytes.Equal(opened, data) {
+			t.Errorf("open failed: length %d, %v", n, err)
+		}
+
+		// another key must not open the ciphertext
+		if _, err := b.Open(nil, nonce, sealed, ad); err != cbccts.ErrAuthentication {
+			t.Errorf("ciphertext opened with another key: length %d", n)
+		}
+
+		// the commitment is checked
+		sealed[len(sealed)-1] ^= 1
+		if _, err := a.Open(nil, nonce, sealed, ad); err != cbccts.ErrAuthentication {
+			t.Errorf("altered commitment not detected: length %d", n)
+		}
+	}
+
+	// a zero-padded key of another length must not open the ciphertext, although HMAC pads its keys the same way
+	short, err := cbccts.NewCommittingAEAD(aes.NewCipher, key[:16], cbccts.CS3, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padded, err := cbccts.NewCommittingAEAD(aes.NewCipher, append(append([]byte(nil), key[:16]...), make([]byte, 16)...), cbccts.CS3, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed := short.Seal(nil, nonce, make([]byte, 3*aes.BlockSize), ad)
+	if opened, err := padded.Open(nil, nonce, sealed, ad); err != cbccts.ErrAuthentication {
+		t.Errorf("ciphertext of K opened with K || 0: %x, %v", opened, err)
+	}
+
+	// an invalid key size for the cipher is reported
+	if _, err := cbccts.NewCommittingAEAD(aes.NewCipher, key[:5], cbccts.CS3, sha256.New); err == nil {
+		t.Errorf("invalid key accepted")
+	}
+}
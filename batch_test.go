@@ -0,0 +1,89 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestBatch(t *testing.T) {
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	b, _ := aes.NewCipher(key)
+
+	msgs, ivs := make([][]byte, 1000), make([][]byte, 1000)
+	for i := range msgs {
+		msgs[i] = make([]byte, aes.BlockSize+i%50)
+		ivs[i] = make([]byte, aes.BlockSize)
+		rand.Read(msgs[i])
+		rand.Read(ivs[i])
+	}
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		ct := cbccts.EncryptBatch(b, mode, ivs, msgs, 4)
+		for i := range msgs {
+			want := make([]byte, len(msgs[i]))
+			cbccts.NewCBCCTSEncrypter(b, ivs[i], mode).CryptBlocks(want, msgs[i])
+			if !bytes.Equal(ct[i], want) {
+				t.Fatalf("CS%d message %d: unexpected ciphertext", mode, i)
+			}
+		}
+		pt := cbccts.DecryptBatch(b, mode, ivs, ct, 0)
+		for i := range msgs {
+			if !bytes.Equal(pt[i], msgs[i]) {
+				t.Fatalf("CS%d message %d: decryption failed", mode, i)
+			}
+		}
+	}
+}
+
+func TestBatchAll(t *testing.T) {
+
+	key := make([]byte, 16)
+	rand.Read(key)
+	b, _ := aes.NewCipher(key)
+
+	msgs, ivs := make([][]byte, 100), make([][]byte, 100)
+	for i := range msgs {
+		msgs[i] = make([]byte, aes.BlockSize+i)
+		ivs[i] = make([]byte, aes.BlockSize)
+		rand.Read(msgs[i])
+		rand.Read(ivs[i])
+	}
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		bt := cbccts.NewBatch(b, mode)
+		ct, err := bt.EncryptAll(msgs, ivs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range msgs {
+			want := make([]byte, len(msgs[i]))
+			cbccts.NewCBCCTSEncrypter(b, ivs[i], mode).CryptBlocks(want, msgs[i])
+			if !bytes.Equal(ct[i], want) {
+				t.Fatalf("CS%d record %d: unexpected ciphertext", mode, i)
+			}
+		}
+		pt, err := bt.DecryptAll(ct, ivs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range msgs {
+			if !bytes.Equal(pt[i], msgs[i]) {
+				t.Fatalf("CS%d record %d: decryption failed", mode, i)
+			}
+		}
+	}
+
+	bt := cbccts.NewBatch(b, cbccts.CS3)
+	if _, err := bt.EncryptAll([][]byte{make([]byte, 15)}, [][]byte{make([]byte, 16)}); err == nil {
+		t.Errorf("short record not detected")
+	}
+	if _, err := bt.EncryptAll([][]byte{make([]byte, 16)}, [][]byte{make([]byte, 8)}); err == nil {
+		t.Errorf("wrong IV size not detected")
+	}
+}
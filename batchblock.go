@@ -0,0 +1,101 @@
+/*
+	batchblock.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// BlockBatcher is an optional interface of a cipher.Block that processes many blocks in one call, in ECB mode,
+// as adapters of HSM or KMS ciphers do to save network round trips.
+// len(src) must be a multiple of the block size, and dst and src must overlap entirely or not at all.
+//
+// The decrypter of a BlockBatcher decrypts the whole ciphertext with a few DecryptBlocks calls instead of one Decrypt call per block.
+// CBC encryption chains every block on the previous ciphertext block and cannot be batched, so the encrypter still calls Encrypt.
+type BlockBatcher interface {
+	EncryptBlocks(dst, src []byte)
+	DecryptBlocks(dst, src []byte)
+}
+
+// a CBC decrypter of a BlockBatcher
+type batchCBCDecrypter struct {
+	b       cipher.Block
+	bb      BlockBatcher
+	iv      []byte
+	buf     []byte // ECB output, reused across calls
+	lastbuf []byte
+}
+
+// a CBC decrypter of b, batched if b implements BlockBatcher
+func newCBCDecrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	bb, ok := b.(BlockBatcher)
+	if !ok {
+		return cipher.NewCBCDecrypter(b, iv)
+	}
+	if len(iv) != b.BlockSize() {
+		panic(fmt.Errorf("IV length must equal block size"))
+	}
+	return &batchCBCDecrypter{
+		b:       b,
+		bb:      bb,
+		iv:      append([]byte(nil), iv...),
+		lastbuf: make([]byte, b.BlockSize()),
+	}
+}
+
+func (x *batchCBCDecrypter) BlockSize() int {
+	return x.b.BlockSize()
+}
+
+func (x *batchCBCDecrypter) SetIV(iv []byte) {
+	if len(iv) != len(x.iv) {
+		panic(fmt.Errorf("IV length must equal block size"))
+	}
+	copy(x.iv, iv)
+}
+
+func (x *batchCBCDecrypter) CryptBlocks(dst, src []byte) {
+	blocksz := len(x.iv)
+	if len(src)%blocksz != 0 {
+		panic(fmt.Errorf("input not full blocks"))
+	}
+	if len(dst) < len(src) {
+		panic(fmt.Errorf("output smaller than input"))
+	}
+	if len(src) == 0 {
+		return
+	}
+	if cap(x.buf) < len(src) {
+		x.buf = make([]byte, len(src))
+	}
+	buf := x.buf[:len(src)]
+	x.bb.DecryptBlocks(buf, src)
+	copy(x.lastbuf, src[len(src)-blocksz:])
+
+	// xor from the last block, so that an in-place dst does not overwrite a ciphertext block before it is used
+	for end := len(src); end > 0; end -= blocksz {
+		start := end - blocksz
+		prev := x.iv
+		if start > 0 {
+			prev = src[start-blocksz : start]
+		}
+		for i := start; i < end; i++ {
+			dst[i] = buf[i] ^ prev[i-start]
+		}
+	}
+	zero(buf) // the ECB output reveals the plaintext
+	x.iv, x.lastbuf = x.lastbuf, x.iv
+}
+
+// decrypt a single block in ECB mode
+func (cd *cbccts) decryptBlock(dst, src []byte) {
+	if bb, ok := cd.block.(BlockBatcher); ok {
+		bb.DecryptBlocks(dst, src)
+		return
+	}
+	cd.block.Decrypt(dst, src)
+}
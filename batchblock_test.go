@@ -0,0 +1,70 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// a block cipher that counts its calls, as an HSM adapter would count round trips
+type batchBlock struct {
+	cipher.Block
+	single, batched int
+}
+
+func (b *batchBlock) Decrypt(dst, src []byte) {
+	b.single++
+	b.Block.Decrypt(dst, src)
+}
+
+func (b *batchBlock) EncryptBlocks(dst, src []byte) {
+	b.batched++
+	for i := 0; i < len(src); i += b.BlockSize() {
+		b.Block.Encrypt(dst[i:], src[i:])
+	}
+}
+
+func (b *batchBlock) DecryptBlocks(dst, src []byte) {
+	b.batched++
+	for i := 0; i < len(src); i += b.BlockSize() {
+		b.Block.Decrypt(dst[i:], src[i:])
+	}
+}
+
+func TestBlockBatcher(t *testing.T) {
+
+	key := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(key)
+	rand.Read(iv)
+	ac, _ := aes.NewCipher(key)
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		for _, n := range []int{16, 17, 32, 47, 64, 1000} {
+			pt := make([]byte, n)
+			rand.Read(pt)
+			ct := make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(ac, iv, mode).CryptBlocks(ct, pt)
+
+			bb := &batchBlock{Block: ac}
+			out := make([]byte, n)
+			cbccts.NewCBCCTSDecrypter(bb, iv, mode).CryptBlocks(out, ct)
+			if !bytes.Equal(out, pt) {
+				t.Errorf("CS%d %d bytes: batched decryption failed", mode, n)
+			}
+			if bb.single != 0 || bb.batched > 3 {
+				t.Errorf("CS%d %d bytes: %d single and %d batched calls", mode, n, bb.single, bb.batched)
+			}
+
+			copy(out, ct)
+			cbccts.NewCBCCTSDecrypter(bb, iv, mode).CryptBlocks(out, out)
+			if !bytes.Equal(out, pt) {
+				t.Errorf("CS%d %d bytes: in-place batched decryption failed", mode, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package cbccts_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// the benchmark matrix: key sizes, formats, sizes from 1 KiB to 64 MiB, and aligned or unaligned data
+func benchmarkCBCCTS(b *testing.B, newMode func(cipher.Block, []byte, cbccts.Format) cipher.BlockMode) {
+	iv := make([]byte, aes.BlockSize)
+	for _, keylen := range []int{16, 32} {
+		ac, _ := aes.NewCipher(make([]byte, keylen))
+		for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+			for _, size := range []int{1 << 10, 64 << 10, 1 << 20, 64 << 20} {
+				for _, extra := range []int{0, 5} {
+					align := "aligned"
+					if extra != 0 {
+						align = "unaligned"
+					}
+					name := fmt.Sprintf("AES-%d/CS%d/%dKiB/%s", keylen*8, mode, size>>10, align)
+					b.Run(name, func(b *testing.B) {
+						data := make([]byte, size+extra)
+						m := newMode(ac, iv, mode)
+						b.SetBytes(int64(len(data)))
+						b.ReportAllocs()
+						b.ResetTimer()
+						for i := 0; i < b.N; i++ {
+							m.CryptBlocks(data, data)
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	benchmarkCBCCTS(b, cbccts.NewCBCCTSEncrypter)
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	benchmarkCBCCTS(b, cbccts.NewCBCCTSDecrypter)
+}
+
+// CBC with PKCS#7 padding, the baseline of CBC-CTS: the padding is added or checked and removed with every message,
+// and the ciphertext is up to a block longer
+func pkcs7Pad(dst, src []byte, bs int) []byte {
+	n := bs - len(src)%bs
+	dst = append(dst[:0], src...)
+	for i := 0; i < n; i++ {
+		dst = append(dst, byte(n))
+	}
+	return dst
+}
+
+func pkcs7Unpad(b []byte, bs int) ([]byte, error) {
+	n := int(b[len(b)-1])
+	if n == 0 || n > bs || n > len(b) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	for _, c := range b[len(b)-n:] {
+		if int(c) != n {
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+	return b[:len(b)-n], nil
+}
+
+// message sizes from a block to 1 MiB, aligned and not, where the overhead of the tail shows most on the short ones
+var versusSizes = []int{16, 17, 31, 32, 64, 100, 1 << 10, 1<<10 + 5, 64 << 10, 64<<10 + 5, 1 << 20, 1<<20 + 5}
+
+func BenchmarkVersusPKCS7Encrypt(b *testing.B) {
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	for _, size := range versusSizes {
+		msg := make([]byte, size)
+		b.Run(fmt.Sprintf("CTS/%dB", size), func(b *testing.B) {
+			m := cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3)
+			out := make([]byte, size)
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.CryptBlocks(out, msg)
+			}
+		})
+		b.Run(fmt.Sprintf("PKCS7/%dB", size), func(b *testing.B) {
+			m := cipher.NewCBCEncrypter(ac, iv)
+			out := make([]byte, 0, size+aes.BlockSize)
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				out = pkcs7Pad(out, msg, aes.BlockSize)
+				m.CryptBlocks(out, out)
+			}
+		})
+	}
+}
+
+func BenchmarkVersusPKCS7Decrypt(b *testing.B) {
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	for _, size := range versusSizes {
+		msg := make([]byte, size)
+		b.Run(fmt.Sprintf("CTS/%dB", size), func(b *testing.B) {
+			ct := make([]byte, size)
+			cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3).CryptBlocks(ct, msg)
+			m := cbccts.NewCBCCTSDecrypter(ac, iv, cbccts.CS3).(interface {
+				cipher.BlockMode
+				Reset(iv []byte)
+			})
+			out := make([]byte, size)
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.Reset(iv)
+				m.CryptBlocks(out, ct)
+			}
+		})
+		b.Run(fmt.Sprintf("PKCS7/%dB", size), func(b *testing.B) {
+			ct := pkcs7Pad(nil, msg, aes.BlockSize)
+			cipher.NewCBCEncrypter(ac, iv).CryptBlocks(ct, ct)
+			m := cipher.NewCBCDecrypter(ac, iv).(interface {
+				cipher.BlockMode
+				SetIV(iv []byte)
+			})
+			out := make([]byte, len(ct))
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.SetIV(iv)
+				m.CryptBlocks(out, ct)
+				if _, err := pkcs7Unpad(out, aes.BlockSize); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
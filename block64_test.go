@@ -0,0 +1,131 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"io"
+	"testing"
+
+	"golang.org/x/crypto/blowfish"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// CBC-CTS built from the CBC mode of the standard library: CBC of the zero-padded message,
+// with the last two blocks swapped (CS3) and the next to last one truncated; also the last CBC block, the residue
+func stdlibCTS(b cipher.Block, iv []byte, f cbccts.Format, pt []byte) (ct, residue []byte) {
+	bs, n := b.BlockSize(), len(pt)
+	padded := make([]byte, (n+bs-1)/bs*bs)
+	copy(padded, pt)
+	cipher.NewCBCEncrypter(b, iv).CryptBlocks(padded, padded)
+	residue = padded[len(padded)-bs:]
+	d := n % bs
+	if n == bs || d == 0 && f != cbccts.CS3 {
+		return padded, residue
+	}
+	if d == 0 {
+		d = bs
+	}
+	last, prev := padded[len(padded)-bs:], padded[len(padded)-2*bs : len(padded)-bs][:d]
+	out := append([]byte(nil), padded[:len(padded)-2*bs]...)
+	if f == cbccts.CS1 {
+		return append(append(out, prev...), last...), residue
+	}
+	return append(append(out, last...), prev...), residue
+}
+
+// 8-byte block ciphers, as used by 3DES-CTS systems, around the single block and the first partial block
+func TestBlock64(t *testing.T) {
+
+	desCipher, _ := des.NewCipher([]byte("des key!"))
+	tdesCipher, _ := des.NewTripleDESCipher([]byte("triple des key, 24 bytes"))
+	bfCipher, _ := blowfish.NewCipher([]byte("blowfish key"))
+	iv := []byte("8byte iv")
+
+	for name, b := range map[string]cipher.Block{"DES": desCipher, "3DES": tdesCipher, "Blowfish": bfCipher} {
+		if b.BlockSize() != 8 {
+			t.Fatalf("%s: block size %d", name, b.BlockSize())
+		}
+		for _, n := range []int{8, 9, 10, 15, 16, 17, 23, 24, 25, 1000, 1001} {
+			pt := make([]byte, n)
+			for i := range pt {
+				pt[i] = byte(i*13 + n)
+			}
+			for _, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+				expect, residue := stdlibCTS(b, iv, f, pt)
+				ct := make([]byte, n)
+				cbccts.NewCBCCTSEncrypter(b, iv, f).CryptBlocks(ct, pt)
+				if !bytes.Equal(ct, expect) {
+					t.Fatalf("%s CS%d, %d bytes: unexpected ciphertext %x, expected %x", name, f, n, ct, expect)
+				}
+				if !bytes.Equal(cbccts.CBCResidue(ct, 8, f), residue) {
+					t.Errorf("%s CS%d, %d bytes: unexpected residue", name, f, n)
+				}
+
+				// the decrypters
+				out := make([]byte, n)
+				cbccts.NewCBCCTSDecrypter(b, iv, f).CryptBlocks(out, ct)
+				if !bytes.Equal(out, pt) {
+					t.Fatalf("%s CS%d, %d bytes: decryption failed", name, f, n)
+				}
+				dec := cbccts.NewCBCCTSParallelDecrypter(b, iv, f, 4)
+				dec.CryptBlocks(out, ct)
+				if !bytes.Equal(out, pt) {
+					t.Fatalf("%s CS%d, %d bytes: parallel decryption failed", name, f, n)
+				}
+				dec = cbccts.WithScratch(cbccts.NewCBCCTSDecrypter(b, iv, f), make([]byte, cbccts.ScratchSize(8)))
+				if allocs := testing.AllocsPerRun(10, func() { dec.CryptBlocks(out, ct) }); allocs != 0 {
+					t.Errorf("%s CS%d, %d bytes: %v allocations with a scratch buffer", name, f, n, allocs)
+				}
+
+				// a stream
+				var buf bytes.Buffer
+				w := cbccts.NewWriter(&buf, cbccts.NewCBCCTSEncrypter(b, iv, f))
+				w.Write(pt)
+				if err := w.Close(); err != nil || !bytes.Equal(buf.Bytes(), ct) {
+					t.Fatalf("%s CS%d, %d bytes: stream encryption failed: %v", name, f, n, err)
+				}
+				if out, err := io.ReadAll(cbccts.NewReader(&buf, cbccts.NewCBCCTSDecrypter(b, iv, f))); err != nil || !bytes.Equal(out, pt) {
+					t.Fatalf("%s CS%d, %d bytes: stream decryption failed: %v", name, f, n, err)
+				}
+			}
+		}
+
+		// long enough for the parallel decrypter to split it into segments
+		pt := make([]byte, 300001)
+		for i := range pt {
+			pt[i] = byte(i)
+		}
+		ct := make([]byte, len(pt))
+		cbccts.NewCBCCTSEncrypter(b, iv, cbccts.CS3).CryptBlocks(ct, pt)
+		cbccts.NewCBCCTSParallelDecrypter(b, iv, cbccts.CS3, 4).CryptBlocks(ct, ct)
+		if !bytes.Equal(ct, pt) {
+			t.Errorf("%s: parallel decryption of %d bytes failed", name, len(pt))
+		}
+
+		// shorter than a block
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: 7 bytes accepted", name)
+				}
+			}()
+			cbccts.NewCBCCTSEncrypter(b, iv, cbccts.CS3).CryptBlocks(make([]byte, 7), make([]byte, 7))
+		}()
+	}
+
+	// the CMAC AEAD of 3DES
+	mac, _ := des.NewTripleDESCipher([]byte("another triple des key!!"))
+	aead, err := cbccts.NewCMACAEAD(tdesCipher, cbccts.CS3, mac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 8, 9} {
+		msg := make([]byte, n)
+		sealed := aead.Seal(nil, iv, msg, nil)
+		if out, err := aead.Open(nil, iv, sealed, nil); err != nil || !bytes.Equal(out, msg) {
+			t.Errorf("3DES AEAD, %d bytes: %v", n, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"math/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/internal/ref"
+)
+
+// every length from one block to 4 blocks and a block less, for every format and every pairing of an encrypter and a decrypter
+func TestBoundaryLengths(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(2))
+	desCipher, _ := des.NewCipher([]byte("8bytekey"))
+	aesCipher, _ := aes.NewCipher([]byte("a sixteen by key"))
+	formats := []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3}
+
+	for _, b := range []cipher.Block{desCipher, aesCipher, &wideBlock{}} {
+		bs := b.BlockSize()
+		iv := make([]byte, bs)
+		rnd.Read(iv)
+
+		for n := bs; n <= 4*bs+bs-1; n++ {
+			pt := make([]byte, n)
+			rnd.Read(pt)
+
+			// the format each one is the same as at this length
+			same := func(f cbccts.Format) cbccts.Format {
+				switch {
+				case n == bs:
+					return cbccts.CS1 // a single block is plain CBC in all formats
+				case f == cbccts.CS2 && n%bs == 0:
+					return cbccts.CS1
+				case f == cbccts.CS2:
+					return cbccts.CS3
+				}
+				return f
+			}
+
+			for _, ef := range formats {
+				ct := make([]byte, n)
+				cbccts.NewCBCCTSEncrypter(b, iv, ef).CryptBlocks(ct, pt)
+				if !bytes.Equal(ct, ref.Encrypt(b, iv, ef, pt)) {
+					t.Fatalf("block size %d, CS%d, %d bytes: encryption differs from the reference", bs, ef, n)
+				}
+				inPlace := append([]byte(nil), pt...)
+				cbccts.NewCBCCTSEncrypter(b, iv, ef).CryptBlocks(inPlace, inPlace)
+				if !bytes.Equal(inPlace, ct) {
+					t.Fatalf("block size %d, CS%d, %d bytes: in-place encryption differs", bs, ef, n)
+				}
+
+				for _, df := range formats {
+					out := make([]byte, n)
+					cbccts.NewCBCCTSDecrypter(b, iv, df).CryptBlocks(out, ct)
+					if ok := bytes.Equal(out, pt); ok != (same(ef) == same(df)) {
+						t.Fatalf("block size %d, %d bytes: CS%d decryption of CS%d gives the plaintext: %v", bs, n, df, ef, ok)
+					}
+					if df == ef {
+						cbccts.NewCBCCTSDecrypter(b, iv, df).CryptBlocks(ct, ct)
+						if !bytes.Equal(ct, pt) {
+							t.Fatalf("block size %d, CS%d, %d bytes: in-place decryption failed", bs, df, n)
+						}
+						cbccts.NewCBCCTSEncrypter(b, iv, ef).CryptBlocks(ct, ct)
+					}
+				}
+			}
+		}
+	}
+}
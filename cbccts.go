@@ -27,75 +27,206 @@ const (
 	CS3 Format = 3 // A full block precedes a partial block.
 )
 
-// CBC-CTS-Decrypter is a cipher.BlockMode interface which decrypts ciphers in CBC-CTS mode.
+// the state shared by the CBC-CTS encrypter and decrypter.
 // CTS means "Ciphertext Stealing", an encoding scheme for data not aligned for block boundaries; i.e. arbitrary length data.
 type cbccts struct {
-	encoder bool // if true, use
-	block   cipher.Block
-	codec   cipher.BlockMode
-	mode    Format
+	block cipher.Block
+	codec cipher.BlockMode
+	mode  Format
+
+	// fixed-size scratch of the tail blocks for 16-byte block ciphers like AES.
+	// they live in the codec rather than on the stack, as slices passed to cipher.Block methods escape to the heap.
+	tail16 [2 * 16]byte
+	d16    [16]byte
+
+	workers int    // number of goroutines of the parallel decrypter; 0 for serial
+	chunk   int    // bytes of aligned blocks per call to the CBC codec; 0 for DefaultChunkSize
+	ext     []byte // caller-provided scratch of three blocks, or nil
+	state   []byte // the chaining value, the last ciphertext block passed through the CBC codec
+	release func() // releases the locked memory of WithLockedMemory, or nil
+	ct      bool   // process the tail in constant time
+
+	indicator func(approved bool) // the service indicator, or nil
+	approved  bool                // whether the block cipher is an approved one
+}
+
+// Encrypter is a cipher.BlockMode which encrypts data in CBC-CTS mode.
+type Encrypter struct {
+	cbccts
+}
+
+// Decrypter is a cipher.BlockMode which decrypts ciphers in CBC-CTS mode.
+type Decrypter struct {
+	cbccts
+}
+
+// scratch buffers of two blocks and one block for the tail processing
+func (cd *cbccts) scratch(blocksz int) (tail, d []byte) {
+	if cd.ext != nil {
+		return cd.ext[:2*blocksz], cd.ext[2*blocksz : 3*blocksz]
+	}
+	if blocksz == 16 {
+		return cd.tail16[:], cd.d16[:]
+	}
+	return make([]byte, 2*blocksz), make([]byte, blocksz)
+}
+
+// Reset restarts the codec with a new IV, keeping the block cipher and the scratch buffers.
+func (cd *cbccts) Reset(iv []byte) {
+	cd.codec.(ivSetter).SetIV(iv)
+}
+
+// CipherState returns a copy of the current chaining value: the IV for the next call to CryptBlocks.
+// After a call of aligned data in CS1, this is the last ciphertext block, as in plain CBC.
+func (cd *cbccts) CipherState() []byte {
+	return append([]byte(nil), cd.state...)
 }
 
 func (cd *cbccts) BlockSize() int {
 	return cd.codec.BlockSize()
 }
 
-// NewCBCCTSEncrypter creates a new CBC-CTS encrypter, compatible with cipher.BlockMode.
+// NewCBCCTSEncrypter creates a new CBC-CTS encrypter, compatible with cipher.BlockMode. The returned value is an *Encrypter.
+// Like the CBC modes of the standard library, the codec keeps the chaining state and scratch buffers, and is not safe for concurrent use;
+// use a codec per goroutine, or wrap it with NewSafeBlockMode.
 func NewCBCCTSEncrypter(b cipher.Block, iv []byte, mode Format) cipher.BlockMode {
+	mustPassSelfTest()
+	return newEncrypter(b, iv, mode)
+}
+
+func newEncrypter(b cipher.Block, iv []byte, mode Format) *Encrypter {
 	if mode < CS1 || mode > CS3 {
 		panic(fmt.Errorf("invalid mode"))
 	}
-	return &cbccts{
-		encoder: true,
-		block:   b,
-		codec:   cipher.NewCBCEncrypter(b, iv),
-		mode:    mode,
-	}
+	state := append([]byte(nil), iv...)
+	return &Encrypter{cbccts{
+		block: b,
+		codec: &encChain{cipher.NewCBCEncrypter(b, iv), state},
+		mode:  mode,
+		state: state,
+	}}
 }
 
-// NewCBCCTSDecrypter creates a new CBC-CTS decrypter, compatible with cipher.BlockMode
+// NewCBCCTSDecrypter creates a new CBC-CTS decrypter, compatible with cipher.BlockMode. The returned value is a *Decrypter.
+// The decrypter is not safe for concurrent use, as is the encrypter.
 func NewCBCCTSDecrypter(b cipher.Block, iv []byte, mode Format) cipher.BlockMode {
+	mustPassSelfTest()
+	return newDecrypter(b, iv, mode)
+}
+
+func newDecrypter(b cipher.Block, iv []byte, mode Format) *Decrypter {
 	if mode < CS1 || mode > CS3 {
 		panic(fmt.Errorf("invalid mode"))
 	}
-	return &cbccts{
-		encoder: false,
-		block:   b,
-		codec:   cipher.NewCBCDecrypter(b, iv),
-		mode:    mode,
+	state := append([]byte(nil), iv...)
+	return &Decrypter{cbccts{
+		block: b,
+		codec: &decChain{newCBCDecrypter(b, iv), state},
+		mode:  mode,
+		state: state,
+	}}
+}
+
+// CryptBlocks encrypts src to dst. dst and src must overlap entirely or not at all.
+func (e *Encrypter) CryptBlocks(dst, src []byte) {
+	e.encode(dst, src)
+	if e.indicator != nil {
+		e.indicator(e.approved)
 	}
 }
 
-// Execute the cipher work
-func (cd *cbccts) CryptBlocks(dst, src []byte) {
-	if cd.encoder {
-		cd.encode(dst, src)
-	} else {
-		cd.decode(dst, src)
+// CryptBlocks decrypts src to dst. dst and src must overlap entirely or not at all.
+func (d *Decrypter) CryptBlocks(dst, src []byte) {
+	d.decode(dst, src)
+	if d.indicator != nil {
+		d.indicator(d.approved)
 	}
 }
 
+// place the chaining value in b, which holds its current value
+func (cd *cbccts) setState(b []byte) {
+	switch c := cd.codec.(type) {
+	case *encChain:
+		c.state = b
+	case *decChain:
+		c.state = b
+	}
+	cd.state = b
+}
+
+// CBC codecs recording the chaining value, which the standard library keeps private
+type encChain struct {
+	cipher.BlockMode
+	state []byte
+}
+
+func (c *encChain) CryptBlocks(dst, src []byte) {
+	c.BlockMode.CryptBlocks(dst, src)
+	if n := len(src); n > 0 {
+		copy(c.state, dst[n-len(c.state):n])
+	}
+}
+
+func (c *encChain) SetIV(iv []byte) {
+	c.BlockMode.(ivSetter).SetIV(iv)
+	copy(c.state, iv)
+}
+
+type decChain struct {
+	cipher.BlockMode
+	state []byte
+}
+
+func (c *decChain) CryptBlocks(dst, src []byte) {
+	if n := len(src); n > 0 {
+		copy(c.state, src[n-len(c.state):]) // before dst may overwrite it
+	}
+	c.BlockMode.CryptBlocks(dst, src)
+}
+
+func (c *decChain) SetIV(iv []byte) {
+	c.BlockMode.(ivSetter).SetIV(iv)
+	copy(c.state, iv)
+}
+
 // decrypt text in CBC-CTS mode
 func (cd *cbccts) encode(dst, src []byte) {
 	blocksz := cd.codec.BlockSize()
 	textlen := len(src)
+	if cd.ct && textlen > blocksz {
+		cd.encodeCT(dst, src)
+		return
+	}
 	leftover := textlen % blocksz
 
 	if leftover == 0 { // text aligned at block size
-		cd.codec.CryptBlocks(dst, src)
-
 		switch cd.mode {
 
 		case CS1, CS2:
 			// No final block swapping
+			cd.chain(dst, src)
 			return
 
 		case CS3:
 			// mode CS3: Swap the last two blocks
+			if textlen <= blocksz {
+				// a single block has nothing to swap
+				cd.chain(dst, src)
+				return
+			}
+			// the last two blocks are encrypted directly into their swapped positions
 			py, pz := textlen-2*blocksz, textlen-blocksz
-			tmp := make([]byte, blocksz)
+			cd.chain(dst[:py], src[:py])
+			if !sameStart(dst, src) {
+				cd.codec.CryptBlocks(dst[pz:], src[py:pz])
+				cd.codec.CryptBlocks(dst[py:pz], src[pz:])
+				return
+			}
+			// in place, the last plaintext block must be read before the second last ciphertext block is written over it
+			_, tmp := cd.scratch(blocksz)
+			cd.codec.CryptBlocks(dst[py:pz], src[py:pz])
 			copy(tmp, dst[py:pz])
-			copy(dst[py:pz], dst[pz:])
+			cd.codec.CryptBlocks(dst[py:pz], src[pz:])
 			copy(dst[pz:], tmp)
 			return
 
@@ -113,24 +244,29 @@ func (cd *cbccts) encode(dst, src []byte) {
 		panic(fmt.Errorf("data size too small; must be larger than one block"))
 	}
 
-	// encrypt aligned blocks
-	cd.codec.CryptBlocks(dst[:py], src[:py])
+	// the padded partial block is read first, as dst and src may overlap
+	tmp, _ := cd.scratch(blocksz)
+	tmp = tmp[:blocksz]
+	copy(tmp, src[pz:])
+	pad := tmp[leftover:]
+	for i := range pad {
+		pad[i] = 0 // zero padding; the range form compiles to a memclr
+	}
 
-	// process last two blocks
-	tmp := make([]byte, 2*blocksz)
-	copy(tmp[:blocksz+leftover], src[py:])
-	cd.codec.CryptBlocks(tmp, tmp)
+	// encrypt aligned blocks, including the last full block, directly into dst
+	cd.chain(dst[:pz], src[:pz])
 
 	switch cd.mode {
 	case CS1:
-		// retain the block order: partial blck precedes full block
-		copy(dst[py:py+leftover], tmp[:leftover]) // copy partial block
-		copy(dst[py+leftover:], tmp[blocksz:])    // copy full block
+		// retain the block order: partial blck precedes full block.
+		// the head of the last full block is already in place, and the padded block is encrypted over its rest
+		cd.codec.CryptBlocks(dst[py+leftover:], tmp)
 	case CS2, CS3:
 		// swap last two blocks and make the full block precedes the partial block
-		copy(dst[py:pz], tmp[blocksz:]) // copy the last full block
-		copy(dst[pz:], tmp[:leftover])  // copy partial block
+		copy(dst[pz:], dst[py:py+leftover])   // the partial block, stolen from the last full block
+		cd.codec.CryptBlocks(dst[py:pz], tmp) // the padded block, encrypted to the full block position
 	}
+	zero(tmp) // the plaintext of the partial block
 }
 
 // decrypt text in CBC-CTS mode
@@ -138,6 +274,10 @@ func (cd *cbccts) decode(dst, src []byte) {
 
 	blocksz := cd.codec.BlockSize()
 	textlen := len(src)
+	if cd.ct && textlen > blocksz {
+		cd.decodeCT(dst, src)
+		return
+	}
 
 	leftover := textlen % blocksz
 	if leftover == 0 { // src aligned at block boundary
@@ -145,16 +285,28 @@ func (cd *cbccts) decode(dst, src []byte) {
 
 		case CS1, CS2:
 			// No final block swapping
-			cd.codec.CryptBlocks(dst, src)
+			cd.decryptBlocks(dst, src)
 			return
 
 		case CS3:
 			// mode CS3: Swap the last two blocks
+			if textlen <= blocksz {
+				// a single block has nothing to swap
+				cd.decryptBlocks(dst, src)
+				return
+			}
+			// the swapped last two blocks are decrypted from their positions in src
 			py, pz := textlen-2*blocksz, textlen-blocksz
-			copy(dst[:py], src[:py])
-			copy(dst[py:pz], src[pz:])
-			copy(dst[pz:], src[py:pz])
-			cd.codec.CryptBlocks(dst, dst)
+			cd.decryptBlocks(dst[:py], src[:py])
+			last := src[py:pz]
+			if sameStart(dst, src) {
+				// in place, the last ciphertext block is saved before it is written over
+				_, tmp := cd.scratch(blocksz)
+				copy(tmp, last)
+				last = tmp
+			}
+			cd.codec.CryptBlocks(dst[py:pz], src[pz:])
+			cd.codec.CryptBlocks(dst[pz:], last)
 			return
 
 		default:
@@ -170,10 +322,10 @@ func (cd *cbccts) decode(dst, src []byte) {
 		panic(fmt.Errorf("data size too small; must be larger than one block"))
 	}
 
-	// encrypt aligned blocks
-	cd.codec.CryptBlocks(dst[:py], src[:py])
+	// decrypt aligned blocks
+	cd.decryptBlocks(dst[:py], src[:py])
 
-	tmp := make([]byte, 2*blocksz)
+	tmp, D := cd.scratch(blocksz)
 
 	switch cd.mode {
 	case CS1:
@@ -190,15 +342,21 @@ func (cd *cbccts) decode(dst, src []byte) {
 	}
 
 	// decrypt the last full block, in ECB mode
-	D := make([]byte, blocksz)
-	cd.block.Decrypt(D, tmp[blocksz:])
+	cd.decryptBlock(D, tmp[blocksz:])
 
 	// Overlay the decrypted portion with the partial block
-	for i := leftover; i < blocksz; i++ {
-		tmp[i] = D[i]
-	}
+	copy(tmp[leftover:blocksz], D[leftover:])
 
 	// run the decrypter
 	cd.codec.CryptBlocks(tmp, tmp)
 	copy(dst[py:], tmp)
+
+	// the scratch holds the plaintext of the last two blocks
+	zero(tmp)
+	zero(D)
+}
+
+// whether dst and src share the same memory; a cipher.BlockMode requires them to overlap entirely or not at all
+func sameStart(dst, src []byte) bool {
+	return len(dst) > 0 && len(src) > 0 && &dst[0] == &src[0]
 }
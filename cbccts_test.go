@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"fmt"
 	"testing"
 
 	"github.com/mixcode/golib-cbccts"
@@ -37,6 +38,9 @@ func TestCTS(t *testing.T) {
 	// prepare unaligned data
 	dataUnaligned := make([]byte, 4*aes.BlockSize+3)
 	prepare(dataUnaligned)
+	// prepare single block data
+	dataBlock := make([]byte, aes.BlockSize)
+	prepare(dataBlock)
 
 	ac, err := aes.NewCipher(key)
 
@@ -53,6 +57,9 @@ func TestCTS(t *testing.T) {
 		{cbccts.CS1, cbccts.CS1, dataUnaligned, true},
 		{cbccts.CS2, cbccts.CS2, dataUnaligned, true},
 		{cbccts.CS3, cbccts.CS3, dataUnaligned, true},
+		{cbccts.CS1, cbccts.CS1, dataBlock, true},
+		{cbccts.CS2, cbccts.CS2, dataBlock, true},
+		{cbccts.CS3, cbccts.CS3, dataBlock, true},
 
 		// cbccts.CS1/cbccts.CS2 is compatible on aligned data
 		{cbccts.CS1, cbccts.CS2, dataAligned, true},
@@ -81,6 +88,10 @@ func TestCTS(t *testing.T) {
 		{cbccts.CS1, 0, dataAligned, true},
 		{cbccts.CS2, 0, dataAligned, true},
 		{cbccts.CS3, 0, dataAligned, false},
+
+		// a single block is not swapped in any mode
+		{0, cbccts.CS3, dataBlock, true},
+		{cbccts.CS3, 0, dataBlock, true},
 	}
 
 	for i, c := range testCase {
@@ -113,3 +124,98 @@ func TestCTS(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// the tail of AES-sized blocks is processed without allocation
+func TestTailAllocs(t *testing.T) {
+
+	b, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	for _, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		for _, n := range []int{64, 67} {
+			buf := make([]byte, n)
+			enc := cbccts.NewCBCCTSEncrypter(b, iv, f)
+			dec := cbccts.NewCBCCTSDecrypter(b, iv, f)
+			if a := testing.AllocsPerRun(100, func() { enc.CryptBlocks(buf, buf) }); a != 0 {
+				t.Errorf("CS%d, %d bytes: %v allocations per encryption", f, n, a)
+			}
+			if a := testing.AllocsPerRun(100, func() { dec.CryptBlocks(buf, buf) }); a != 0 {
+				t.Errorf("CS%d, %d bytes: %v allocations per decryption", f, n, a)
+			}
+		}
+	}
+}
+
+func BenchmarkEncryptTail(b *testing.B) {
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	data := make([]byte, 4*aes.BlockSize+5)
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS3} {
+		enc := cbccts.NewCBCCTSEncrypter(ac, iv, mode)
+		b.Run(fmt.Sprintf("CS%d", mode), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				enc.CryptBlocks(data, data)
+			}
+		})
+	}
+}
+
+func TestCipherState(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	iv[0] = 1
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		enc := cbccts.NewCBCCTSEncrypter(ac, iv, mode).(*cbccts.Encrypter)
+		dec := cbccts.NewCBCCTSDecrypter(ac, iv, mode).(*cbccts.Decrypter)
+		if !bytes.Equal(enc.CipherState(), iv) || !bytes.Equal(dec.CipherState(), iv) {
+			t.Fatalf("CS%d: the initial state is not the IV", mode)
+		}
+
+		// the chaining values of the encrypter and the decrypter go along
+		for _, n := range []int{32, 21, 16, 64, 40} {
+			data := make([]byte, n)
+			enc.CryptBlocks(data, data)
+			if mode == cbccts.CS1 && n%aes.BlockSize == 0 && !bytes.Equal(enc.CipherState(), data[n-aes.BlockSize:]) {
+				t.Errorf("CS%d %d bytes: the state is not the last ciphertext block", mode, n)
+			}
+			dec.CryptBlocks(data, data)
+			if !bytes.Equal(enc.CipherState(), dec.CipherState()) {
+				t.Errorf("CS%d %d bytes: the states differ", mode, n)
+			}
+		}
+	}
+}
+
+// a single block has nothing to swap: CS3, as the other formats, is one block of CBC, E(P xor IV)
+func TestSingleBlockCS3(t *testing.T) {
+
+	ac, _ := aes.NewCipher([]byte("a sixteen by key"))
+	iv := []byte("0123456789abcdef")
+	pt := []byte("one single block")
+	want := make([]byte, aes.BlockSize)
+	for i := range want {
+		want[i] = pt[i] ^ iv[i]
+	}
+	ac.Encrypt(want, want)
+
+	for _, constantTime := range []bool{false, true} {
+		enc, dec := cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3), cbccts.NewCBCCTSDecrypter(ac, iv, cbccts.CS3)
+		if constantTime {
+			enc, dec = cbccts.WithConstantTime(enc), cbccts.WithConstantTime(dec)
+		}
+		ct := make([]byte, aes.BlockSize)
+		enc.CryptBlocks(ct, pt)
+		if !bytes.Equal(ct, want) {
+			t.Errorf("constant time %v: %x, expected %x", constantTime, ct, want)
+		}
+		if !bytes.Equal(enc.(*cbccts.Encrypter).CipherState(), want) {
+			t.Errorf("constant time %v: the state is not the ciphertext block", constantTime)
+		}
+		dec.CryptBlocks(ct, ct) // in place
+		if !bytes.Equal(ct, pt) {
+			t.Errorf("constant time %v: decryption failed", constantTime)
+		}
+	}
+}
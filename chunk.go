@@ -0,0 +1,72 @@
+/*
+	chunk.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// DefaultChunkSize is the default number of bytes of aligned blocks passed to the underlying CBC codec at once.
+// Processing a very large input in bounded chunks keeps the working set in cache; the output is identical.
+const DefaultChunkSize = 1 << 20
+
+// WithChunkSize sets the chunk size of a codec made by this package and returns the codec.
+// The size is rounded down to a multiple of the block size, and is at least one block.
+func WithChunkSize(m cipher.BlockMode, size int) cipher.BlockMode {
+	cd := codecOf(m)
+	blocksz := cd.BlockSize()
+	size -= size % blocksz
+	if size < blocksz {
+		size = blocksz
+	}
+	cd.chunk = size
+	return m
+}
+
+// run the CBC codec over aligned blocks, in chunks
+func (cd *cbccts) chain(dst, src []byte) {
+	n := cd.chunk
+	if n == 0 {
+		n = DefaultChunkSize
+	}
+	for len(src) > n {
+		cd.codec.CryptBlocks(dst[:n], src[:n])
+		dst, src = dst[n:], src[n:]
+	}
+	cd.codec.CryptBlocks(dst, src)
+}
+
+// ScratchSize returns the size of the scratch buffer for WithScratch, of a block size.
+func ScratchSize(blocksz int) int {
+	return 3 * blocksz
+}
+
+// WithScratch makes a codec made by this package use buf for all of its temporary storage, and returns the codec.
+// Codecs of 16-byte block ciphers already keep their scratch inside; for other block sizes,
+// this keeps the codec off the allocator, and for any block size, it places the scratch in memory of the caller's choice.
+// buf must be at least ScratchSize(blocksize) bytes and must not be shared with another codec in use.
+func WithScratch(m cipher.BlockMode, buf []byte) cipher.BlockMode {
+	cd := codecOf(m)
+	if len(buf) < ScratchSize(cd.BlockSize()) {
+		panic(fmt.Errorf("scratch buffer too small"))
+	}
+	cd.ext = buf
+	return m
+}
+
+// the codec of a cipher.BlockMode made by this package
+func codecOf(m cipher.BlockMode) *cbccts {
+	switch c := m.(type) {
+	case *Encrypter:
+		return &c.cbccts
+	case *Decrypter:
+		return &c.cbccts
+	case *pooledCodec:
+		return codecOf(c.Codec)
+	}
+	panic(fmt.Errorf("not a CBC-CTS codec"))
+}
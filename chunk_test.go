@@ -0,0 +1,98 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestChunkSize(t *testing.T) {
+
+	key := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(key)
+	rand.Read(iv)
+	ac, _ := aes.NewCipher(key)
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		for _, n := range []int{1000, 1024, 3<<20 + 7} {
+			pt := make([]byte, n)
+			rand.Read(pt)
+			want := make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(ac, iv, mode).CryptBlocks(want, pt)
+
+			for _, chunk := range []int{1, 100, 4096, 0} {
+				ct := make([]byte, n)
+				enc := cbccts.NewCBCCTSEncrypter(ac, iv, mode)
+				if chunk != 0 {
+					enc = cbccts.WithChunkSize(enc, chunk)
+				}
+				enc.CryptBlocks(ct, pt)
+				if !bytes.Equal(ct, want) {
+					t.Errorf("CS%d %d bytes, chunk %d: unexpected ciphertext", mode, n, chunk)
+				}
+
+				dec := cbccts.NewCBCCTSDecrypter(ac, iv, mode)
+				if chunk != 0 {
+					dec = cbccts.WithChunkSize(dec, chunk)
+				}
+				dec.CryptBlocks(ct, ct)
+				if !bytes.Equal(ct, pt) {
+					t.Errorf("CS%d %d bytes, chunk %d: decryption failed", mode, n, chunk)
+				}
+			}
+		}
+	}
+}
+
+func TestScratch(t *testing.T) {
+
+	// a 32-byte block cipher has no internal scratch
+	b := &wideBlock{}
+	iv := make([]byte, b.BlockSize())
+	scratch := make([]byte, cbccts.ScratchSize(b.BlockSize()))
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		pt := make([]byte, 3*b.BlockSize()+7)
+		rand.Read(pt)
+		want := make([]byte, len(pt))
+		cbccts.NewCBCCTSEncrypter(b, iv, mode).CryptBlocks(want, pt)
+
+		enc := cbccts.WithScratch(cbccts.NewCBCCTSEncrypter(b, iv, mode), scratch).(cbccts.Codec)
+		dec := cbccts.WithScratch(cbccts.NewCBCCTSDecrypter(b, iv, mode), scratch).(cbccts.Codec)
+		ct := make([]byte, len(pt))
+		out := make([]byte, len(pt))
+		allocs := testing.AllocsPerRun(10, func() {
+			enc.Reset(iv)
+			dec.Reset(iv)
+			enc.CryptBlocks(ct, pt)
+			dec.CryptBlocks(out, ct)
+		})
+		if !bytes.Equal(ct, want) || !bytes.Equal(out, pt) {
+			t.Errorf("CS%d: unexpected output", mode)
+		}
+		if allocs != 0 {
+			t.Errorf("CS%d: %v allocations", mode, allocs)
+		}
+	}
+}
+
+// a toy 32-byte block cipher: two AES blocks under a fixed key
+type wideBlock struct{}
+
+var wideAES, _ = aes.NewCipher(make([]byte, 16))
+
+func (*wideBlock) BlockSize() int { return 32 }
+
+func (*wideBlock) Encrypt(dst, src []byte) {
+	wideAES.Encrypt(dst[:16], src[:16])
+	wideAES.Encrypt(dst[16:32], src[16:32])
+}
+
+func (*wideBlock) Decrypt(dst, src []byte) {
+	wideAES.Decrypt(dst[:16], src[:16])
+	wideAES.Decrypt(dst[16:32], src[16:32])
+}
@@ -0,0 +1,78 @@
+/*
+	cbcmac.go
+	2026-10, github.com/mixcode
+*/
+
+package cmac
+
+import (
+	"crypto/cipher"
+	"hash"
+)
+
+type cbcmac struct {
+	block cipher.Block
+	x     []byte // chaining value
+	buf   []byte // pending input, less than one block
+	n     int    // number of bytes in buf
+}
+
+// NewCBCMAC returns a hash.Hash computing the raw CBC-MAC of the block cipher b, with a zero IV.
+// An incomplete last block is padded with zeros (ISO/IEC 9797-1 padding method 1); aligned messages are not padded.
+//
+// The result equals the final CBC chaining value of a CBC-CTS encryption of the same message with a zero IV,
+// so it can be cross-checked with cbccts.CBCResidue of the ciphertext.
+//
+// Raw CBC-MAC is only secure for messages of a fixed length. Use New (CMAC) for variable-length messages.
+func NewCBCMAC(b cipher.Block) hash.Hash {
+	blocksz := b.BlockSize()
+	return &cbcmac{
+		block: b,
+		x:     make([]byte, blocksz),
+		buf:   make([]byte, blocksz),
+	}
+}
+
+func (c *cbcmac) Size() int      { return len(c.x) }
+func (c *cbcmac) BlockSize() int { return len(c.x) }
+
+func (c *cbcmac) Reset() {
+	for i := range c.x {
+		c.x[i] = 0
+	}
+	c.n = 0
+}
+
+func (c *cbcmac) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		k := copy(c.buf[c.n:], p)
+		c.n += k
+		p = p[k:]
+		if c.n == len(c.buf) {
+			c.encrypt(c.buf)
+			c.n = 0
+		}
+	}
+	return written, nil
+}
+
+func (c *cbcmac) Sum(in []byte) []byte {
+	x := append([]byte(nil), c.x...)
+	if c.n > 0 {
+		// zero-pad the incomplete last block
+		for i := 0; i < c.n; i++ {
+			x[i] ^= c.buf[i]
+		}
+		c.block.Encrypt(x, x)
+	}
+	return append(in, x...)
+}
+
+// chain a block
+func (c *cbcmac) encrypt(b []byte) {
+	for i := range c.x {
+		c.x[i] ^= b[i]
+	}
+	c.block.Encrypt(c.x, c.x)
+}
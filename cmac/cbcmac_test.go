@@ -0,0 +1,62 @@
+package cmac_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/cmac"
+)
+
+func TestCBCMAC(t *testing.T) {
+
+	key := make([]byte, 0x10)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	ac, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zeroIV := make([]byte, aes.BlockSize)
+
+	for n := 0; n < 5*aes.BlockSize; n++ {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i * 3)
+		}
+		m := cmac.NewCBCMAC(ac)
+		m.Write(data[:n/2])
+		m.Write(data[n/2:])
+		mac := m.Sum(nil)
+
+		// the MAC is the last block of the CBC encryption of the zero-padded message
+		padded := make([]byte, (n+aes.BlockSize-1)/aes.BlockSize*aes.BlockSize)
+		copy(padded, data)
+		if n == 0 {
+			padded = nil
+		}
+		cipher.NewCBCEncrypter(ac, zeroIV).CryptBlocks(padded, padded)
+		expect := zeroIV
+		if len(padded) > 0 {
+			expect = padded[len(padded)-aes.BlockSize:]
+		}
+		if !bytes.Equal(mac, expect) {
+			t.Errorf("unexpected MAC: length %d", n)
+		}
+
+		// and it agrees with the residue of a CBC-CTS encryption, in every format
+		if n < aes.BlockSize {
+			continue
+		}
+		for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+			enc := make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(ac, zeroIV, mode).CryptBlocks(enc, data)
+			if r := cbccts.CBCResidue(enc, aes.BlockSize, mode); !bytes.Equal(r, mac) {
+				t.Errorf("residue does not match the MAC: length %d, mode %d", n, mode)
+			}
+		}
+	}
+}
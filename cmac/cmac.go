@@ -0,0 +1,113 @@
+/*
+	cmac.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package cmac implements the CMAC message authentication code (NIST SP 800-38B, RFC 4493) as a hash.Hash.
+
+	CMAC only needs a block cipher, so a deployment with a single block cipher key (and a key-derivation step) can authenticate messages without a hash function.
+*/
+package cmac
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"hash"
+)
+
+type cmac struct {
+	block  cipher.Block
+	k1, k2 []byte // subkeys
+	x      []byte // chaining value
+	buf    []byte // pending input, at most one block
+	n      int    // number of bytes in buf
+}
+
+// New returns a hash.Hash computing the CMAC of the block cipher b.
+// The block size of b must be 8 or 16 bytes.
+func New(b cipher.Block) hash.Hash {
+	blocksz := b.BlockSize()
+	var rb byte
+	switch blocksz {
+	case 8:
+		rb = 0x1b
+	case 16:
+		rb = 0x87
+	default:
+		panic(fmt.Errorf("cmac: unsupported block size %d", blocksz))
+	}
+
+	c := &cmac{
+		block: b,
+		k1:    make([]byte, blocksz),
+		k2:    make([]byte, blocksz),
+		x:     make([]byte, blocksz),
+		buf:   make([]byte, blocksz),
+	}
+
+	// generate subkeys
+	l := make([]byte, blocksz)
+	b.Encrypt(l, l)
+	shift(c.k1, l, rb)
+	shift(c.k2, c.k1, rb)
+	return c
+}
+
+// shift multiplies src by x in GF(2^n), in big-endian order
+func shift(dst, src []byte, rb byte) {
+	msb := src[0] >> 7
+	for i := 0; i < len(src)-1; i++ {
+		dst[i] = src[i]<<1 | src[i+1]>>7
+	}
+	dst[len(src)-1] = src[len(src)-1] << 1
+	if msb != 0 {
+		dst[len(src)-1] ^= rb
+	}
+}
+
+func (c *cmac) Size() int      { return len(c.x) }
+func (c *cmac) BlockSize() int { return len(c.x) }
+
+func (c *cmac) Reset() {
+	for i := range c.x {
+		c.x[i] = 0
+	}
+	c.n = 0
+}
+
+func (c *cmac) Write(p []byte) (int, error) {
+	written := len(p)
+	blocksz := len(c.x)
+	for len(p) > 0 {
+		// the last block is kept in buf until Sum, since it is processed differently
+		if c.n == blocksz {
+			for i := range c.x {
+				c.x[i] ^= c.buf[i]
+			}
+			c.block.Encrypt(c.x, c.x)
+			c.n = 0
+		}
+		k := copy(c.buf[c.n:], p)
+		c.n += k
+		p = p[k:]
+	}
+	return written, nil
+}
+
+func (c *cmac) Sum(in []byte) []byte {
+	blocksz := len(c.x)
+	last := make([]byte, blocksz)
+	copy(last, c.buf[:c.n])
+	k := c.k1
+	if c.n < blocksz {
+		// pad an incomplete last block
+		last[c.n] = 0x80
+		k = c.k2
+	}
+	for i := range last {
+		last[i] ^= c.x[i] ^ k[i]
+	}
+	c.block.Encrypt(last, last)
+	return append(in, last...)
+}
@@ -0,0 +1,80 @@
+package cmac_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/cmac"
+)
+
+func TestCMAC(t *testing.T) {
+
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	msg := unhex("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+
+	type testParam struct {
+		key    string
+		tdes   bool
+		msglen int
+		expect string
+	}
+	testCase := []testParam{
+		// RFC 4493 / NIST SP 800-38B AES-128 examples
+		{"2b7e151628aed2a6abf7158809cf4f3c", false, 0, "bb1d6929e95937287fa37d129b756746"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", false, 16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", false, 40, "dfa66747de9ae63030ca32611497c827"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", false, 64, "51f0bebf7e3b9d92fc49741779363cfe"},
+
+		// NIST SP 800-38B AES-256 examples
+		{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", false, 0, "028962f61b7bf89efc6b551f4667d983"},
+		{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", false, 64, "e1992190549f6ed5696a2c056c315410"},
+
+		// NIST SP 800-38B three-key TDEA examples
+		{"8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5", true, 0, "b7a688e122ffaf95"},
+		{"8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5", true, 32, "33e6b1092400eae5"},
+	}
+
+	for i, c := range testCase {
+		var m interface {
+			Write([]byte) (int, error)
+			Sum([]byte) []byte
+		}
+		if c.tdes {
+			b, err := des.NewTripleDESCipher(unhex(c.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m = cmac.New(b)
+		} else {
+			b, err := aes.NewCipher(unhex(c.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m = cmac.New(b)
+		}
+
+		// write in small pieces to exercise the buffering
+		data := msg[:c.msglen]
+		for len(data) > 0 {
+			k := 7
+			if k > len(data) {
+				k = len(data)
+			}
+			m.Write(data[:k])
+			data = data[k:]
+		}
+		if got, expect := m.Sum(nil), unhex(c.expect); !bytes.Equal(got, expect) {
+			t.Errorf("case %d: expected %x, got %x", i, expect, got)
+		}
+	}
+}
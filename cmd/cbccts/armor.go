@@ -0,0 +1,152 @@
+/*
+	armor.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// characters per line of armored output
+const armorLineLen = 64
+
+// bytes of input examined to detect armor
+const armorPeekLen = 512
+
+// an io.WriteCloser encoding to base64 or hex, wrapped in lines
+type armorWriter struct {
+	enc io.WriteCloser // the encoder, writing to lw
+	lw  *lineWriter
+}
+
+func newArmorWriter(w io.Writer, kind string) (*armorWriter, error) {
+	lw := &lineWriter{w: w}
+	a := &armorWriter{lw: lw}
+	switch kind {
+	case "base64":
+		a.enc = base64.NewEncoder(base64.StdEncoding, lw)
+	case "hex":
+		a.enc = nopCloser{hex.NewEncoder(lw)}
+	default:
+		return nil, fmt.Errorf("unknown armor %q; base64 or hex", kind)
+	}
+	return a, nil
+}
+
+func (a *armorWriter) Write(p []byte) (int, error) {
+	return a.enc.Write(p)
+}
+
+// Close flushes the encoder and ends the last line.
+func (a *armorWriter) Close() error {
+	if err := a.enc.Close(); err != nil {
+		return err
+	}
+	if a.lw.n > 0 {
+		_, err := a.lw.w.Write([]byte{'\n'})
+		return err
+	}
+	return nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// a writer breaking lines after armorLineLen bytes
+type lineWriter struct {
+	w io.Writer
+	n int // bytes on the current line
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		k := armorLineLen - lw.n
+		if k > len(p) {
+			k = len(p)
+		}
+		if _, err := lw.w.Write(p[:k]); err != nil {
+			return written, err
+		}
+		written, p, lw.n = written+k, p[k:], lw.n+k
+		if lw.n == armorLineLen {
+			if _, err := lw.w.Write([]byte{'\n'}); err != nil {
+				return written, err
+			}
+			lw.n = 0
+		}
+	}
+	return written, nil
+}
+
+// detect armored input by its first bytes, and return a reader of the decoded input, with the armor found or ""
+func dearmor(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, armorPeekLen)
+	head, err := br.Peek(armorPeekLen)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	switch armorOf(head) {
+	case "hex":
+		return hex.NewDecoder(&spaceStripper{r: br}), "hex", nil
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &spaceStripper{r: br}), "base64", nil
+	}
+	return br, "", nil
+}
+
+// the armor of which the bytes may be the start; hex digits are also base64 characters, and are taken for hex
+func armorOf(b []byte) string {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return ""
+	}
+	isHex := true
+	for _, c := range b {
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		case 'g' <= c && c <= 'z', 'G' <= c && c <= 'Z', c == '+', c == '/', c == '=':
+			isHex = false
+		default:
+			return ""
+		}
+	}
+	if isHex {
+		return "hex"
+	}
+	return "base64"
+}
+
+// a reader dropping whitespace
+type spaceStripper struct {
+	r io.Reader
+}
+
+func (s *spaceStripper) Read(p []byte) (int, error) {
+	for {
+		n, err := s.r.Read(p)
+		k := 0
+		for _, c := range p[:n] {
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				p[k] = c
+				k++
+			}
+		}
+		if k > 0 || err != nil {
+			return k, err
+		}
+	}
+}
@@ -0,0 +1,29 @@
+/*
+	ciphers.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"crypto/aes"
+	"strings"
+
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// the cipher of -cipher; "aes" selects AES by the key size, or AES-256 for a passphrase
+func lookupCipher(name string, keySize int) (container.Cipher, error) {
+	if strings.EqualFold(name, "aes") {
+		switch keySize {
+		case 16:
+			return container.AES128, nil
+		case 24:
+			return container.AES192, nil
+		case 0, 32:
+			return container.AES256, nil
+		}
+		return 0, aes.KeySizeError(keySize)
+	}
+	return container.ParseCipher(name)
+}
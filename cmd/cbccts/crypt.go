@@ -0,0 +1,359 @@
+/*
+	crypt.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// the enc and dec commands
+func runCrypt(encrypt bool, args []string, stdin io.Reader, stdout, stderr io.Writer) (err error) {
+	name := "dec"
+	if encrypt {
+		name = "enc"
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		cipherName = fs.String("cipher", "aes", "block cipher; aes (by the key size), or one of "+strings.Join(container.CipherNames(), ", "))
+		keys       keySource
+		ivHex      = fs.String("iv", "", "IV in hex, of the block size")
+		format     = fs.String("format", "cs3", "ciphertext format; cs1, cs2 or cs3. CS3 is that of Kerberos; openssl enc uses CS1")
+		passphrase = fs.String("passphrase", "", "derive the key from a passphrase; the KDF parameters, salt and IV are written in a header")
+		kdfName    = fs.String("kdf", "argon2id", "KDF of -passphrase; pbkdf2, scrypt or argon2id")
+		inFile     = fs.String("in", "", "input file; standard input if empty")
+		outFile    = fs.String("out", "", "output file; standard output if empty")
+		withMAC    = fs.Bool("mac", true, "append an HMAC-SHA-256 of the header and the ciphertext to a passphrase-encrypted file")
+		verify     = fs.Bool("verify", true, "refuse a passphrase-encrypted file without a valid MAC")
+		armor      = fs.String("armor", "", "write the output in base64 or hex, in lines; dec detects armored input")
+		recursive  = fs.Bool("r", false, "encrypt the directory -in into the directory -out file by file, with an authenticated manifest")
+		compress   = fs.String("compress", "", "compress a passphrase-encrypted file before encryption; gzip or zstd")
+		shred      = fs.Bool("shred", false, "after encrypting and verifying the output, overwrite and remove the -in file; best effort, not on SSDs or copy-on-write file systems")
+		jsonOut    = fs.Bool("json", false, "write the result as JSON; to the standard output with -out, otherwise to the standard error")
+		chunked    = fs.Bool("chunked", false, "write a passphrase-encrypted file as chunks authenticated on their own, for large files")
+		resume     = fs.Bool("resume", false, "continue an interrupted enc -chunked of -in into the -out file, after verifying what it holds")
+		sparse     = fs.Bool("sparse", false, "store the holes of a sparse -in file, such as a disk image, as holes of a chunked file instead of encrypted zeros")
+	)
+	fs.StringVar(&keys.hex, "key", "", "key in hex; visible to other users in the process list, unlike the other key sources")
+	fs.StringVar(&keys.file, "keyfile", "", "read the key from a file, in hex or, unless the file is all hex digits, in binary")
+	fs.StringVar(&keys.env, "key-env", "", "read the key in hex from an environment variable")
+	fs.IntVar(&keys.fd, "key-fd", -1, "read the key from an open file descriptor, as -keyfile")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	res := &result{Command: name, Output: "-"}
+	rc, wc := &countReader{r: stdin}, &countWriter{w: stdout}
+	if *jsonOut {
+		start, jw := time.Now(), stderr
+		if *outFile != "" {
+			res.Output, jw = *outFile, stdout
+		}
+		defer func() {
+			res.BytesIn, res.BytesOut = rc.n, wc.n
+			if e := res.write(jw, start, err); err == nil {
+				err = e
+			}
+		}()
+	}
+	if *passphrase != "" && (keys.count() != 0 || set["iv"]) {
+		return fmt.Errorf("-passphrase cannot be used with a key or -iv")
+	}
+	if keys.count() > 1 {
+		return fmt.Errorf("only one of -key, -keyfile, -key-env and -key-fd may be given")
+	}
+	if *shred && (!encrypt || *inFile == "" || *outFile == "" || *recursive) {
+		return fmt.Errorf("-shred is an option of enc with -in and -out files")
+	}
+	if *resume {
+		if !encrypt || *inFile == "" || *outFile == "" || *passphrase == "" {
+			return fmt.Errorf("-resume is an option of enc -passphrase with -in and -out files")
+		}
+		// the header of the file decides the rest
+		for _, f := range []string{"cipher", "format", "kdf", "chunked", "sparse", "compress", "mac", "armor", "shred", "r"} {
+			if set[f] {
+				return fmt.Errorf("-%s cannot be used with -resume", f)
+			}
+		}
+		return resumeEncrypt(*inFile, *outFile, *passphrase)
+	}
+	if *recursive {
+		for _, f := range []string{"iv", "armor", "chunked", "sparse", "mac", "verify", "compress", "json"} {
+			if set[f] {
+				return fmt.Errorf("-%s cannot be used with -r", f)
+			}
+		}
+		if *inFile == "" || *outFile == "" {
+			return fmt.Errorf("-r requires -in and -out directories")
+		}
+		if *passphrase == "" && keys.count() == 0 {
+			return fmt.Errorf("-r requires a key or -passphrase")
+		}
+		mode, err := cbccts.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+		if encrypt {
+			return encryptTree(*inFile, *outFile, *cipherName, mode, *passphrase, *kdfName, &keys)
+		}
+		return decryptTree(*inFile, *outFile, *cipherName, set["cipher"], *passphrase, &keys)
+	}
+	if *passphrase == "" && set["kdf"] {
+		return fmt.Errorf("-kdf requires -passphrase")
+	}
+	if *passphrase != "" && !encrypt && set["kdf"] {
+		return fmt.Errorf("-kdf cannot be used with dec; the KDF is read from the header")
+	}
+	if (*passphrase == "" || !encrypt) && (set["mac"] || set["chunked"] || set["sparse"] || set["compress"]) {
+		return fmt.Errorf("-mac, -chunked, -sparse and -compress are options of enc -passphrase")
+	}
+	if *sparse {
+		if *inFile == "" || *compress != "" {
+			return fmt.Errorf("-sparse requires an -in file, and cannot be used with -compress")
+		}
+		*chunked = true
+	}
+	compression, err := container.ParseCompression(*compress)
+	if err != nil {
+		return err
+	}
+	if !encrypt && set["armor"] {
+		return fmt.Errorf("-armor is an option of enc; dec detects armored input")
+	}
+	if *chunked && !*withMAC {
+		return fmt.Errorf("-chunked and -sparse require -mac")
+	}
+	if (*passphrase == "" || encrypt) && set["verify"] {
+		return fmt.Errorf("-verify is an option of dec -passphrase")
+	}
+	mode, err := cbccts.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	var in *os.File
+	if *inFile != "" {
+		if in, err = os.Open(*inFile); err != nil {
+			return err
+		}
+		defer in.Close()
+		rc.r = in
+	}
+	var r io.Reader = rc
+	if !encrypt {
+		var kind string
+		if r, kind, err = dearmor(r); err != nil {
+			return err
+		}
+		if kind != "" {
+			in = nil // the file is not the ciphertext to seek in
+		}
+	}
+	var key, iv []byte
+	var c container.Cipher
+	var h *container.Header // the header of a passphrase-encrypted file
+	opts := &container.Options{Passphrase: []byte(*passphrase)}
+	switch {
+	case *passphrase != "" && encrypt:
+		if c, err = lookupCipher(*cipherName, 0); err != nil {
+			return err
+		}
+		if opts.KDF, err = container.ParseKDF(*kdfName); err != nil {
+			return err
+		}
+		opts.Cipher, opts.Format, opts.KDFParams = c, mode, kdfDefaults(opts.KDF)
+		opts.NoMAC, opts.Chunked, opts.Sparse, opts.Compression = !*withMAC, *chunked, *sparse, compression
+
+	case *passphrase != "":
+		br := bufio.NewReader(r)
+		if h, err = container.ReadHeader(br); err != nil {
+			return err
+		}
+		// the header decides the format and the cipher; explicit flags must agree with it
+		if set["format"] && mode != h.Format {
+			return fmt.Errorf("-format %s conflicts with the format CS%d of the header", *format, h.Format)
+		}
+		if set["cipher"] {
+			if c, err := lookupCipher(*cipherName, 0); err != nil || c != h.Cipher {
+				return fmt.Errorf("-cipher %s conflicts with the cipher %v of the header", *cipherName, h.Cipher)
+			}
+		}
+		if h.Flags&container.FlagMAC == 0 && *verify {
+			return fmt.Errorf("the file has no MAC; decrypt it with -verify=false to accept it without an integrity check")
+		}
+		opts.AllowNoMAC, opts.SkipMAC = !*verify, !*verify
+		r = br
+		if fi, e := os.Stat(*inFile); in != nil && e == nil && fi.Mode().IsRegular() {
+			// a file is verified before any of its plaintext is written, and read again past the header,
+			// not from the bytes buffered
+			hdr, err := h.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			crs := &countReadSeeker{countReader: rc, s: in}
+			if _, err := crs.Seek(int64(len(hdr)), io.SeekStart); err != nil {
+				return err
+			}
+			r = crs
+		}
+
+	default:
+		if key, err = keys.read(); err != nil {
+			return err
+		}
+		if iv, err = hex.DecodeString(*ivHex); err != nil {
+			return fmt.Errorf("invalid IV: %v", err)
+		}
+		if c, err = lookupCipher(*cipherName, len(key)); err != nil {
+			return err
+		}
+		if len(iv) != c.BlockSize() {
+			return fmt.Errorf("the IV must be %d bytes", c.BlockSize())
+		}
+		if mode < cbccts.CS1 || mode > cbccts.CS3 {
+			return fmt.Errorf("invalid format %d", mode)
+		}
+	}
+	if *shred {
+		// runs after the output is closed
+		sum := sha256.New()
+		r = io.TeeReader(r, sum)
+		defer func() {
+			if err == nil {
+				err = verifyEncrypted(shredDecArgs(*outFile, *passphrase, h, c, key, iv, *format), sum.Sum(nil))
+			}
+			if err == nil {
+				err = shredFile(*inFile)
+			}
+		}()
+	}
+
+	if h != nil {
+		res.setHeader(h)
+		res.Verified = *verify && h.Flags&container.FlagMAC != 0
+	} else if *passphrase == "" {
+		res.Cipher, res.Format = c.String(), fmt.Sprintf("CS%d", mode)
+	}
+
+	if *outFile != "" {
+		fd, e := os.Create(*outFile)
+		if e != nil {
+			return e
+		}
+		defer func() {
+			if e := fd.Close(); err == nil {
+				err = e
+			}
+			if err != nil {
+				// no partial or unverified output is left behind
+				os.Remove(*outFile)
+			}
+		}()
+		wc.w = fd
+		if h != nil && h.Flags&container.FlagSparse != 0 {
+			// the holes are restored as holes
+			sw := &sparseWriter{f: fd}
+			defer func() {
+				if err == nil {
+					err = sw.Close()
+				}
+			}()
+			wc.w = sw
+		}
+	}
+	var w io.Writer = wc
+	if *armor != "" {
+		aw, err := newArmorWriter(w, *armor)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err == nil {
+				err = aw.Close()
+			}
+		}()
+		w = aw
+	}
+
+	// the input is streamed; the final blocks are processed at the end of the input
+	switch {
+	case *passphrase != "" && encrypt:
+		ew, err := container.NewWriter(w, opts)
+		if err != nil {
+			return err
+		}
+		h = ew.Header()
+		res.setHeader(h)
+		if *sparse {
+			err = copySparse(ew, r, in)
+		} else {
+			_, err = io.Copy(ew, r)
+		}
+		if err != nil {
+			return err
+		}
+		if err = ew.Close(); err != nil {
+			return err
+		}
+		if sum := ew.MAC(); sum != nil {
+			res.setMAC(sum)
+		}
+		return nil
+
+	case *passphrase != "":
+		dr, err := container.NewReader(r, h, opts)
+		if err != nil {
+			return err
+		}
+		defer dr.Close()
+		_, err = io.Copy(w, dr)
+		return err
+	}
+
+	// a raw key has no header, MAC or compression
+	block, err := c.NewBlock(key)
+	if err != nil {
+		return err
+	}
+	if encrypt {
+		ew := cbccts.NewWriter(w, cbccts.NewCBCCTSEncrypter(block, iv, mode))
+		if _, err = io.Copy(ew, r); err != nil {
+			return err
+		}
+		return ew.Close()
+	}
+	_, err = io.Copy(w, cbccts.NewReader(r, cbccts.NewCBCCTSDecrypter(block, iv, mode)))
+	return err
+}
+
+// countReadSeeker counts the bytes read from a file as its offset, for a file read again after a seek
+type countReadSeeker struct {
+	*countReader
+	s io.Seeker // the file read by countReader
+}
+
+func (c *countReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := c.s.Seek(offset, whence)
+	if err == nil {
+		c.n = pos
+	}
+	return pos, err
+}
@@ -0,0 +1,372 @@
+/*
+	dir.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// the name of the manifest at the root of an encrypted directory
+const manifestName = "MANIFEST.cbccts"
+
+// the manifest of an encrypted directory. It is stored as a container with a MAC,
+// so the file names are encrypted and the list of files and their IVs and MACs is authenticated.
+type manifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	Path string      `json:"path"` // slash-separated, relative to the root
+	Dir  bool        `json:"dir,omitempty"`
+	Mode os.FileMode `json:"mode"` // permission bits
+	Size int64       `json:"size,omitempty"`
+	IV   []byte      `json:"iv,omitempty"`
+	MAC  []byte      `json:"mac,omitempty"`  // HMAC-SHA-256 of the path and the ciphertext
+	Data []byte      `json:"data,omitempty"` // the content of a file shorter than a block, which CBC-CTS cannot encrypt
+}
+
+// key material of a directory from a raw key, whose MAC key must be derived as the key is a single cipher key
+func rawKeyMaterial(key []byte, h *container.Header) ([]byte, error) {
+	m := make([]byte, h.KeySize())
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte("cbccts directory")), m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// the MAC of an encrypted file
+func fileMAC(macKey []byte, rel string) hash.Hash {
+	m := hmac.New(sha256.New, macKey)
+	m.Write([]byte(rel))
+	m.Write([]byte{0})
+	return m
+}
+
+// encrypt the files under src into the same paths under dst, and write the manifest with the header h
+func encryptDir(src, dst string, h *container.Header, material []byte) error {
+	key, macKey, err := h.SplitKey(material)
+	if err != nil {
+		return err
+	}
+	block, err := h.Cipher.NewBlock(key)
+	if err != nil {
+		return err
+	}
+	blocksz := block.BlockSize()
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return err
+	}
+
+	var m manifest
+	err = filepath.Walk(src, func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == manifestName {
+			return fmt.Errorf("%s: reserved for the manifest", name)
+		}
+		e := manifestEntry{Path: rel, Mode: fi.Mode().Perm()}
+		switch {
+		case fi.IsDir():
+			e.Dir = true
+			if err := os.MkdirAll(filepath.Join(dst, filepath.FromSlash(rel)), 0700); err != nil {
+				return err
+			}
+		case !fi.Mode().IsRegular():
+			return fmt.Errorf("%s: not a regular file or a directory", name)
+		case fi.Size() < int64(blocksz):
+			if e.Data, err = os.ReadFile(name); err != nil {
+				return err
+			}
+			e.Size = int64(len(e.Data))
+		default:
+			e.IV = make([]byte, blocksz)
+			if _, err := rand.Read(e.IV); err != nil {
+				return err
+			}
+			mac := fileMAC(macKey, rel)
+			if e.Size, err = encryptDirFile(name, filepath.Join(dst, filepath.FromSlash(rel)), mac, block, e.IV, h.Format); err != nil {
+				return err
+			}
+			e.MAC = mac.Sum(nil)
+		}
+		m.Files = append(m.Files, e)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the manifest: a container of the JSON, with an HMAC trailer
+	js, err := json.Marshal(&m)
+	if err != nil {
+		return err
+	}
+	hdr, err := h.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	mac, err := h.NewMAC(macKey)
+	if err != nil {
+		return err
+	}
+	cbccts.NewCBCCTSEncrypter(block, h.IV, h.Format).CryptBlocks(js, js)
+	mac.Write(js)
+	return os.WriteFile(filepath.Join(dst, manifestName), append(append(hdr, js...), mac.Sum(nil)...), 0600)
+}
+
+// encrypt a file, writing the ciphertext to mac too, and return its size
+func encryptDirFile(src, dst string, mac hash.Hash, block cipher.Block, iv []byte, f cbccts.Format) (n int64, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if e := out.Close(); err == nil {
+			err = e
+		}
+	}()
+	w := cbccts.NewWriter(io.MultiWriter(out, mac), cbccts.NewCBCCTSEncrypter(block, iv, f))
+	if n, err = io.Copy(w, in); err != nil {
+		return n, err
+	}
+	return n, w.Close()
+}
+
+// decrypt a directory made by encryptDir. material returns the key material of the manifest header.
+// The manifest and every file are verified before any file is written.
+func decryptDir(src, dst string, material func(h *container.Header) ([]byte, error)) error {
+	mf, err := os.Open(filepath.Join(src, manifestName))
+	if err != nil {
+		return err
+	}
+	defer mf.Close()
+	h, err := container.ReadHeader(mf)
+	if err != nil {
+		return err
+	}
+	if h.Flags != container.FlagMAC {
+		return fmt.Errorf("%s: not a directory manifest", manifestName)
+	}
+	km, err := material(h)
+	if err != nil {
+		return err
+	}
+	key, macKey, err := h.SplitKey(km)
+	if err != nil {
+		return err
+	}
+	block, err := h.Cipher.NewBlock(key)
+	if err != nil {
+		return err
+	}
+	mac, err := h.NewMAC(macKey)
+	if err != nil {
+		return err
+	}
+	js, err := io.ReadAll(container.NewMACReader(mf, mac))
+	if err != nil {
+		return err
+	}
+	if len(js) < block.BlockSize() {
+		return fmt.Errorf("%s: too short", manifestName)
+	}
+	cbccts.NewCBCCTSDecrypter(block, h.IV, h.Format).CryptBlocks(js, js)
+	var m manifest
+	if err := json.Unmarshal(js, &m); err != nil {
+		return fmt.Errorf("%s: %v", manifestName, err)
+	}
+
+	// the files must be exactly those of the manifest, with their sizes and MACs
+	listed := map[string]bool{manifestName: true}
+	for _, e := range m.Files {
+		if e.Path == "" || e.Path != path.Clean(e.Path) || path.IsAbs(e.Path) || e.Path == ".." || strings.HasPrefix(e.Path, "../") {
+			return fmt.Errorf("%s: invalid path %q", manifestName, e.Path)
+		}
+		listed[e.Path] = true
+		if e.Dir || e.Data != nil || e.Size == 0 {
+			continue
+		}
+		if len(e.IV) != block.BlockSize() || e.Size < int64(block.BlockSize()) {
+			return fmt.Errorf("%s: invalid entry %q", manifestName, e.Path)
+		}
+		if err := verifyDirFile(src, e, macKey); err != nil {
+			return err
+		}
+	}
+	err = filepath.Walk(src, func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil || rel == "." {
+			return err
+		}
+		if !listed[filepath.ToSlash(rel)] {
+			return fmt.Errorf("%s: not in the manifest", name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// parents come before their files in the manifest, as they are walked in lexical order
+	sort.SliceStable(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	for _, e := range m.Files {
+		out := filepath.Join(dst, filepath.FromSlash(e.Path))
+		switch {
+		case e.Dir:
+			if err := os.MkdirAll(out, 0700); err != nil {
+				return err
+			}
+		case e.Data != nil || e.Size == 0:
+			if err := os.MkdirAll(filepath.Dir(out), 0700); err != nil {
+				return err
+			}
+			if err := os.WriteFile(out, e.Data, e.Mode|0200); err != nil {
+				return err
+			}
+		default:
+			if err := os.MkdirAll(filepath.Dir(out), 0700); err != nil {
+				return err
+			}
+			if err := decryptDirFile(filepath.Join(src, filepath.FromSlash(e.Path)), out, e, block, h.Format); err != nil {
+				return err
+			}
+		}
+	}
+	// directory permissions last, so that they do not prevent writing the files in them
+	for _, e := range m.Files {
+		if e.Dir {
+			if err := os.Chmod(filepath.Join(dst, filepath.FromSlash(e.Path)), e.Mode|0700); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check the size and the MAC of an encrypted file
+func verifyDirFile(src string, e manifestEntry, macKey []byte) error {
+	f, err := os.Open(filepath.Join(src, filepath.FromSlash(e.Path)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	mac := fileMAC(macKey, e.Path)
+	n, err := io.Copy(mac, f)
+	if err != nil {
+		return err
+	}
+	if n != e.Size || !hmac.Equal(mac.Sum(nil), e.MAC) {
+		return fmt.Errorf("%s: %v", e.Path, container.ErrMAC)
+	}
+	return nil
+}
+
+func decryptDirFile(src, dst string, e manifestEntry, block cipher.Block, f cbccts.Format) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, e.Mode|0200)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := out.Close(); err == nil {
+			err = e
+		}
+	}()
+	_, err = io.Copy(out, cbccts.NewReader(in, cbccts.NewCBCCTSDecrypter(block, e.IV, f)))
+	return err
+}
+
+// enc -r: the header of the manifest and the key material, from a passphrase or a raw key
+func encryptTree(src, dst, cipherName string, mode cbccts.Format, passphrase, kdfName string, keys *keySource) error {
+	h := &container.Header{Format: mode, Flags: container.FlagMAC}
+	var key []byte
+	var err error
+	if passphrase == "" {
+		if key, err = keys.read(); err != nil {
+			return err
+		}
+	}
+	if h.Cipher, err = lookupCipher(cipherName, len(key)); err != nil {
+		return err
+	}
+	h.IV = make([]byte, h.Cipher.BlockSize())
+	if _, err := rand.Read(h.IV); err != nil {
+		return err
+	}
+	var material []byte
+	if passphrase != "" {
+		if err := initKDF(h, kdfName); err != nil {
+			return err
+		}
+		material, err = h.KDF.DeriveKey([]byte(passphrase), h.KeySize())
+	} else {
+		material, err = rawKeyMaterial(key, h)
+	}
+	if err != nil {
+		return err
+	}
+	return encryptDir(src, dst, h, material)
+}
+
+// dec -r: the key material of the manifest header
+func decryptTree(src, dst, cipherName string, cipherSet bool, passphrase string, keys *keySource) error {
+	return decryptDir(src, dst, func(h *container.Header) ([]byte, error) {
+		if passphrase != "" {
+			if h.KDF.KDF == container.None {
+				return nil, fmt.Errorf("the key of the directory is not derived from a passphrase")
+			}
+			return h.KDF.DeriveKey([]byte(passphrase), h.KeySize())
+		}
+		if h.KDF.KDF != container.None {
+			return nil, fmt.Errorf("the key of the directory is derived from a passphrase")
+		}
+		key, err := keys.read()
+		if err != nil {
+			return nil, err
+		}
+		if cipherSet {
+			if c, err := lookupCipher(cipherName, len(key)); err != nil || c != h.Cipher {
+				return nil, fmt.Errorf("-cipher %s conflicts with the cipher %v of the manifest", cipherName, h.Cipher)
+			}
+		}
+		return rawKeyMaterial(key, h)
+	})
+}
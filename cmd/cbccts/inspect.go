@@ -0,0 +1,141 @@
+/*
+	inspect.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// the inspect command: print the header of a container, which needs no key
+func runInspect(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cbccts inspect [FILE]\n\nPrint the container header of FILE, or of the standard input.")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args()[1:])
+	}
+	r := stdin
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	r, armor, err := dearmor(r)
+	if err != nil {
+		return err
+	}
+	h, err := container.ReadHeader(r)
+	if err != nil {
+		return err
+	}
+
+	flags := []string{}
+	if h.Flags&container.FlagMAC != 0 {
+		flags = append(flags, "mac")
+	}
+	if h.Flags&container.FlagChunked != 0 {
+		flags = append(flags, "chunked")
+	}
+	if h.Flags&container.FlagSparse != 0 {
+		flags = append(flags, "sparse")
+	}
+	if len(flags) == 0 {
+		flags = append(flags, "none")
+	}
+	if armor == "" {
+		armor = "none"
+	}
+	p := func(name, format string, a ...interface{}) {
+		fmt.Fprintf(stdout, "%-11s"+format+"\n", append([]interface{}{name}, a...)...)
+	}
+	p("version", "%d", container.Version)
+	p("armor", "%s", armor)
+	p("cipher", "%v (%d-byte key, %d-byte block)", h.Cipher, h.Cipher.KeySize(), h.Cipher.BlockSize())
+	p("format", "CS%d", h.Format)
+	p("flags", "%s", strings.Join(flags, ", "))
+	p("compress", "%s", h.Compression())
+	p("kdf", "%v", &h.KDF)
+	p("salt", "%s", hex.EncodeToString(h.KDF.Salt))
+	p("iv", "%s", hex.EncodeToString(h.IV))
+
+	// the rest is read only for its size
+	switch {
+	case h.Flags&container.FlagChunked != 0:
+		chunks, size, holes, err := countChunks(r)
+		if err != nil {
+			return err
+		}
+		p("mac", "HMAC-SHA-256 per chunk")
+		p("chunks", "%d", chunks)
+		p("ciphertext", "%d bytes", size)
+		if h.Flags&container.FlagSparse != 0 {
+			p("holes", "%d bytes", holes)
+		}
+	case h.Flags&container.FlagMAC != 0:
+		n, err := io.Copy(io.Discard, r)
+		if err != nil {
+			return err
+		}
+		if n < container.MACSize {
+			return container.ErrTruncated
+		}
+		p("mac", "HMAC-SHA-256 trailer")
+		p("ciphertext", "%d bytes", n-container.MACSize)
+	default:
+		n, err := io.Copy(io.Discard, r)
+		if err != nil {
+			return err
+		}
+		p("mac", "none")
+		p("ciphertext", "%d bytes", n)
+	}
+	return nil
+}
+
+// walk the chunks of a chunked container, without verifying them
+func countChunks(r io.Reader) (chunks int, size, holes int64, err error) {
+	var head [5]byte
+	for {
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = container.ErrTruncated
+			}
+			return chunks, size, holes, err
+		}
+		n := int64(binary.BigEndian.Uint32(head[1:]))
+		if head[0] == 2 {
+			// a hole, without ciphertext
+			holes, n = holes+n, 0
+		}
+		if _, err := io.CopyN(io.Discard, r, n+container.MACSize); err != nil {
+			if err == io.EOF {
+				err = container.ErrTruncated
+			}
+			return chunks, size, holes, err
+		}
+		chunks++
+		size += n
+		if head[0] == 1 {
+			return chunks, size, holes, nil
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+	interop.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// message lengths of the interop check: a block, partial last blocks, and aligned multi-block messages
+var interopLengths = []int{16, 17, 31, 32, 33, 47, 48, 100, 1000, 4096}
+
+// the interop command: round-trip random data through this command's codec and another tool
+func runInterop(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("interop", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		withOpenSSL = fs.Bool("with-openssl", false, "check against the openssl command, version 3.0 or later")
+		opensslPath = fs.String("openssl", "openssl", "the openssl command")
+		keySize     = fs.Int("keysize", 16, "AES key size in bytes; 16, 24 or 32")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	if !*withOpenSSL {
+		return fmt.Errorf("no tool to check against; use -with-openssl")
+	}
+	if *keySize != 16 && *keySize != 24 && *keySize != 32 {
+		return aes.KeySizeError(*keySize)
+	}
+	o := &openssl{path: *opensslPath, cipher: fmt.Sprintf("aes-%d-cbc-cts", *keySize*8)}
+	version, err := o.version()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s, %s\n", version, o.cipher)
+
+	failed := 0
+	for _, n := range interopLengths {
+		key, iv, pt := make([]byte, *keySize), make([]byte, aes.BlockSize), make([]byte, n)
+		for _, b := range [][]byte{key, iv, pt} {
+			if _, err := rand.Read(b); err != nil {
+				return err
+			}
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+
+		// openssl to cbccts: the formats whose ciphertext is that of openssl
+		theirs, err := o.crypt(true, key, iv, pt)
+		if err != nil {
+			return err
+		}
+		var matched []string
+		for _, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+			ours := make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(block, iv, f).CryptBlocks(ours, pt)
+			if bytes.Equal(ours, theirs) {
+				matched = append(matched, fmt.Sprintf("CS%d", f))
+			}
+		}
+		dec := make([]byte, n)
+		cbccts.NewCBCCTSDecrypter(block, iv, cbccts.CS1).CryptBlocks(dec, theirs)
+		toUs := bytes.Equal(dec, pt)
+
+		// cbccts to openssl, in CS1, the format of openssl enc
+		ours := make([]byte, n)
+		cbccts.NewCBCCTSEncrypter(block, iv, cbccts.CS1).CryptBlocks(ours, pt)
+		back, err := o.crypt(false, key, iv, ours)
+		if err != nil {
+			return err
+		}
+		toThem := bytes.Equal(back, pt)
+
+		status := "ok"
+		if !toUs || !toThem || len(matched) == 0 {
+			status = "MISMATCH"
+			failed++
+		}
+		if len(matched) == 0 {
+			matched = append(matched, "none")
+		}
+		fmt.Fprintf(stdout, "%5d bytes: openssl matches %s; openssl->cbccts %s, cbccts->openssl %s: %s\n",
+			n, strings.Join(matched, " "), okString(toUs), okString(toThem), status)
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d lengths mismatched", failed, len(interopLengths))
+	}
+	return nil
+}
+
+func okString(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "differs"
+}
+
+// the openssl command
+type openssl struct {
+	path   string
+	cipher string
+}
+
+var opensslVersion = regexp.MustCompile(`^OpenSSL (\d+)\.(\d+)\.\S+`)
+
+// the version of openssl, which must be 3.0 or later for the CTS ciphers
+func (o *openssl) version() (string, error) {
+	out, err := exec.Command(o.path, "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("running %s: %v", o.path, err)
+	}
+	m := opensslVersion.FindSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("unrecognized %s version %q", o.path, bytes.TrimSpace(out))
+	}
+	if major, _ := strconv.Atoi(string(m[1])); major < 3 {
+		return "", fmt.Errorf("%s is required to be 3.0 or later", m[0])
+	}
+	return string(m[0]), nil
+}
+
+// run openssl enc on data
+func (o *openssl) crypt(encrypt bool, key, iv, data []byte) ([]byte, error) {
+	op := "-d"
+	if encrypt {
+		op = "-e"
+	}
+	cmd := exec.Command(o.path, "enc", op, "-"+o.cipher, "-nopad", "-K", hex.EncodeToString(key), "-iv", hex.EncodeToString(iv))
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("openssl enc: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
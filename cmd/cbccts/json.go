@@ -0,0 +1,106 @@
+/*
+	json.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// the result of enc or dec, written by -json
+type result struct {
+	Command  string     `json:"command"`
+	Output   string     `json:"output"` // "-" for the standard output
+	BytesIn  int64      `json:"bytes_in"`
+	BytesOut int64      `json:"bytes_out"`
+	Cipher   string     `json:"cipher,omitempty"`
+	Format   string     `json:"format,omitempty"`
+	MAC      string     `json:"mac,omitempty"`      // the trailer of enc, in hex
+	Verified bool       `json:"verified,omitempty"` // dec checked the MACs
+	Chunked  bool       `json:"chunked,omitempty"`
+	Compress string     `json:"compress,omitempty"`
+	KDF      *kdfResult `json:"kdf,omitempty"`
+	Seconds  float64    `json:"seconds"`
+	Error    string     `json:"error,omitempty"`
+}
+
+type kdfResult struct {
+	Name   string            `json:"name"`
+	Params map[string]uint32 `json:"params"`
+	Salt   string            `json:"salt"`
+}
+
+// the parameters of a header, by the names of its KDF
+func newKDFResult(k *container.KDFParams) *kdfResult {
+	p := k.Params
+	var params map[string]uint32
+	switch k.KDF {
+	case container.PBKDF2:
+		params = map[string]uint32{"iterations": p[0]}
+	case container.Scrypt:
+		params = map[string]uint32{"log2_n": p[0], "r": p[1], "p": p[2]}
+	case container.Argon2id:
+		params = map[string]uint32{"time": p[0], "memory_kib": p[1], "threads": p[2]}
+	}
+	return &kdfResult{k.KDF.String(), params, hex.EncodeToString(k.Salt)}
+}
+
+// record the header of a passphrase-encrypted file
+func (res *result) setHeader(h *container.Header) {
+	res.Cipher = h.Cipher.String()
+	res.Format = fmt.Sprintf("CS%d", h.Format)
+	res.Chunked = h.Flags&container.FlagChunked != 0
+	if c := h.Compression(); c != "none" {
+		res.Compress = c
+	}
+	res.KDF = newKDFResult(&h.KDF)
+}
+
+func (res *result) setMAC(sum []byte) {
+	res.MAC = hex.EncodeToString(sum)
+}
+
+// write the result of a finished command as a line of JSON
+func (res *result) write(w io.Writer, start time.Time, err error) error {
+	res.Seconds = time.Since(start).Seconds()
+	if err != nil {
+		res.Error = err.Error()
+	}
+	b, e := json.Marshal(res)
+	if e != nil {
+		return e
+	}
+	_, e = w.Write(append(b, '\n'))
+	return e
+}
+
+// byte counters of the input and the output
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,26 @@
+/*
+	kdf.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"crypto/rand"
+
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// default parameters of the KDFs; a variable for the tests
+var kdfDefaults = container.DefaultKDFParams
+
+// set the KDF of a header to the named one with its default parameters and a random salt
+func initKDF(h *container.Header, name string) (err error) {
+	if h.KDF.KDF, err = container.ParseKDF(name); err != nil {
+		return err
+	}
+	h.KDF.Params = kdfDefaults(h.KDF.KDF)
+	h.KDF.Salt = make([]byte, 16)
+	_, err = rand.Read(h.KDF.Salt)
+	return err
+}
@@ -0,0 +1,86 @@
+/*
+	key.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maximum size of a key read from a file or a file descriptor
+const maxKeyFile = 1024
+
+// the sources of a raw key; a key on the command line leaks through process listings, the others do not
+type keySource struct {
+	hex  string // -key
+	file string // -keyfile
+	env  string // -key-env
+	fd   int    // -key-fd; -1 if not given
+}
+
+// the number of sources given
+func (s *keySource) count() int {
+	n := 0
+	for _, given := range []bool{s.hex != "", s.file != "", s.env != "", s.fd >= 0} {
+		if given {
+			n++
+		}
+	}
+	return n
+}
+
+// read the key from the source given
+func (s *keySource) read() ([]byte, error) {
+	switch {
+	case s.file != "":
+		f, err := os.Open(s.file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readKeyFile(f)
+	case s.env != "":
+		v, ok := os.LookupEnv(s.env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s not set", s.env)
+		}
+		return decodeHexKey(v)
+	case s.fd >= 0:
+		f := os.NewFile(uintptr(s.fd), "key-fd")
+		if f == nil {
+			return nil, fmt.Errorf("invalid file descriptor %d", s.fd)
+		}
+		defer f.Close()
+		return readKeyFile(f)
+	}
+	return decodeHexKey(s.hex)
+}
+
+// a key file holds the key in hex, with optional surrounding whitespace, or the raw key bytes
+func readKeyFile(r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxKeyFile+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxKeyFile {
+		return nil, fmt.Errorf("key file larger than %d bytes", maxKeyFile)
+	}
+	if k, err := hex.DecodeString(string(bytes.TrimSpace(b))); err == nil && len(k) > 0 {
+		return k, nil
+	}
+	return b, nil
+}
+
+func decodeHexKey(s string) ([]byte, error) {
+	k, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+	return k, nil
+}
@@ -0,0 +1,132 @@
+/*
+	main.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Command cbccts encrypts and decrypts files with a block cipher in CBC-CTS mode, to interoperate with the CTS output of other systems.
+
+	The cipher is AES by default, with the key size choosing AES-128, AES-192 or AES-256; -cipher selects another one,
+	such as des3, blowfish or twofish, including the 8-byte block ciphers. The IV is given in hex, and so is the key with -key;
+	as a key on the command line is visible in process listings, it may instead be read from a file (-keyfile), in hex or binary,
+	from an environment variable in hex (-key-env), or from an inherited file descriptor (-key-fd).
+	The ciphertext has the same length as the plaintext, which must be at least one block.
+	The format, -format cs1, cs2 or cs3, defaults to CS3, the one of Kerberos; openssl enc uses CS1.
+
+		cbccts enc -key 000102030405060708090a0b0c0d0e0f -iv 00000000000000000000000000000000 -in plain.txt -out cipher.bin
+		cbccts dec -key 000102030405060708090a0b0c0d0e0f -iv 00000000000000000000000000000000 -format cs1 < cipher.bin
+
+	enc -armor base64 or -armor hex writes the output as text in lines of 64 characters, to paste into tickets and configs;
+	dec detects armored input by itself.
+
+	Without -in or -out, the standard input or output is used. The data is streamed, with only a small buffer in memory,
+	so that the command can sit in a pipeline:
+
+		tar c dir | cbccts enc -passphrase "correct horse" | ssh host 'cat > dir.tar.enc'
+
+	With -passphrase, the key, AES-256 by default, is derived with Argon2id, scrypt or PBKDF2 (-kdf), and the output is a file
+	of package container: a versioned header recording the cipher, the format, the KDF parameters, the salt and a random IV,
+	followed by the ciphertext and an HMAC-SHA-256 trailer over both. dec reads them back from the header, and refuses a file
+	whose MAC does not match; an -in file is verified before any plaintext is written, and a stream before its final blocks.
+	-mac=false omits the trailer, and dec then requires -verify=false, which also skips checking a trailer.
+	enc -compress gzip or -compress zstd compresses the plaintext before encryption, which the header records for dec;
+	the ciphertext length then reveals how compressible the plaintext is.
+	For large files, enc -chunked writes the ciphertext as chunks of 64 KiB, each with its own MAC and sequence number,
+	so that dec detects truncation, reordering and corruption as it reads, without a pass over the whole file.
+
+	enc -sparse, for a sparse -in file such as a disk image, finds its holes with SEEK_HOLE and SEEK_DATA and stores them
+	as chunks without ciphertext, instead of encrypting the zeros; dec writes them back as holes of an -out file.
+	The size of the holes is visible in the file. Without -sparse, the zeros of the holes are encrypted like any data.
+
+	An enc -chunked of an -in file into an -out file that was interrupted, by a crash or a kill, can be continued with
+	enc -resume and the same passphrase: the chunks already written are verified and compared with the input, a torn last chunk
+	is dropped, and the encryption goes on from there with the settings of the header.
+
+		cbccts enc -passphrase "correct horse" -kdf scrypt -in plain.txt -out cipher.bin
+		cbccts dec -passphrase "correct horse" -in cipher.bin
+
+	With -r, enc encrypts the directory tree -in into the directory -out file by file, keeping the relative paths,
+	and writes a manifest, MANIFEST.cbccts: a container with a MAC holding the paths, sizes, per-file IVs and MACs of the files,
+	and the content of files shorter than a block. dec -r verifies the manifest and every file against it, and refuses
+	missing, modified or extra files, before writing any file. With a raw key, the keys of the manifest are derived with HKDF.
+
+		cbccts enc -r -passphrase "correct horse" -in photos -out photos.enc
+		cbccts dec -r -passphrase "correct horse" -in photos.enc -out photos
+
+	enc -json and dec -json write the result as a line of JSON, for scripts and CI: the output, the bytes read and written,
+	the cipher and format, the MAC, the KDF parameters and the time taken, or the error. It goes to the standard output
+	when the data goes to an -out file, and to the standard error otherwise.
+
+	enc -shred, with -in and -out files, decrypts the output once more and, if it matches the input, overwrites the input with
+	random bytes and removes it. This is best effort only: SSDs and flash remap their writes, and copy-on-write or journaling
+	file systems, snapshots and backups may keep the old content; on those, only data encrypted from the start is safe.
+
+	rekey decrypts with the flags of dec and encrypts again with the flags of enc prefixed by new-, passing the plaintext
+	in memory; the cipher and the format are kept unless -new-cipher or -new-format is given:
+
+		cbccts rekey -passphrase "correct horse" -new-passphrase "battery staple" -in cipher.bin -out rekeyed.bin
+		cbccts rekey -key 00010203... -iv 0f0e0d0c... -new-passphrase "battery staple" -new-cipher twofish -in cipher.bin -out rekeyed.bin
+
+	inspect prints the header of such a file, which needs no passphrase, to debug interoperability problems:
+
+		cbccts inspect cipher.bin
+
+	selftest runs the RFC 3962 and NIST known-answer vectors built into the binary, to verify a deployment.
+
+	interop -with-openssl round-trips random data of several lengths through the openssl command, 3.0 or later, in both directions,
+	and reports which formats match the output of openssl enc, which is CS1, and any mismatch.
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: cbccts <command> [flags]
+
+commands:
+  enc        encrypt
+  dec        decrypt
+  rekey      decrypt and encrypt again with a new key, without writing the plaintext
+  inspect    print the header of a passphrase-encrypted file
+  selftest   run the built-in known-answer tests
+  interop    check against another CTS implementation, such as the openssl command
+
+Run 'cbccts <command> -h' for the flags of a command.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "cbccts: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run a command line, without the program name
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("no command")
+	}
+	switch args[0] {
+	case "enc":
+		return runCrypt(true, args[1:], stdin, stdout, stderr)
+	case "dec":
+		return runCrypt(false, args[1:], stdin, stdout, stderr)
+	case "rekey":
+		return runRekey(args[1:], stdin, stdout, stderr)
+	case "inspect":
+		return runInspect(args[1:], stdin, stdout, stderr)
+	case "selftest":
+		return runSelfTest(args[1:], stdout, stderr)
+	case "interop":
+		return runInterop(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+	fmt.Fprint(stderr, usage)
+	return fmt.Errorf("unknown command %q", args[0])
+}
@@ -0,0 +1,798 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// run a command line with stdin, and return stdout
+func runCmd(t *testing.T, stdin []byte, args ...string) ([]byte, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(args, bytes.NewReader(stdin), &stdout, &stderr)
+	return stdout.Bytes(), err
+}
+
+// make the KDFs cheap for the rest of a test
+func fastKDF(t *testing.T) {
+	defaults := kdfDefaults
+	kdfDefaults = func(k container.KDF) [3]uint32 {
+		return map[container.KDF][3]uint32{container.PBKDF2: {1000, 0, 0}, container.Scrypt: {10, 8, 1}, container.Argon2id: {1, 1024, 1}}[k]
+	}
+	t.Cleanup(func() { kdfDefaults = defaults })
+}
+
+func TestEncDec(t *testing.T) {
+
+	key := "000102030405060708090a0b0c0d0e0f"
+	iv := "0f0e0d0c0b0a09080706050403020100"
+	msg := []byte("a message to another system's CTS implementation")
+
+	for _, format := range []string{"cs1", "cs2", "CS3"} {
+		ct, err := runCmd(t, msg, "enc", "-key", key, "-iv", iv, "-format", format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the ciphertext is that of the package
+		k, _ := hex.DecodeString(key)
+		v, _ := hex.DecodeString(iv)
+		f, _ := cbccts.ParseFormat(format)
+		b, _ := aes.NewCipher(k)
+		want := make([]byte, len(msg))
+		cbccts.NewCBCCTSEncrypter(b, v, f).CryptBlocks(want, msg)
+		if !bytes.Equal(ct, want) {
+			t.Errorf("%s: unexpected ciphertext", format)
+		}
+
+		pt, err := runCmd(t, ct, "dec", "-key", key, "-iv", iv, "-format", format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pt, msg) {
+			t.Errorf("%s: decryption failed", format)
+		}
+	}
+
+	// files
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	os.WriteFile(in, msg, 0600)
+	if _, err := runCmd(t, nil, "enc", "-key", key, "-iv", iv, "-in", in, "-out", out); err != nil {
+		t.Fatal(err)
+	}
+	ct, _ := os.ReadFile(out)
+	pt, err := runCmd(t, ct, "dec", "-key", key, "-iv", iv)
+	if err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("file round trip failed: %v", err)
+	}
+
+	// a stream longer than the buffer
+	long := bytes.Repeat(msg, 5000)
+	ct, err = runCmd(t, long, "enc", "-key", key, "-iv", iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, _ := hex.DecodeString(key)
+	v, _ := hex.DecodeString(iv)
+	b, _ := aes.NewCipher(k)
+	want := make([]byte, len(long))
+	cbccts.NewCBCCTSEncrypter(b, v, cbccts.CS3).CryptBlocks(want, long)
+	if !bytes.Equal(ct, want) {
+		t.Errorf("unexpected ciphertext of a long stream")
+	}
+	pt, err = runCmd(t, ct, "dec", "-key", key, "-iv", iv)
+	if err != nil || !bytes.Equal(pt, long) {
+		t.Errorf("long stream round trip failed: %v", err)
+	}
+}
+
+func TestEncErrors(t *testing.T) {
+
+	key := "000102030405060708090a0b0c0d0e0f"
+	iv := "00000000000000000000000000000000"
+	for _, c := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"enc", "-key", "00", "-iv", iv}, "key size"},
+		{[]string{"enc", "-key", key, "-iv", "00"}, "IV"},
+		{[]string{"enc", "-key", key, "-iv", iv, "-format", "cs4"}, "format"},
+		{[]string{"enc", "-key", key, "-iv", iv, "extra"}, "arguments"},
+		{[]string{"enc", "-key", key, "-iv", iv, "-kdf", "scrypt"}, "-kdf requires -passphrase"},
+		{[]string{"frobnicate"}, "command"},
+	} {
+		if _, err := runCmd(t, make([]byte, 32), c.args...); err == nil || !strings.Contains(err.Error(), c.msg) {
+			t.Errorf("%v: unexpected error %v", c.args, err)
+		}
+	}
+	if _, err := runCmd(t, make([]byte, 15), "enc", "-key", key, "-iv", iv); err == nil {
+		t.Errorf("short input accepted")
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+
+	// cheap parameters for the test
+	fastKDF(t)
+
+	msg := []byte("a message under a passphrase")
+	for _, kdf := range []string{"pbkdf2", "scrypt", "argon2id"} {
+		ct, err := runCmd(t, msg, "enc", "-passphrase", "correct horse", "-kdf", kdf, "-format", "cs1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := container.ReadHeader(bytes.NewReader(ct))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.KDF.KDF.String() != kdf || h.KDF.Params != kdfDefaults(h.KDF.KDF) || h.Format != cbccts.CS1 || h.Cipher != container.AES256 {
+			t.Errorf("%s: unexpected header %+v", kdf, h)
+		}
+
+		pt, err := runCmd(t, ct, "dec", "-passphrase", "correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pt, msg) {
+			t.Errorf("%s: decryption failed", kdf)
+		}
+		if pt, _ := runCmd(t, ct, "dec", "-passphrase", "wrong"); bytes.Equal(pt, msg) {
+			t.Errorf("%s: decrypted with a wrong passphrase", kdf)
+		}
+
+		// flags repeating the header must agree with it
+		if _, err := runCmd(t, ct, "dec", "-passphrase", "correct horse", "-format", "CS1", "-cipher", "aes"); err != nil {
+			t.Errorf("%s: %v", kdf, err)
+		}
+		if _, err := runCmd(t, ct, "dec", "-passphrase", "correct horse", "-format", "cs3"); err == nil || !strings.Contains(err.Error(), "conflicts") {
+			t.Errorf("%s: conflicting format: unexpected error %v", kdf, err)
+		}
+		if _, err := runCmd(t, ct, "dec", "-passphrase", "correct horse", "-cipher", "twofish"); err == nil || !strings.Contains(err.Error(), "conflicts") {
+			t.Errorf("%s: conflicting cipher: unexpected error %v", kdf, err)
+		}
+		if _, err := runCmd(t, ct, "dec", "-passphrase", "correct horse", "-kdf", kdf); err == nil {
+			t.Errorf("%s: -kdf accepted by dec", kdf)
+		}
+	}
+
+	// the MAC trailer, in a stream and in a file
+	ct, err := runCmd(t, msg, "enc", "-passphrase", "p", "-kdf", "pbkdf2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	for i, edit := range []func([]byte) []byte{
+		func(b []byte) []byte { b[len(b)-40] ^= 1; return b }, // the ciphertext
+		func(b []byte) []byte { b[len(b)-1] ^= 1; return b },  // the trailer
+		func(b []byte) []byte { return b[:len(b)-1] },         // truncated
+	} {
+		bad := edit(append([]byte(nil), ct...))
+		if pt, err := runCmd(t, bad, "dec", "-passphrase", "p"); err != container.ErrMAC || bytes.Contains(pt, msg[len(msg)-16:]) {
+			t.Errorf("edit %d: stream: unexpected error %v", i, err)
+		}
+		os.WriteFile(in, bad, 0600)
+		if _, err := runCmd(t, nil, "dec", "-passphrase", "p", "-in", in, "-out", out); err != container.ErrMAC {
+			t.Errorf("edit %d: file: unexpected error %v", i, err)
+		}
+		if _, err := os.Stat(out); err == nil {
+			t.Errorf("edit %d: output of an unverified file written", i)
+		}
+	}
+	if pt, err := runCmd(t, ct, "dec", "-passphrase", "p", "-verify=false"); err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("-verify=false: %v", err)
+	}
+
+	// chunks
+	long := bytes.Repeat(msg, 10000)
+	ct, err = runCmd(t, long, "enc", "-passphrase", "p", "-kdf", "pbkdf2", "-chunked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := runCmd(t, ct, "dec", "-passphrase", "p"); err != nil || !bytes.Equal(pt, long) {
+		t.Errorf("chunked: round trip failed: %v", err)
+	}
+	if _, err := runCmd(t, ct[:len(ct)-100], "dec", "-passphrase", "p"); err != container.ErrTruncated {
+		t.Errorf("chunked: unexpected error %v", err)
+	}
+	if _, err := runCmd(t, msg, "enc", "-passphrase", "p", "-chunked", "-mac=false"); err == nil {
+		t.Errorf("-chunked without -mac accepted")
+	}
+
+	// a file without the MAC is refused unless verification is disabled
+	ct, _ = runCmd(t, msg, "enc", "-passphrase", "p", "-kdf", "pbkdf2", "-mac=false")
+	if _, err := runCmd(t, ct, "dec", "-passphrase", "p"); err == nil {
+		t.Errorf("file without a MAC accepted")
+	}
+	if pt, err := runCmd(t, ct, "dec", "-passphrase", "p", "-verify=false"); err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("file without a MAC: %v", err)
+	}
+
+	if _, err := runCmd(t, msg, "enc", "-passphrase", "x", "-kdf", "md5"); err == nil {
+		t.Errorf("unknown KDF accepted")
+	}
+	if _, err := runCmd(t, msg, "enc", "-passphrase", "x", "-key", "00"); err == nil {
+		t.Errorf("-passphrase with -key accepted")
+	}
+}
+
+func TestCiphers(t *testing.T) {
+
+	fastKDF(t)
+
+	msg := []byte("a message longer than a block of any cipher")
+	for _, name := range container.CipherNames() {
+		c, _ := container.ParseCipher(name)
+		key := hex.EncodeToString(bytes.Repeat([]byte{7}, c.KeySize()))
+		iv := hex.EncodeToString(make([]byte, c.BlockSize()))
+
+		ct, err := runCmd(t, msg, "enc", "-cipher", name, "-key", key, "-iv", iv)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		pt, err := runCmd(t, ct, "dec", "-cipher", name, "-key", key, "-iv", iv)
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%s: round trip failed: %v", name, err)
+		}
+
+		// the cipher is recorded in the header
+		ct, err = runCmd(t, msg, "enc", "-cipher", name, "-passphrase", "p", "-kdf", "pbkdf2")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		pt, err = runCmd(t, ct, "dec", "-passphrase", "p")
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%s: passphrase round trip failed: %v", name, err)
+		}
+	}
+
+	if _, err := runCmd(t, msg, "enc", "-cipher", "aes128", "-key", strings.Repeat("00", 32), "-iv", strings.Repeat("00", 16)); err == nil {
+		t.Errorf("AES key size mismatch accepted")
+	}
+	if _, err := runCmd(t, msg, "enc", "-cipher", "rot13", "-key", "00", "-iv", "00"); err == nil {
+		t.Errorf("unknown cipher accepted")
+	}
+}
+
+func TestKeySources(t *testing.T) {
+
+	key := "000102030405060708090a0b0c0d0e0f"
+	iv := "0f0e0d0c0b0a09080706050403020100"
+	msg := []byte("a message under a key kept off the command line")
+	want, err := runCmd(t, msg, "enc", "-key", key, "-iv", iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	hexFile, binFile := filepath.Join(dir, "hex"), filepath.Join(dir, "bin")
+	os.WriteFile(hexFile, []byte(key+"\n"), 0600)
+	raw, _ := hex.DecodeString(key)
+	os.WriteFile(binFile, raw, 0600)
+	os.Setenv("CBCCTS_TEST_KEY", key)
+	defer os.Unsetenv("CBCCTS_TEST_KEY")
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw.Write(raw)
+	pw.Close()
+
+	for _, src := range [][]string{
+		{"-keyfile", hexFile},
+		{"-keyfile", binFile},
+		{"-key-env", "CBCCTS_TEST_KEY"},
+		{"-key-fd", fmt.Sprint(pr.Fd())},
+	} {
+		ct, err := runCmd(t, msg, append([]string{"enc", "-iv", iv}, src...)...)
+		if err != nil || !bytes.Equal(ct, want) {
+			t.Errorf("%v: unexpected ciphertext: %v", src, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"enc", "-iv", iv, "-key", key, "-keyfile", hexFile},
+		{"enc", "-iv", iv, "-key-env", "CBCCTS_TEST_NO_SUCH_KEY"},
+		{"enc", "-iv", iv, "-keyfile", filepath.Join(dir, "missing")},
+		{"enc", "-passphrase", "p", "-keyfile", hexFile},
+	} {
+		if _, err := runCmd(t, msg, args...); err == nil {
+			t.Errorf("%v accepted", args)
+		}
+	}
+}
+
+func TestArmor(t *testing.T) {
+
+	fastKDF(t)
+
+	key := "000102030405060708090a0b0c0d0e0f"
+	iv := "0f0e0d0c0b0a09080706050403020100"
+	msg := bytes.Repeat([]byte("a message pasted into a ticket. "), 10)
+	binary, _ := runCmd(t, msg, "enc", "-key", key, "-iv", iv)
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	for _, armor := range []string{"base64", "hex"} {
+		ct, err := runCmd(t, msg, "enc", "-key", key, "-iv", iv, "-armor", armor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(ct), "\n"), "\n") {
+			if len(line) > 64 {
+				t.Errorf("%s: line of %d characters", armor, len(line))
+			}
+		}
+		var decoded []byte
+		if armor == "hex" {
+			decoded, _ = hex.DecodeString(strings.ReplaceAll(string(ct), "\n", ""))
+		} else {
+			decoded, _ = base64.StdEncoding.DecodeString(strings.ReplaceAll(string(ct), "\n", ""))
+		}
+		if !bytes.Equal(decoded, binary) {
+			t.Errorf("%s: unexpected armored ciphertext", armor)
+		}
+		if pt, err := runCmd(t, ct, "dec", "-key", key, "-iv", iv); err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%s: decryption failed: %v", armor, err)
+		}
+
+		// a container, from a file
+		ct, err = runCmd(t, msg, "enc", "-passphrase", "p", "-kdf", "pbkdf2", "-armor", armor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(in, ct, 0600)
+		if pt, err := runCmd(t, nil, "dec", "-passphrase", "p", "-in", in); err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%s: container decryption failed: %v", armor, err)
+		}
+	}
+
+	if _, err := runCmd(t, msg, "enc", "-key", key, "-iv", iv, "-armor", "uuencode"); err == nil {
+		t.Errorf("unknown armor accepted")
+	}
+}
+
+func TestInspect(t *testing.T) {
+
+	fastKDF(t)
+
+	msg := bytes.Repeat([]byte("x"), 100000)
+	for _, c := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-cipher", "des3", "-format", "cs1"}, []string{"des3 (24-byte key, 8-byte block)", "CS1", "mac\n", "HMAC-SHA-256 trailer", "100000 bytes"}},
+		{[]string{"-mac=false", "-armor", "hex"}, []string{"aes256", "armor      hex", "flags      none", "mac        none"}},
+		{[]string{"-chunked"}, []string{"mac, chunked", "per chunk", "chunks     2", "100000 bytes"}},
+	} {
+		ct, err := runCmd(t, msg, append([]string{"enc", "-passphrase", "p", "-kdf", "scrypt"}, c.args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := runCmd(t, ct, "inspect")
+		if err != nil {
+			t.Fatalf("%v: %v", c.args, err)
+		}
+		for _, want := range append(c.want, "scrypt N=2^10 r=8 p=1", "version    1") {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("%v: %q not in\n%s", c.args, want, out)
+			}
+		}
+	}
+
+	if _, err := runCmd(t, msg, "inspect"); err != container.ErrNotContainer {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+
+	out, err := runCmd(t, nil, "selftest", "-v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "PASS RFC 3962") || !strings.Contains(string(out), " 0 failed\n") || strings.Contains(string(out), "FAIL") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
+
+func TestInterop(t *testing.T) {
+
+	if _, err := runCmd(t, nil, "interop"); err == nil {
+		t.Errorf("interop without a tool accepted")
+	}
+	if _, err := runCmd(t, nil, "interop", "-with-openssl", "-openssl", filepath.Join(t.TempDir(), "openssl")); err == nil {
+		t.Errorf("missing openssl accepted")
+	}
+
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not installed")
+	}
+	out, err := runCmd(t, nil, "interop", "-with-openssl")
+	if err != nil && strings.Contains(err.Error(), "3.0 or later") {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "17 bytes: openssl matches CS1;") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
+
+func TestDirectory(t *testing.T) {
+
+	fastKDF(t)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	files := map[string][]byte{
+		"empty":         {},
+		"short":         []byte("short"),
+		"a/block":       bytes.Repeat([]byte{1}, 16),
+		"a/b/text":      bytes.Repeat([]byte("text "), 200),
+		"a/b/large.bin": bytes.Repeat([]byte{2, 3, 5, 7, 11}, 30000),
+	}
+	for name, data := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, data, 0640)
+	}
+	os.MkdirAll(filepath.Join(src, "empty dir"), 0755)
+
+	check := func(out string) {
+		t.Helper()
+		for name, data := range files {
+			got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(name)))
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("%s: unexpected content: %v", name, err)
+			}
+		}
+		if fi, err := os.Stat(filepath.Join(out, "empty dir")); err != nil || !fi.IsDir() {
+			t.Errorf("empty directory not restored: %v", err)
+		}
+	}
+
+	for i, keyArgs := range [][]string{
+		{"-passphrase", "p", "-kdf", "pbkdf2"},
+		{"-key", "000102030405060708090a0b0c0d0e0f", "-cipher", "twofish"},
+	} {
+		enc, out := filepath.Join(dir, fmt.Sprint("enc", i)), filepath.Join(dir, fmt.Sprint("out", i))
+		if _, err := runCmd(t, nil, append([]string{"enc", "-r", "-in", src, "-out", enc}, keyArgs...)...); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(filepath.Join(enc, "a", "b", "text")); bytes.Contains(got, []byte("text text")) {
+			t.Errorf("file not encrypted")
+		}
+		decArgs := keyArgs
+		if i == 0 {
+			decArgs = keyArgs[:2]
+		}
+		if _, err := runCmd(t, nil, append([]string{"dec", "-r", "-in", enc, "-out", out}, decArgs...)...); err != nil {
+			t.Fatal(err)
+		}
+		check(out)
+	}
+
+	// tampering is detected before anything is written
+	enc := filepath.Join(dir, "enc0")
+	dec := func() error {
+		out := filepath.Join(dir, "tampered")
+		os.RemoveAll(out)
+		_, err := runCmd(t, nil, "dec", "-r", "-in", enc, "-out", out, "-passphrase", "p")
+		if _, e := os.Stat(filepath.Join(out, "short")); err != nil && e == nil {
+			t.Errorf("output written before verification")
+		}
+		return err
+	}
+	text := filepath.Join(enc, "a", "b", "text")
+	ct, _ := os.ReadFile(text)
+	ct[100] ^= 1
+	os.WriteFile(text, ct, 0600)
+	if err := dec(); err == nil || !strings.Contains(err.Error(), "MAC") {
+		t.Errorf("modified file: unexpected error %v", err)
+	}
+	ct[100] ^= 1
+	os.WriteFile(text, ct, 0600)
+	if err := dec(); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(enc, "injected"), []byte("x"), 0600)
+	if err := dec(); err == nil {
+		t.Errorf("file not in the manifest accepted")
+	}
+	os.Remove(filepath.Join(enc, "injected"))
+	os.Rename(text, text+".moved")
+	if err := dec(); err == nil {
+		t.Errorf("missing file accepted")
+	}
+	os.Rename(text+".moved", text)
+
+	if _, err := runCmd(t, nil, "dec", "-r", "-in", enc, "-out", filepath.Join(dir, "wrong"), "-passphrase", "wrong"); err != container.ErrMAC {
+		t.Errorf("wrong passphrase: unexpected error %v", err)
+	}
+	if _, err := runCmd(t, nil, "enc", "-r", "-in", src, "-passphrase", "p"); err == nil {
+		t.Errorf("-r without -out accepted")
+	}
+}
+
+func TestCompress(t *testing.T) {
+
+	fastKDF(t)
+
+	msg := bytes.Repeat([]byte("a backup compresses well. "), 20000)
+	for _, args := range [][]string{
+		{"-compress", "gzip"},
+		{"-compress", "zstd"},
+		{"-compress", "zstd", "-chunked"},
+		{"-compress", "gzip", "-mac=false"},
+	} {
+		ct, err := runCmd(t, msg, append([]string{"enc", "-passphrase", "p", "-kdf", "pbkdf2"}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) > len(msg)/10 {
+			t.Errorf("%v: %d bytes of ciphertext", args, len(ct))
+		}
+		dec := []string{"dec", "-passphrase", "p"}
+		if args[len(args)-1] == "-mac=false" {
+			dec = append(dec, "-verify=false")
+		}
+		if pt, err := runCmd(t, ct, dec...); err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%v: round trip failed: %v", args, err)
+		}
+		if out, _ := runCmd(t, ct, "inspect"); !strings.Contains(string(out), "compress   "+args[1]) {
+			t.Errorf("%v: compression not shown\n%s", args, out)
+		}
+	}
+
+	if _, err := runCmd(t, msg, "enc", "-passphrase", "p", "-compress", "lzma"); err == nil {
+		t.Errorf("unknown compression accepted")
+	}
+	if _, err := runCmd(t, msg, "enc", "-key", strings.Repeat("00", 16), "-iv", strings.Repeat("00", 16), "-compress", "gzip"); err == nil {
+		t.Errorf("-compress without a header accepted")
+	}
+}
+
+func TestRekey(t *testing.T) {
+
+	fastKDF(t)
+
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")
+	msg := bytes.Repeat([]byte("a secret kept under a rotating key. "), 5000)
+	key, iv := strings.Repeat("11", 24), strings.Repeat("22", 8)
+	if _, err := runCmd(t, msg, "enc", "-cipher", "des3", "-format", "cs1", "-key", key, "-iv", iv, "-out", a); err != nil {
+		t.Fatal(err)
+	}
+
+	// a raw key to a passphrase, keeping the cipher and the format
+	if _, err := runCmd(t, nil, "rekey", "-cipher", "des3", "-format", "cs1", "-key", key, "-iv", iv,
+		"-new-passphrase", "new", "-new-kdf", "pbkdf2", "-in", a, "-out", b); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := runCmd(t, nil, "inspect", b)
+	if !strings.Contains(string(out), "des3") || !strings.Contains(string(out), "CS1") {
+		t.Errorf("cipher or format not kept\n%s", out)
+	}
+	if pt, err := runCmd(t, nil, "dec", "-passphrase", "new", "-in", b); err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("rekeyed file: %v", err)
+	}
+
+	// a passphrase to another, with a new cipher
+	if _, err := runCmd(t, nil, "rekey", "-passphrase", "new", "-new-passphrase", "newer", "-new-kdf=pbkdf2", "-new-cipher", "twofish", "-new-chunked", "-in", b, "-out", c); err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := runCmd(t, nil, "dec", "-passphrase", "newer", "-in", c); err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("rekeyed file: %v", err)
+	}
+
+	// a failed decryption leaves no output
+	os.Remove(c)
+	if _, err := runCmd(t, nil, "rekey", "-passphrase", "wrong", "-new-passphrase", "newer", "-in", b, "-out", c); err == nil {
+		t.Errorf("wrong passphrase accepted")
+	}
+	if _, err := os.Stat(c); err == nil {
+		t.Errorf("output of a failed rekey left")
+	}
+	if _, err := runCmd(t, nil, "rekey", "-passphrase", "new", "-new-passphrase", "newer", "-new-cipher", "rot13", "-in", b, "-out", c); err == nil || !strings.Contains(err.Error(), "encryption") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := runCmd(t, nil, "rekey", "-passphrase", "new", "-new-passphrase", "newer"); err == nil {
+		t.Errorf("rekey without -in accepted")
+	}
+}
+
+func TestShred(t *testing.T) {
+
+	fastKDF(t)
+
+	dir := t.TempDir()
+	msg := bytes.Repeat([]byte("plaintext not to be left on the disk. "), 3000)
+	key, iv := strings.Repeat("33", 16), strings.Repeat("44", 16)
+	for i, args := range [][]string{
+		{"-key", key, "-iv", iv, "-format", "cs1"},
+		{"-passphrase", "p", "-kdf", "pbkdf2", "-chunked", "-compress", "gzip"},
+		{"-passphrase", "p", "-kdf", "pbkdf2", "-mac=false"},
+	} {
+		in, out := filepath.Join(dir, fmt.Sprint("in", i)), filepath.Join(dir, fmt.Sprint("out", i))
+		if err := os.WriteFile(in, msg, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := runCmd(t, nil, append([]string{"enc", "-shred", "-in", in, "-out", out}, args...)...); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		if _, err := os.Stat(in); !os.IsNotExist(err) {
+			t.Errorf("%v: input not removed", args)
+		}
+		dec := append([]string{"dec", "-in", out}, args[:2]...)
+		if args[0] == "-key" {
+			dec = append(dec, args[2:]...)
+		} else if args[len(args)-1] == "-mac=false" {
+			dec = append(dec, "-verify=false")
+		}
+		if pt, err := runCmd(t, nil, dec...); err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%v: output does not decrypt: %v", args, err)
+		}
+	}
+
+	// a failed encryption keeps the input
+	in := filepath.Join(dir, "short")
+	os.WriteFile(in, []byte("short"), 0600)
+	if _, err := runCmd(t, nil, "enc", "-shred", "-key", key, "-iv", iv, "-in", in, "-out", filepath.Join(dir, "x")); err == nil {
+		t.Errorf("short input accepted")
+	}
+	if _, err := os.Stat(in); err != nil {
+		t.Errorf("input removed after a failure: %v", err)
+	}
+	if _, err := runCmd(t, msg, "enc", "-shred", "-key", key, "-iv", iv); err == nil {
+		t.Errorf("-shred of stdin accepted")
+	}
+}
+
+func TestJSON(t *testing.T) {
+
+	fastKDF(t)
+
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	msg := bytes.Repeat([]byte("a result for the CI. "), 1000)
+	os.WriteFile(in, msg, 0600)
+
+	var res result
+	stdout, err := runCmd(t, nil, "enc", "-json", "-passphrase", "p", "-kdf", "pbkdf2", "-in", in, "-out", out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(stdout, &res); err != nil {
+		t.Fatalf("%v: %s", err, stdout)
+	}
+	fi, _ := os.Stat(out)
+	if res.Command != "enc" || res.Output != out || res.BytesIn != int64(len(msg)) || res.BytesOut != fi.Size() ||
+		res.Cipher != "aes256" || res.Format != "CS3" || len(res.MAC) != 64 || res.KDF == nil ||
+		res.KDF.Name != "pbkdf2" || res.KDF.Params["iterations"] != 1000 || len(res.KDF.Salt) != 32 || res.Error != "" {
+		t.Errorf("enc result %s", stdout)
+	}
+
+	res = result{}
+	stdout, err = runCmd(t, nil, "dec", "-json", "-passphrase", "p", "-in", out, "-out", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(stdout, &res); err != nil {
+		t.Fatalf("%v: %s", err, stdout)
+	}
+	if res.Command != "dec" || res.BytesIn != fi.Size() || res.BytesOut != int64(len(msg)) || !res.Verified {
+		t.Errorf("dec result %s", stdout)
+	}
+
+	// an error is reported in the JSON too, and on the standard error without -out
+	var stderr bytes.Buffer
+	err = run([]string{"dec", "-json", "-passphrase", "wrong", "-in", out}, nil, io.Discard, &stderr)
+	res = result{}
+	if err == nil || json.Unmarshal(stderr.Bytes(), &res) != nil || res.Error != err.Error() {
+		t.Errorf("error not reported: %v\n%s", err, stderr.Bytes())
+	}
+}
+
+func TestSparse(t *testing.T) {
+
+	fastKDF(t)
+
+	// an image of 64 MiB with data at three places
+	dir := t.TempDir()
+	img, ct, out := filepath.Join(dir, "img"), filepath.Join(dir, "ct"), filepath.Join(dir, "out")
+	f, err := os.Create(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Truncate(64 << 20)
+	for _, off := range []int64{0, 20 << 20, 64<<20 - 100} {
+		f.WriteAt(bytes.Repeat([]byte("data"), 25), off)
+	}
+	holes, err := fileHoles(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runCmd(t, nil, "enc", "-passphrase", "p", "-kdf", "pbkdf2", "-sparse", "-in", img, "-out", ct); err != nil {
+		t.Fatal(err)
+	}
+	fi, _ := os.Stat(ct)
+	if len(holes) > 0 && fi.Size() > 1<<20 {
+		t.Errorf("holes encrypted: %d bytes", fi.Size())
+	}
+	if info, _ := runCmd(t, nil, "inspect", ct); !strings.Contains(string(info), "sparse") {
+		t.Errorf("inspect:\n%s", info)
+	}
+	if _, err := runCmd(t, nil, "dec", "-passphrase", "p", "-in", ct, "-out", out); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.ReadFile(img)
+	b, _ := os.ReadFile(out)
+	if !bytes.Equal(a, b) {
+		t.Errorf("decrypted image differs")
+	}
+	if g, err := os.Open(out); err == nil {
+		if h, _ := fileHoles(g); len(holes) > 0 && len(h) == 0 {
+			t.Errorf("decrypted image is not sparse")
+		}
+		g.Close()
+	}
+
+	if _, err := runCmd(t, bytes.Repeat([]byte{1}, 100), "enc", "-passphrase", "p", "-sparse"); err == nil {
+		t.Errorf("-sparse without -in accepted")
+	}
+	if _, err := runCmd(t, nil, "enc", "-passphrase", "p", "-sparse", "-compress", "gzip", "-in", img); err == nil {
+		t.Errorf("-sparse with -compress accepted")
+	}
+}
+
+func TestResume(t *testing.T) {
+
+	fastKDF(t)
+
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	msg := bytes.Repeat([]byte("a long backup, interrupted. "), 20000)
+	os.WriteFile(in, msg, 0600)
+	if _, err := runCmd(t, nil, "enc", "-passphrase", "p", "-kdf", "pbkdf2", "-chunked", "-in", in, "-out", out); err != nil {
+		t.Fatal(err)
+	}
+	full, _ := os.ReadFile(out)
+
+	// an interruption within the third chunk
+	os.WriteFile(out, full[:2*container.ChunkSize+1000], 0600)
+	if _, err := runCmd(t, nil, "enc", "-resume", "-passphrase", "p", "-in", in, "-out", out); err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := runCmd(t, nil, "dec", "-passphrase", "p", "-in", out); err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("resumed file: %v", err)
+	}
+	if _, err := runCmd(t, nil, "enc", "-resume", "-passphrase", "p", "-in", in, "-out", out); err == nil {
+		t.Errorf("complete file resumed")
+	}
+	os.WriteFile(out, full[:2*container.ChunkSize+1000], 0600)
+	if _, err := runCmd(t, nil, "enc", "-resume", "-passphrase", "wrong", "-in", in, "-out", out); err == nil {
+		t.Errorf("wrong passphrase resumed")
+	}
+	if _, err := runCmd(t, nil, "enc", "-resume", "-passphrase", "p", "-cipher", "twofish", "-in", in, "-out", out); err == nil {
+		t.Errorf("-cipher accepted with -resume")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+	rekey.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// boolean flags of enc and dec, which take no separate value
+var boolFlags = map[string]bool{"mac": true, "verify": true, "chunked": true, "sparse": true, "resume": true, "r": true, "shred": true, "json": true}
+
+const rekeyUsage = `usage: cbccts rekey [dec flags] [-new-<enc flag> ...] -in FILE [-out FILE]
+
+Decrypt with the flags of dec, and encrypt again with the flags of enc prefixed by new-, as
+  cbccts rekey -passphrase old -new-passphrase new -new-cipher twofish -in a.enc -out b.enc
+The plaintext is passed between them in memory and never written to disk.
+The cipher and the format are kept unless -new-cipher or -new-format is given.
+`
+
+// the rekey command: dec piped into enc
+func runRekey(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	decArgs, encArgs, err := splitRekeyArgs(args)
+	if err != nil {
+		fmt.Fprint(stderr, rekeyUsage)
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := runCrypt(false, decArgs, stdin, pw, stderr)
+		pw.CloseWithError(err) // a failed decryption fails the encryption, which then removes its output
+		done <- err
+	}()
+	err = runCrypt(true, encArgs, pr, stdout, stderr)
+	pr.CloseWithError(io.ErrClosedPipe)
+	// a failed encryption closes the pipe under the decryption
+	if decErr := <-done; decErr != nil && !errors.Is(decErr, io.ErrClosedPipe) {
+		return fmt.Errorf("decryption: %v", decErr)
+	}
+	if err != nil {
+		return fmt.Errorf("encryption: %v", err)
+	}
+	return nil
+}
+
+// split the arguments of rekey into those of dec and enc
+func splitRekeyArgs(args []string) (decArgs, encArgs []string, err error) {
+	given := map[string]bool{}
+	kept := map[string]string{} // -cipher and -format of dec, kept for enc
+	var in string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "-") {
+			return nil, nil, fmt.Errorf("unexpected argument %q", a)
+		}
+		name := strings.TrimLeft(a, "-")
+		value, hasValue := "", false
+		if k := strings.IndexByte(name, '='); k >= 0 {
+			name, value, hasValue = name[:k], name[k+1:], true
+		}
+		flag := []string{"-" + name}
+		if !hasValue && !boolFlags[strings.TrimPrefix(name, "new-")] {
+			if i+1 == len(args) {
+				return nil, nil, fmt.Errorf("flag needs an argument: %s", a)
+			}
+			i++
+			value, hasValue = args[i], true
+		}
+		if hasValue {
+			flag = []string{"-" + name + "=" + value}
+		}
+
+		switch {
+		case name == "r" || name == "new-r":
+			return nil, nil, fmt.Errorf("rekey cannot be used with -r")
+		case name == "in":
+			in = value
+			decArgs = append(decArgs, flag...)
+		case name == "out":
+			encArgs = append(encArgs, flag...)
+		case strings.HasPrefix(name, "new-"):
+			name = strings.TrimPrefix(name, "new-")
+			given[name] = true
+			encArgs = append(encArgs, strings.Replace(flag[0], "-new-", "-", 1))
+		default:
+			if name == "cipher" || name == "format" {
+				kept[name] = value
+			}
+			decArgs = append(decArgs, flag...)
+		}
+	}
+	if in == "" {
+		return nil, nil, fmt.Errorf("rekey requires -in")
+	}
+
+	// the cipher and the format of a container are in its header
+	if f, err := os.Open(in); err == nil {
+		if r, _, err := dearmor(f); err == nil {
+			if h, err := container.ReadHeader(r); err == nil {
+				kept["cipher"], kept["format"] = h.Cipher.String(), fmt.Sprintf("cs%d", h.Format)
+			}
+		}
+		f.Close()
+	}
+	for _, name := range []string{"cipher", "format"} {
+		if v, ok := kept[name]; ok && !given[name] {
+			encArgs = append(encArgs, "-"+name+"="+v)
+		}
+	}
+	return decArgs, encArgs, nil
+}
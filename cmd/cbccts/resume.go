@@ -0,0 +1,68 @@
+/*
+	resume.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// continue the encryption of the file in into the chunked file out, interrupted before its end
+func resumeEncrypt(inFile, outFile, passphrase string) (err error) {
+	f, err := os.OpenFile(outFile, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}()
+	h, err := container.ReadHeader(f)
+	if err != nil {
+		return err
+	}
+	if h.Flags&container.FlagChunked == 0 || h.Compression() != "none" {
+		return fmt.Errorf("only a chunked file without compression can be resumed")
+	}
+	if h.KDF.KDF == container.None {
+		return fmt.Errorf("the key of the file is not derived from a passphrase")
+	}
+	material, err := h.KDF.DeriveKey([]byte(passphrase), h.KeySize())
+	if err != nil {
+		return err
+	}
+	key, macKey, err := h.SplitKey(material)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// the chunks written are verified against the input, which is then read on from the end of them
+	w, err := container.ResumeChunkWriter(f, h, in, key, macKey)
+	if err != nil {
+		return err
+	}
+	if h.Flags&container.FlagSparse != 0 {
+		err = copySparse(w, in, in)
+	} else {
+		_, err = io.Copy(w, in)
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
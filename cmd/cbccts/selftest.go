@@ -0,0 +1,62 @@
+/*
+	selftest.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+// the selftest command: run the known-answer tests built into the binary
+func runSelfTest(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	verbose := fs.Bool("v", false, "list every vector")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	passed, failed := 0, 0
+	report := func(name string, err error) {
+		if err != nil {
+			failed++
+			fmt.Fprintf(stdout, "FAIL %s: %v\n", name, err)
+			return
+		}
+		passed++
+		if *verbose {
+			fmt.Fprintf(stdout, "PASS %s\n", name)
+		}
+	}
+
+	// the power-on self-test of the package, on both the normal and constant-time paths
+	report("package self-test", cbccts.SelfTest())
+	for _, set := range []struct {
+		name string
+		vs   []vectors.Vector
+	}{
+		{"RFC 3962", vectors.RFC3962},
+		{"NIST SP 800-38A addendum", vectors.NIST},
+	} {
+		for i := range set.vs {
+			v := &set.vs[i]
+			report(set.name+": "+v.Name, v.Verify())
+		}
+	}
+
+	fmt.Fprintf(stdout, "%d passed, %d failed\n", passed, failed)
+	if failed != 0 {
+		return fmt.Errorf("self-test failed")
+	}
+	return nil
+}
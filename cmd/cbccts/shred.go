@@ -0,0 +1,68 @@
+/*
+	shred.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+// decrypt an encrypted file in memory and compare the SHA-256 of its plaintext; the arguments of dec give the key
+func verifyEncrypted(decArgs []string, sum []byte) error {
+	h := sha256.New()
+	if err := runCrypt(false, decArgs, nil, h, io.Discard); err != nil {
+		return fmt.Errorf("verifying the output: %v", err)
+	}
+	if !bytes.Equal(h.Sum(nil), sum) {
+		return fmt.Errorf("verifying the output: the decrypted output differs from the input")
+	}
+	return nil
+}
+
+// the arguments of dec to decrypt the output of enc
+func shredDecArgs(out, passphrase string, h *container.Header, c container.Cipher, key, iv []byte, format string) []string {
+	args := []string{"-in=" + out}
+	if passphrase != "" {
+		args = append(args, "-passphrase="+passphrase)
+		if h.Flags&container.FlagMAC == 0 {
+			args = append(args, "-verify=false")
+		}
+		return args
+	}
+	// the key stays in this process
+	return append(args, "-key="+hex.EncodeToString(key), "-iv="+hex.EncodeToString(iv), "-cipher="+c.String(), "-format="+format)
+}
+
+// overwrite a file with random bytes, flush it to the device, and remove it.
+// This is best effort: on SSDs and flash, wear leveling writes the new bytes elsewhere, and copy-on-write or journaling
+// file systems, snapshots and backups may keep the old content; only encryption from the start protects those.
+func shredFile(name string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err == nil {
+		_, err = io.CopyN(f, rand.Reader, fi.Size())
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("shredding %s: %v", name, err)
+	}
+	return os.Remove(name)
+}
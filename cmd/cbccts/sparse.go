@@ -0,0 +1,167 @@
+/*
+	sparse.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// the holes of a file, as [start, end) offsets, by SEEK_HOLE and SEEK_DATA; none where the system cannot tell.
+// The offset of the file is kept.
+func fileHoles(f *os.File) (holes [][2]int64, err error) {
+	if !seekHoles {
+		return nil, nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if _, e := f.Seek(pos, io.SeekStart); err == nil {
+			err = e
+		}
+	}()
+	size := fi.Size()
+	for off := int64(0); off < size; {
+		hole, err := f.Seek(off, seekHole)
+		if err != nil {
+			if errors.Is(err, syscall.EINVAL) {
+				return nil, nil // not supported by the file system
+			}
+			return nil, err
+		}
+		if hole >= size {
+			break
+		}
+		data, err := f.Seek(hole, seekData)
+		if errors.Is(err, syscall.ENXIO) {
+			data, err = size, nil // a hole up to the end
+		}
+		if err != nil {
+			return nil, err
+		}
+		holes = append(holes, [2]int64{hole, data})
+		off = data
+	}
+	return holes, nil
+}
+
+// holeWriter writes runs of zeros without their ciphertext, as container.Writer and container.ChunkWriter
+type holeWriter interface {
+	io.Writer
+	WriteHole(n int64) error
+}
+
+// copy the file f from r, which reads it from its current offset on, writing its holes as hole chunks.
+// A hole is still read, which costs no disk access, to check that it reads as zeros.
+func copySparse(w holeWriter, r io.Reader, f *os.File) error {
+	holes, err := fileHoles(f)
+	if err != nil {
+		return err
+	}
+	off, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 64*1024)
+	for _, h := range holes {
+		if h[1] <= off {
+			continue
+		}
+		if h[0] < off {
+			h[0] = off
+		}
+		if _, err := io.CopyN(w, r, h[0]-off); err != nil {
+			return err
+		}
+		for n := h[1] - h[0]; n > 0; {
+			b := buf
+			if int64(len(b)) > n {
+				b = b[:n]
+			}
+			if _, err := io.ReadFull(r, b); err != nil {
+				return err
+			}
+			for _, c := range b {
+				if c != 0 {
+					return fmt.Errorf("the input changed while it was read")
+				}
+			}
+			n -= int64(len(b))
+		}
+		if err := w.WriteHole(h[1] - h[0]); err != nil {
+			return err
+		}
+		off = h[1]
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// sparseWriter writes to a new file, seeking over runs of zeros to leave holes
+type sparseWriter struct {
+	f    *os.File
+	skip int64 // zeros not written yet
+}
+
+// the size of a run of zeros that is not written
+const sparseBlock = 4096
+
+func (s *sparseWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		b := p
+		if len(b) > sparseBlock {
+			b = b[:sparseBlock]
+		}
+		if isZero(b) {
+			s.skip += int64(len(b))
+		} else {
+			if s.skip > 0 {
+				if _, err := s.f.Seek(s.skip, io.SeekCurrent); err != nil {
+					return n, err
+				}
+				s.skip = 0
+			}
+			if _, err := s.f.Write(b); err != nil {
+				return n, err
+			}
+		}
+		n += len(b)
+		p = p[len(b):]
+	}
+	return n, nil
+}
+
+// Close sets the size of the file, for zeros at its end.
+func (s *sparseWriter) Close() error {
+	if s.skip == 0 {
+		return nil
+	}
+	end, err := s.f.Seek(s.skip, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	s.skip = 0
+	return s.f.Truncate(end)
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+/*
+	sparse_darwin.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+// the whence of lseek to find data and holes, in the order of darwin
+const (
+	seekHoles = true
+	seekHole  = 3
+	seekData  = 4
+)
@@ -0,0 +1,16 @@
+//go:build !(linux || freebsd || illumos || solaris || darwin)
+// +build !linux,!freebsd,!illumos,!solaris,!darwin
+
+/*
+	sparse_other.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+// no way to find holes; a sparse file is read as data
+const (
+	seekHoles = false
+	seekData  = 0
+	seekHole  = 0
+)
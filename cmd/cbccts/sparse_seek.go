@@ -0,0 +1,16 @@
+//go:build linux || freebsd || illumos || solaris
+// +build linux freebsd illumos solaris
+
+/*
+	sparse_seek.go
+	2026-10, github.com/mixcode
+*/
+
+package main
+
+// the whence of lseek to find data and holes
+const (
+	seekHoles = true
+	seekData  = 3
+	seekHole  = 4
+)
@@ -0,0 +1,217 @@
+/*
+	main.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Command ctsgen emits a corpus of CBC-CTS test vectors, to validate implementations in other languages against this package.
+
+	Each record is a tuple of (cipher, format, key, iv, plaintext, ciphertext), with binary fields in hex.
+	The keys, IVs and plaintexts are pseudo-random from a seed, so a corpus is reproducible.
+
+		ctsgen -o corpus.json
+		ctsgen -output csv -ciphers aes128,des3 -max 64 -seed 7
+
+	It also writes the corpus as ACVP sample vector sets of the AES ciphers, and answers the prompts of an ACVP vector set:
+
+		ctsgen -output acvp -ciphers aes128,aes256
+		ctsgen -respond prompt.json -o response.json
+*/
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+// a block cipher and its key size
+type cipherSpec struct {
+	keySize   int
+	newCipher func(key []byte) (cipher.Block, error)
+}
+
+var ciphers = map[string]cipherSpec{
+	"aes128": {16, aes.NewCipher},
+	"aes192": {24, aes.NewCipher},
+	"aes256": {32, aes.NewCipher},
+	"des":    {8, des.NewCipher},
+	"des3":   {24, des.NewTripleDESCipher},
+}
+
+var formats = []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3}
+
+// Record is a test vector.
+type Record struct {
+	Cipher     string `json:"cipher"`
+	Format     string `json:"format"`
+	Key        string `json:"key"`
+	IV         string `json:"iv"`
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// generate vectors for the ciphers, from one block to maxBlocks blocks plus a block in length
+func generate(names []string, maxBlocks int, seed int64) ([]Record, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rnd.Read(b)
+		return b
+	}
+
+	var out []Record
+	for _, name := range names {
+		spec, ok := ciphers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher %q", name)
+		}
+		for _, f := range formats {
+			key := random(spec.keySize)
+			block, err := spec.newCipher(key)
+			if err != nil {
+				return nil, err
+			}
+			bs := block.BlockSize()
+			for n := bs; n <= (maxBlocks+1)*bs; n++ {
+				iv, pt := random(bs), random(n)
+				ct := make([]byte, n)
+				cbccts.NewCBCCTSEncrypter(block, iv, f).CryptBlocks(ct, pt)
+				out = append(out, Record{
+					Cipher:     name,
+					Format:     "CS" + strconv.Itoa(int(f)),
+					Key:        hex.EncodeToString(key),
+					IV:         hex.EncodeToString(iv),
+					Plaintext:  hex.EncodeToString(pt),
+					Ciphertext: hex.EncodeToString(ct),
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+func writeJSON(w io.Writer, records []Record) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(records)
+}
+
+func writeCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"cipher", "format", "key", "iv", "plaintext", "ciphertext"})
+	for _, r := range records {
+		cw.Write([]string{r.Cipher, r.Format, r.Key, r.IV, r.Plaintext, r.Ciphertext})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// write the AES records as ACVP sample vector sets, one for each format
+func writeACVP(w io.Writer, records []Record) error {
+	var sets []*vectors.ACVPVectorSet
+	for i, f := range formats {
+		var vs []vectors.Vector
+		for _, r := range records {
+			if !strings.HasPrefix(r.Cipher, "aes") || r.Format != "CS"+strconv.Itoa(int(f)) {
+				continue
+			}
+			v := vectors.Vector{Format: f}
+			v.Key, _ = hex.DecodeString(r.Key)
+			v.IV, _ = hex.DecodeString(r.IV)
+			v.Plaintext, _ = hex.DecodeString(r.Plaintext)
+			v.Ciphertext, _ = hex.DecodeString(r.Ciphertext)
+			vs = append(vs, v)
+		}
+		if len(vs) > 0 {
+			sets = append(sets, vectors.NewACVPVectorSet(i+1, f, vs))
+		}
+	}
+	if len(sets) == 0 {
+		return fmt.Errorf("no AES cipher for ACVP")
+	}
+	return vectors.WriteACVP(w, sets)
+}
+
+// answer the prompts of ACVP vector sets
+func respondACVP(w io.Writer, r io.Reader) error {
+	sets, err := vectors.ReadACVP(r)
+	if err != nil {
+		return err
+	}
+	for i, vs := range sets {
+		if sets[i], err = vs.Response(); err != nil {
+			return fmt.Errorf("vsId %d: %v", vs.VsID, err)
+		}
+	}
+	return vectors.WriteACVP(w, sets)
+}
+
+func main() {
+	var (
+		output    = flag.String("output", "json", "output type; json, csv or acvp")
+		outFile   = flag.String("o", "", "output file; standard output if empty")
+		cipherArg = flag.String("ciphers", "aes128,aes192,aes256,des3", "comma-separated ciphers; aes128, aes192, aes256, des or des3")
+		maxBlocks = flag.Int("max", 4, "maximum number of full blocks before the partial block")
+		seed      = flag.Int64("seed", 1, "seed of the pseudo-random inputs")
+		respond   = flag.String("respond", "", "answer the prompts of an ACVP vector set file instead of generating a corpus")
+	)
+	flag.Parse()
+
+	if err := run(*output, *outFile, strings.Split(*cipherArg, ","), *maxBlocks, *seed, *respond); err != nil {
+		fmt.Fprintf(os.Stderr, "ctsgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(output, outFile string, names []string, maxBlocks int, seed int64, respond string) (err error) {
+	w := io.Writer(os.Stdout)
+	if outFile != "" {
+		var fd *os.File
+		if fd, err = os.Create(outFile); err != nil {
+			return
+		}
+		defer func() {
+			if e := fd.Close(); err == nil {
+				err = e
+			}
+		}()
+		w = fd
+	}
+
+	if respond != "" {
+		fd, err := os.Open(respond)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		return respondACVP(w, fd)
+	}
+
+	records, err := generate(names, maxBlocks, seed)
+	if err != nil {
+		return
+	}
+	switch output {
+	case "json":
+		return writeJSON(w, records)
+	case "csv":
+		return writeCSV(w, records)
+	case "acvp":
+		return writeACVP(w, records)
+	}
+	return fmt.Errorf("unknown output type %q", output)
+}
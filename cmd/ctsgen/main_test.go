@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+func TestGenerate(t *testing.T) {
+
+	records, err := generate([]string{"aes128", "des3"}, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (16*2+1) aes lengths and (8*2+1) des3 lengths, in three formats
+	if len(records) != 3*(33+17) {
+		t.Fatalf("unexpected number of records %d", len(records))
+	}
+
+	// the corpus is reproducible
+	again, _ := generate([]string{"aes128", "des3"}, 2, 1)
+	for i := range records {
+		if records[i] != again[i] {
+			t.Fatalf("record %d differs", i)
+		}
+	}
+
+	// AES records are checked by the vector loader
+	var tf vectors.TestFile
+	for _, r := range records {
+		if r.Cipher != "aes128" {
+			continue
+		}
+		tc := vectors.TestCase{TcID: len(tf.TestGroups) + 1, Result: vectors.ResultValid}
+		tc.Key, _ = hex.DecodeString(r.Key)
+		tc.IV, _ = hex.DecodeString(r.IV)
+		tc.Msg, _ = hex.DecodeString(r.Plaintext)
+		tc.CT, _ = hex.DecodeString(r.Ciphertext)
+		tf.TestGroups = append(tf.TestGroups, vectors.TestGroup{Format: r.Format, Tests: []vectors.TestCase{tc}})
+	}
+	if err := tf.Run(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := generate([]string{"rot13"}, 2, 1); err == nil {
+		t.Errorf("unknown cipher accepted")
+	}
+}
+
+func TestOutput(t *testing.T) {
+
+	records, _ := generate([]string{"des"}, 1, 1)
+
+	var b bytes.Buffer
+	if err := writeJSON(&b, records); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Record
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil || len(decoded) != len(records) {
+		t.Errorf("cannot decode the JSON output: %v", err)
+	}
+
+	b.Reset()
+	if err := writeCSV(&b, records); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != len(records)+1 || lines[0] != "cipher,format,key,iv,plaintext,ciphertext" {
+		t.Errorf("unexpected CSV output")
+	}
+}
+
+func TestACVP(t *testing.T) {
+
+	records, _ := generate([]string{"aes128", "des3"}, 1, 1)
+
+	var b bytes.Buffer
+	if err := writeACVP(&b, records); err != nil {
+		t.Fatal(err)
+	}
+	sets, err := vectors.ReadACVP(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 3 {
+		t.Fatalf("unexpected number of vector sets %d", len(sets))
+	}
+	for _, vs := range sets {
+		if err := vs.Verify(); err != nil {
+			t.Errorf("%s: %v", vs.Algorithm, err)
+		}
+	}
+
+	var resp bytes.Buffer
+	if err := respondACVP(&resp, &b); err != nil {
+		t.Fatal(err)
+	}
+	answers, err := vectors.ReadACVP(&resp)
+	if err != nil || len(answers) != 3 {
+		t.Fatalf("cannot read the responses: %v", err)
+	}
+
+	if err := writeACVP(&b, nil); err == nil {
+		t.Errorf("ACVP output without AES accepted")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+	consttime.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// WithConstantTime makes a codec made by this package process the last two blocks in constant time, and returns the codec.
+//
+// The default tail processing branches on the length of the partial block, and copies as many bytes as the partial block holds.
+// In the constant-time mode, aligned and unaligned data take the same path: the last two blocks are always encrypted or decrypted
+// as a unit, and the partial block is moved by masked byte loops of a fixed count, so that the time and the memory access
+// do not depend on the length modulo the block size, apart from the offsets within the two-block scratch buffer and the tail of dst.
+// The number of whole blocks is not hidden. This is slower for short messages.
+func WithConstantTime(m cipher.BlockMode) cipher.BlockMode {
+	codecOf(m).ct = true
+	return m
+}
+
+// the length of the last, partial or full, block of a text longer than a block; in [1, blocksz]
+func lastBlockLen(textlen, blocksz int) int {
+	return textlen - (textlen-1)/blocksz*blocksz
+}
+
+// 1 if the tail is in the CS1 order, 0 if in the CS3 order
+func (cd *cbccts) cs1Order(last, blocksz int) int {
+	switch cd.mode {
+	case CS1:
+		return 1
+	case CS2:
+		return subtle.ConstantTimeEq(int32(last), int32(blocksz))
+	}
+	return 0
+}
+
+// encrypt text longer than a block, with the tail in constant time
+func (cd *cbccts) encodeCT(dst, src []byte) {
+	blocksz := cd.codec.BlockSize()
+	last := lastBlockLen(len(src), blocksz)
+	py := len(src) - blocksz - last
+	cd.chain(dst[:py], src[:py])
+
+	// the last two blocks, zero padded
+	tail := src[py:]
+	n := len(tail)
+	tmp, _ := cd.scratch(blocksz)
+	for i := range tmp {
+		in := subtle.ConstantTimeLessOrEq(i+1, n)
+		tmp[i] = tail[subtle.ConstantTimeSelect(in, i, n-1)] & byte(-in)
+	}
+	cd.codec.CryptBlocks(tmp, tmp)
+
+	// CS1 order: the head of the second last block, then the last block
+	// CS3 order: the last block, then the head of the second last block
+	// the output is written as two overlapping windows of a block, at the start and at the end of the tail,
+	// so that the count of writes does not depend on the length of the last block
+	cs1 := cd.cs1Order(last, blocksz)
+	for w := 0; w < 2; w++ {
+		base := w * last
+		for j := 0; j < blocksz; j++ {
+			i := base + j
+			a := subtle.ConstantTimeSelect(subtle.ConstantTimeLessOrEq(last, i), i-last+blocksz, i)
+			b := subtle.ConstantTimeSelect(subtle.ConstantTimeLessOrEq(blocksz, i), i-blocksz, blocksz+i)
+			dst[py+i] = tmp[subtle.ConstantTimeSelect(cs1, a, b)]
+		}
+	}
+}
+
+// decrypt text longer than a block, with the tail in constant time
+func (cd *cbccts) decodeCT(dst, src []byte) {
+	blocksz := cd.codec.BlockSize()
+	last := lastBlockLen(len(src), blocksz)
+	py := len(src) - blocksz - last
+	cd.decryptBlocks(dst[:py], src[:py])
+
+	// gather the partial block to the first half of tmp, and the full block to the second half
+	tail := src[py:]
+	n := len(tail)
+	tmp, D := cd.scratch(blocksz)
+	cs1 := cd.cs1Order(last, blocksz)
+	for j := 0; j < blocksz; j++ {
+		tmp[blocksz+j] = tail[subtle.ConstantTimeSelect(cs1, last+j, j)]
+		k := subtle.ConstantTimeSelect(cs1, j, blocksz+j)
+		tmp[j] = tail[subtle.ConstantTimeSelect(subtle.ConstantTimeLessOrEq(n, k), n-1, k)]
+	}
+
+	// the stolen bytes come from the decrypted full block; for an aligned tail, none are stolen
+	cd.decryptBlock(D, tmp[blocksz:])
+	for j := 0; j < blocksz; j++ {
+		m := byte(-subtle.ConstantTimeLessOrEq(last, j))
+		tmp[j] = tmp[j]&^m | D[j]&m
+	}
+
+	// the output is written as two overlapping windows of a block, as in encodeCT
+	cd.codec.CryptBlocks(tmp, tmp)
+	for w := 0; w < 2; w++ {
+		base := w * last
+		for j := 0; j < blocksz; j++ {
+			dst[py+base+j] = tmp[base+j]
+		}
+	}
+	zero(tmp)
+	zero(D)
+}
@@ -0,0 +1,97 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestConstantTime(t *testing.T) {
+
+	aesCipher, _ := aes.NewCipher(make([]byte, 16))
+	desCipher, _ := des.NewCipher(make([]byte, 8))
+	for _, b := range []cipher.Block{aesCipher, desCipher} {
+		bs := b.BlockSize()
+		iv := make([]byte, bs)
+		for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+			enc := cbccts.NewCBCCTSEncrypter(b, iv, mode)
+			dec := cbccts.NewCBCCTSDecrypter(b, iv, mode)
+			encCT := cbccts.WithConstantTime(cbccts.NewCBCCTSEncrypter(b, iv, mode)).(*cbccts.Encrypter)
+			decCT := cbccts.WithConstantTime(cbccts.NewCBCCTSDecrypter(b, iv, mode)).(*cbccts.Decrypter)
+
+			// the same output and chaining state as the default tail processing, in place or not
+			for n := bs; n <= 5*bs; n++ {
+				pt := make([]byte, n)
+				rand.Read(pt)
+				want := make([]byte, n)
+				enc.CryptBlocks(want, pt)
+
+				ct := make([]byte, n)
+				encCT.CryptBlocks(ct, pt)
+				if !bytes.Equal(ct, want) {
+					t.Fatalf("block size %d, CS%d, %d bytes: unexpected ciphertext", bs, mode, n)
+				}
+				dec.CryptBlocks(want, want)
+				decCT.CryptBlocks(ct, ct)
+				if !bytes.Equal(ct, pt) || !bytes.Equal(want, pt) {
+					t.Fatalf("block size %d, CS%d, %d bytes: decryption failed", bs, mode, n)
+				}
+				if !bytes.Equal(encCT.CipherState(), decCT.CipherState()) {
+					t.Fatalf("block size %d, CS%d, %d bytes: the states differ", bs, mode, n)
+				}
+			}
+		}
+	}
+}
+
+func TestConstantTimeTiming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+
+	// texts of the same number of blocks, with a last block of 1 to 16 bytes.
+	// the lengths are measured in turns, so that a noisy period hits all of them, and the best time of each is compared
+	measure := func(m cipher.BlockMode) (min, max time.Duration) {
+		data := make([]byte, 4*aes.BlockSize)
+		best := make([]time.Duration, aes.BlockSize+1)
+		for round := 0; round < 30; round++ {
+			for last := 1; last <= aes.BlockSize; last++ {
+				text := data[:3*aes.BlockSize+last]
+				start := time.Now()
+				for i := 0; i < 1000; i++ {
+					m.CryptBlocks(text, text)
+				}
+				if d := time.Since(start); best[last] == 0 || d < best[last] {
+					best[last] = d
+				}
+			}
+		}
+		min, max = best[1], best[1]
+		for _, d := range best[2:] {
+			if d < min {
+				min = d
+			}
+			if d > max {
+				max = d
+			}
+		}
+		return min, max
+	}
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		for _, newMode := range []func(cipher.Block, []byte, cbccts.Format) cipher.BlockMode{cbccts.NewCBCCTSEncrypter, cbccts.NewCBCCTSDecrypter} {
+			min, max := measure(cbccts.WithConstantTime(newMode(ac, iv, mode)))
+			if float64(max) > 1.5*float64(min) {
+				t.Errorf("CS%d: timings of the last block lengths differ from %v to %v", mode, min, max)
+			}
+		}
+	}
+}
@@ -0,0 +1,282 @@
+/*
+	chunked.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// FlagChunked marks a container whose ciphertext is a sequence of chunks, each encrypted and authenticated on its own,
+// in the manner of the STREAM construction. Truncation, reordering and corruption are detected at the chunk where they occur,
+// so a large file is verified as it is read. It requires FlagMAC, whose key authenticates the chunks; there is no trailer.
+//
+// A chunk is a byte that is 1 for the final chunk and 0 otherwise, the ciphertext length as a uint32, the ciphertext,
+// and an HMAC-SHA-256 of the header, the sequence number as a uint64, and all of the chunk before it.
+// The plaintext of a chunk is ChunkSize bytes, but the final one holds the rest, at least a block unless it is the only chunk.
+// Each chunk is CBC-CTS encrypted with an IV derived from the header IV and the sequence number, as NewEncrypterFromNonce does,
+// with an IV key of HKDF-Expand-SHA-256 of the MAC key and the label "cbccts chunk IV key", so that no key serves two purposes.
+const FlagChunked Flags = 1 << 1
+
+// ChunkSize is the size of the plaintext of a chunk but the final one.
+const ChunkSize = 64 * 1024
+
+// ErrTruncated is returned when a chunked container ends before its final chunk.
+var ErrTruncated = errors.New("container: truncated")
+
+// a chunk whose kind or length is not valid
+var errInvalidChunk = errors.New("container: invalid chunk")
+
+// chunk processing shared by ChunkWriter and ChunkReader
+type chunker struct {
+	h      *Header
+	hdr    []byte // the encoded header
+	block  cipher.Block
+	macKey []byte
+	ivKey  []byte // the key of the IV derivation, derived from the MAC key
+	seq    uint64
+}
+
+func newChunker(h *Header, cipherKey, macKey []byte) (*chunker, error) {
+	if h.Flags&FlagChunked == 0 {
+		return nil, fmt.Errorf("container: not a chunked container")
+	}
+	if len(macKey) != MACKeySize {
+		return nil, fmt.Errorf("container: the MAC key must be %d bytes", MACKeySize)
+	}
+	hdr, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	block, err := h.Cipher.NewBlock(cipherKey)
+	if err != nil {
+		return nil, err
+	}
+	ivKey := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, macKey, chunkIVLabel), ivKey); err != nil {
+		return nil, err
+	}
+	return &chunker{h: h, hdr: hdr, block: block, macKey: macKey, ivKey: ivKey}, nil
+}
+
+var chunkIVLabel = []byte("cbccts chunk IV key")
+
+// the nonce of the IV derivation of the current chunk
+func (c *chunker) nonce() []byte {
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], c.seq)
+	return append(append([]byte(nil), c.h.IV...), seq[:]...)
+}
+
+// the MAC of the current chunk, with the header and the sequence number written
+func (c *chunker) mac() hash.Hash {
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], c.seq)
+	m := hmac.New(sha256.New, c.macKey)
+	m.Write(c.hdr)
+	m.Write(seq[:])
+	return m
+}
+
+// ChunkWriter writes the ciphertext of a chunked container, encrypting what is written to it.
+type ChunkWriter struct {
+	*chunker
+	w   io.Writer
+	buf []byte
+	err error
+}
+
+// NewChunkWriter returns a ChunkWriter writing the chunks to w, after the header that the caller has written.
+// Close must be called to write the final chunk; it does not close w.
+func NewChunkWriter(w io.Writer, h *Header, cipherKey, macKey []byte) (*ChunkWriter, error) {
+	c, err := newChunker(h, cipherKey, macKey)
+	if err != nil {
+		return nil, err
+	}
+	// a full chunk is written once a block more is buffered, which keeps the final chunk at least a block long
+	return &ChunkWriter{chunker: c, w: w, buf: make([]byte, 0, ChunkSize+c.block.BlockSize())}, nil
+}
+
+func (w *ChunkWriter) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	for len(p) > 0 {
+		k := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+k]
+		n, p = n+k, p[k:]
+		if len(w.buf) == cap(w.buf) {
+			if w.err = w.writeChunk(w.buf[:ChunkSize], false); w.err != nil {
+				return n, w.err
+			}
+			w.buf = w.buf[:copy(w.buf, w.buf[ChunkSize:])]
+		}
+	}
+	return n, nil
+}
+
+// Close writes the final chunk.
+func (w *ChunkWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = fmt.Errorf("write to a closed ChunkWriter")
+	defer zero(w.buf[:cap(w.buf)])
+	if n := len(w.buf); n > 0 && n < w.block.BlockSize() {
+		return fmt.Errorf("data size too small; must be larger than one block")
+	}
+	return w.writeChunk(w.buf, true)
+}
+
+// encrypt a chunk in place and write it
+func (w *ChunkWriter) writeChunk(pt []byte, final bool) error {
+	enc, err := cbccts.NewEncrypterFromNonce(w.block, w.ivKey, w.nonce(), w.h.Format)
+	if err != nil {
+		return err
+	}
+	enc.CryptBlocks(pt, pt)
+	var kind byte
+	if final {
+		kind = chunkFinal
+	}
+	return w.writeFrame(kind, uint32(len(pt)), pt)
+}
+
+// write a chunk of the kind and the length, with the ciphertext ct and the MAC
+func (w *ChunkWriter) writeFrame(kind byte, n uint32, ct []byte) error {
+	var head [5]byte
+	head[0] = kind
+	binary.BigEndian.PutUint32(head[1:], n)
+	m := w.mac()
+	m.Write(head[:])
+	m.Write(ct)
+	for _, b := range [][]byte{head[:], ct, m.Sum(nil)} {
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+	}
+	w.seq++
+	return nil
+}
+
+// ChunkReader reads the plaintext of a chunked container, verifying each chunk before releasing it.
+type ChunkReader struct {
+	*chunker
+	r     io.Reader
+	buf   []byte
+	out   []byte // verified plaintext not read yet
+	hole  int64  // zeros of a hole not read yet
+	final bool
+	err   error
+}
+
+// NewChunkReader returns a ChunkReader of the chunks read from r, positioned after the header.
+// Read returns ErrMAC for a chunk that fails verification, and ErrTruncated if the input ends before the final chunk.
+func NewChunkReader(r io.Reader, h *Header, cipherKey, macKey []byte) (*ChunkReader, error) {
+	c, err := newChunker(h, cipherKey, macKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ChunkReader{chunker: c, r: r, buf: make([]byte, ChunkSize+c.block.BlockSize()+MACSize)}, nil
+}
+
+func (r *ChunkReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 && r.hole == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.readChunk()
+	}
+	if r.hole > 0 {
+		if int64(len(p)) > r.hole {
+			p = p[:r.hole]
+		}
+		zero(p)
+		r.hole -= int64(len(p))
+		return len(p), nil
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// read, verify and decrypt the next chunk; io.EOF after the final one
+func (r *ChunkReader) readChunk() error {
+	var head [5]byte
+	if r.final {
+		// nothing may follow the final chunk
+		if n, err := io.ReadFull(r.r, head[:1]); n != 0 || err != io.EOF {
+			if err == nil || err == io.EOF {
+				err = fmt.Errorf("container: data after the final chunk")
+			}
+			return err
+		}
+		return io.EOF
+	}
+	if _, err := io.ReadFull(r.r, head[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrTruncated
+		}
+		return err
+	}
+	final, hole, n := head[0] == chunkFinal, head[0] == chunkHole, int(binary.BigEndian.Uint32(head[1:]))
+	sparse := r.h.Flags&FlagSparse != 0
+	blocksz := r.block.BlockSize()
+	switch {
+	case head[0] > chunkFinal && !(hole && sparse),
+		hole && n == 0,
+		!final && !hole && n != ChunkSize && !(sparse && n >= blocksz && n <= ChunkSize),
+		final && n >= ChunkSize+blocksz,
+		final && n < blocksz && (n != 0 || r.seq != 0):
+		return fmt.Errorf("%w %d", errInvalidChunk, r.seq)
+	}
+	if hole {
+		n = 0
+	}
+	ct := r.buf[:n+MACSize]
+	if _, err := io.ReadFull(r.r, ct); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrTruncated
+		}
+		return err
+	}
+	ct, tag := ct[:n], ct[n:]
+	m := r.mac()
+	m.Write(head[:])
+	m.Write(ct)
+	if !hmac.Equal(m.Sum(nil), tag) {
+		return ErrMAC
+	}
+	if hole {
+		r.hole = int64(binary.BigEndian.Uint32(head[1:]))
+		r.seq++
+		return nil
+	}
+	dec, err := cbccts.NewDecrypterFromNonce(r.block, r.ivKey, r.nonce(), r.h.Format)
+	if err != nil {
+		return err
+	}
+	dec.CryptBlocks(ct, ct)
+	r.out, r.final = ct, final
+	r.seq++
+	return nil
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
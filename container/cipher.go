@@ -0,0 +1,107 @@
+/*
+	cipher.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/cast5"
+	"golang.org/x/crypto/tea"
+	"golang.org/x/crypto/twofish"
+	"golang.org/x/crypto/xtea"
+)
+
+// Cipher is a block cipher, by its id in the header.
+type Cipher byte
+
+const (
+	AES128   Cipher = 1
+	AES192   Cipher = 2
+	AES256   Cipher = 3
+	DES      Cipher = 4
+	DES3     Cipher = 5 // three-key triple DES
+	Blowfish Cipher = 6 // with a 16-byte key
+	Twofish  Cipher = 7 // with a 32-byte key
+	CAST5    Cipher = 8
+	XTEA     Cipher = 9
+	TEA      Cipher = 10
+)
+
+type cipherInfo struct {
+	name      string
+	keySize   int
+	blockSize int
+	newCipher func(key []byte) (cipher.Block, error)
+}
+
+var ciphers = map[Cipher]cipherInfo{
+	AES128:   {"aes128", 16, 16, aes.NewCipher},
+	AES192:   {"aes192", 24, 16, aes.NewCipher},
+	AES256:   {"aes256", 32, 16, aes.NewCipher},
+	DES:      {"des", 8, 8, des.NewCipher},
+	DES3:     {"des3", 24, 8, des.NewTripleDESCipher},
+	Blowfish: {"blowfish", 16, 8, func(k []byte) (cipher.Block, error) { return blowfish.NewCipher(k) }},
+	Twofish:  {"twofish", 32, 16, func(k []byte) (cipher.Block, error) { return twofish.NewCipher(k) }},
+	CAST5:    {"cast5", 16, 8, func(k []byte) (cipher.Block, error) { return cast5.NewCipher(k) }},
+	XTEA:     {"xtea", 16, 8, func(k []byte) (cipher.Block, error) { return xtea.NewCipher(k) }},
+	TEA:      {"tea", 16, 8, tea.NewCipher},
+}
+
+// ParseCipher returns the cipher of a name, such as "aes256" or "des3", in any case.
+func ParseCipher(name string) (Cipher, error) {
+	name = strings.ToLower(name)
+	for c, info := range ciphers {
+		if info.name == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("container: unknown cipher %q; one of %s", name, strings.Join(CipherNames(), ", "))
+}
+
+// CipherNames returns the names of the ciphers, sorted.
+func CipherNames() []string {
+	var names []string
+	for _, info := range ciphers {
+		names = append(names, info.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c Cipher) String() string {
+	if info, ok := ciphers[c]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("cipher(%d)", byte(c))
+}
+
+// KeySize returns the key size of the cipher, or 0 for an unknown cipher.
+func (c Cipher) KeySize() int {
+	return ciphers[c].keySize
+}
+
+// BlockSize returns the block size of the cipher, or 0 for an unknown cipher.
+func (c Cipher) BlockSize() int {
+	return ciphers[c].blockSize
+}
+
+// NewBlock creates the block cipher with a key of KeySize bytes.
+func (c Cipher) NewBlock(key []byte) (cipher.Block, error) {
+	info, ok := ciphers[c]
+	if !ok {
+		return nil, fmt.Errorf("container: unknown cipher %d", byte(c))
+	}
+	if len(key) != info.keySize {
+		return nil, fmt.Errorf("container: %s requires a %d-byte key", info.name, info.keySize)
+	}
+	return info.newCipher(key)
+}
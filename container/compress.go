@@ -0,0 +1,81 @@
+/*
+	compress.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression flags mark a container whose plaintext was compressed before encryption.
+// At most one of them is set. Compression makes the ciphertext length depend on the content;
+// it should not be used where that leaks, such as on data mixing secrets with attacker-controlled input.
+const (
+	FlagGzip Flags = 1 << 2
+	FlagZstd Flags = 1 << 3
+)
+
+// ParseCompression returns the flag of a compression: "gzip", "zstd", or "none" or "" for none.
+func ParseCompression(name string) (Flags, error) {
+	switch name {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return FlagGzip, nil
+	case "zstd":
+		return FlagZstd, nil
+	}
+	return 0, fmt.Errorf("container: unknown compression %q; gzip or zstd", name)
+}
+
+// Compression returns the name of the compression of the header, or "none".
+func (h *Header) Compression() string {
+	switch {
+	case h.Flags&FlagGzip != 0:
+		return "gzip"
+	case h.Flags&FlagZstd != 0:
+		return "zstd"
+	}
+	return "none"
+}
+
+// NewCompressor returns a writer compressing to w by the compression of the header, or w itself if there is none.
+// It must be closed before the encrypting writer under it.
+func (h *Header) NewCompressor(w io.Writer) (io.WriteCloser, error) {
+	switch {
+	case h.Flags&FlagGzip != 0:
+		return gzip.NewWriter(w), nil
+	case h.Flags&FlagZstd != 0:
+		return zstd.NewWriter(w)
+	}
+	return nopWriteCloser{w}, nil
+}
+
+// NewDecompressor returns a reader decompressing r by the compression of the header, or r itself if there is none.
+func (h *Header) NewDecompressor(r io.Reader) (io.ReadCloser, error) {
+	switch {
+	case h.Flags&FlagGzip != 0:
+		return gzip.NewReader(r)
+	case h.Flags&FlagZstd != 0:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return io.NopCloser(r), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
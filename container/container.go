@@ -0,0 +1,163 @@
+/*
+	container.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package container defines the file format written by the cbccts command: a versioned header followed by the CBC-CTS ciphertext.
+
+	The header records everything but the key needed to decrypt the file: the block cipher, the ciphertext format,
+	the IV, the parameters of the passphrase-based key derivation, and flags of optional features.
+	A reader refuses versions and flags it does not know, so that a file is never misread by an older version.
+	Encrypt and Decrypt write and read whole files, as the cbccts command does; the other functions are the parts they are built of.
+
+	The layout, with integers in big endian:
+
+		magic "CBCCTS"
+		version      1 byte, 1
+		cipher       1 byte, a Cipher
+		format       1 byte, a cbccts.Format
+		flags        2 bytes, Flags
+		KDF          1 byte, a KDF; 0 for a raw key
+		KDF params   3 × 4 bytes
+		salt         1 byte length, and the salt
+		IV           1 byte length, and the IV
+		ciphertext   or chunks, if FlagChunked is set, and holes among them if FlagSparse is set
+		MAC          32 bytes, if FlagMAC is set without FlagChunked: HMAC-SHA-256 of all of the above
+*/
+package container
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// Magic is the start of a container file.
+const Magic = "CBCCTS"
+
+// Version is the version of the header written by this package.
+const Version = 1
+
+// ErrNotContainer is returned when the input does not start with Magic.
+var ErrNotContainer = errors.New("container: not a cbccts container")
+
+// Flags are optional features of a container, in the header. A reader refuses any flag it does not know.
+type Flags uint16
+
+// flags known by this version
+const knownFlags = FlagMAC | FlagChunked | FlagGzip | FlagZstd | FlagSparse
+
+// Header is the header of a container.
+type Header struct {
+	Cipher Cipher
+	Format cbccts.Format
+	Flags  Flags
+	KDF    KDFParams // KDF is None if the key is not derived from a passphrase
+	IV     []byte
+}
+
+// MarshalBinary encodes the header.
+func (h *Header) MarshalBinary() ([]byte, error) {
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteString(Magic)
+	b.WriteByte(Version)
+	b.WriteByte(byte(h.Cipher))
+	b.WriteByte(byte(h.Format))
+	binary.Write(&b, binary.BigEndian, uint16(h.Flags))
+	b.WriteByte(byte(h.KDF.KDF))
+	for _, p := range h.KDF.Params {
+		binary.Write(&b, binary.BigEndian, p)
+	}
+	b.WriteByte(byte(len(h.KDF.Salt)))
+	b.Write(h.KDF.Salt)
+	b.WriteByte(byte(len(h.IV)))
+	b.Write(h.IV)
+	return b.Bytes(), nil
+}
+
+// ReadHeader reads a header from the start of r, leaving r at the start of the ciphertext.
+func ReadHeader(r io.Reader) (*Header, error) {
+	var fixed [len(Magic) + 6 + 12]byte
+	if _, err := io.ReadFull(r, fixed[:len(Magic)]); err != nil {
+		return nil, fmt.Errorf("container: reading header: %v", err)
+	}
+	if string(fixed[:len(Magic)]) != Magic {
+		return nil, ErrNotContainer
+	}
+	if _, err := io.ReadFull(r, fixed[len(Magic):]); err != nil {
+		return nil, fmt.Errorf("container: reading header: %v", err)
+	}
+	p := fixed[len(Magic):]
+	if p[0] != Version {
+		return nil, fmt.Errorf("container: unsupported version %d", p[0])
+	}
+	h := &Header{Cipher: Cipher(p[1]), Format: cbccts.Format(p[2]), Flags: Flags(binary.BigEndian.Uint16(p[3:]))}
+	h.KDF.KDF = KDF(p[5])
+	for i := range h.KDF.Params {
+		h.KDF.Params[i] = binary.BigEndian.Uint32(p[6+4*i:])
+	}
+	var err error
+	if h.KDF.Salt, err = readBytes(r); err != nil {
+		return nil, err
+	}
+	if h.IV, err = readBytes(r); err != nil {
+		return nil, err
+	}
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// check the fields that the header can hold and this version understands
+func (h *Header) validate() error {
+	if h.Flags&^knownFlags != 0 {
+		return fmt.Errorf("container: unsupported flags %#x", uint16(h.Flags&^knownFlags))
+	}
+	if h.Flags&FlagChunked != 0 && h.Flags&FlagMAC == 0 {
+		return fmt.Errorf("container: FlagChunked requires FlagMAC")
+	}
+	if h.Flags&FlagSparse != 0 && h.Flags&FlagChunked == 0 {
+		return fmt.Errorf("container: FlagSparse requires FlagChunked")
+	}
+	if h.Flags&FlagGzip != 0 && h.Flags&FlagZstd != 0 {
+		return fmt.Errorf("container: more than one compression")
+	}
+	if h.Format < cbccts.CS1 || h.Format > cbccts.CS3 {
+		return fmt.Errorf("container: invalid format %d", h.Format)
+	}
+	if _, ok := ciphers[h.Cipher]; !ok {
+		return fmt.Errorf("container: unknown cipher %d", h.Cipher)
+	}
+	if h.IV == nil || len(h.IV) != h.Cipher.BlockSize() {
+		return fmt.Errorf("container: the IV of %v must be %d bytes", h.Cipher, h.Cipher.BlockSize())
+	}
+	if _, ok := kdfNames[h.KDF.KDF]; !ok {
+		return fmt.Errorf("container: unknown KDF %d", h.KDF.KDF)
+	}
+	if len(h.KDF.Salt) > 255 {
+		return fmt.Errorf("container: salt too long")
+	}
+	return nil
+}
+
+// read a length byte and as many bytes
+func readBytes(r io.Reader) ([]byte, error) {
+	var n [1]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, fmt.Errorf("container: reading header: %v", err)
+	}
+	b := make([]byte, n[0])
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("container: reading header: %v", err)
+	}
+	return b, nil
+}
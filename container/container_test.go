@@ -0,0 +1,609 @@
+package container_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/container"
+)
+
+func TestHeader(t *testing.T) {
+
+	h := &container.Header{
+		Cipher: container.DES3,
+		Format: cbccts.CS2,
+		KDF:    container.KDFParams{KDF: container.Scrypt, Params: [3]uint32{10, 8, 1}, Salt: []byte("salt")},
+		IV:     []byte("8 bytes!"),
+	}
+	b, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(append(b, "ciphertext"...))
+	g, err := container.ReadHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Cipher != h.Cipher || g.Format != h.Format || g.Flags != h.Flags || g.KDF.KDF != h.KDF.KDF || g.KDF.Params != h.KDF.Params ||
+		!bytes.Equal(g.KDF.Salt, h.KDF.Salt) || !bytes.Equal(g.IV, h.IV) {
+		t.Errorf("unexpected header %+v", g)
+	}
+	if r.Len() != len("ciphertext") {
+		t.Errorf("the reader is not at the ciphertext")
+	}
+
+	// the layout is fixed; this must not change for files to remain readable
+	if want := "CBCCTS\x01\x05\x02\x00\x00\x02\x00\x00\x00\x0a\x00\x00\x00\x08\x00\x00\x00\x01\x04salt\x088 bytes!"; string(b) != want {
+		t.Errorf("unexpected encoding %q", b)
+	}
+
+	for name, edit := range map[string]func([]byte){
+		"magic":    func(b []byte) { b[0] = 'X' },
+		"version":  func(b []byte) { b[6] = 2 },
+		"cipher":   func(b []byte) { b[7] = 99 },
+		"format":   func(b []byte) { b[8] = 4 },
+		"flags":    func(b []byte) { b[10] = 0x80 },
+		"kdf":      func(b []byte) { b[11] = 9 },
+		"compress": func(b []byte) { b[10] = byte(container.FlagGzip | container.FlagZstd) },
+		"iv":       func(b []byte) { b[len(b)-9] = 7 },
+	} {
+		c := append([]byte(nil), b...)
+		edit(c)
+		if _, err := container.ReadHeader(bytes.NewReader(c)); err == nil {
+			t.Errorf("%s: invalid header accepted", name)
+		}
+	}
+	if _, err := container.ReadHeader(bytes.NewReader([]byte("plain text"))); err != container.ErrNotContainer {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := container.ReadHeader(bytes.NewReader(b[:len(b)-1])); err == nil {
+		t.Errorf("truncated header accepted")
+	}
+}
+
+func TestCipher(t *testing.T) {
+
+	for _, name := range container.CipherNames() {
+		c, err := container.ParseCipher(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.String() != name {
+			t.Errorf("%s: unexpected name %v", name, c)
+		}
+		b, err := c.NewBlock(make([]byte, c.KeySize()))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if b.BlockSize() != c.BlockSize() {
+			t.Errorf("%s: block size %d, not %d", name, b.BlockSize(), c.BlockSize())
+		}
+		if _, err := c.NewBlock(make([]byte, c.KeySize()+1)); err == nil {
+			t.Errorf("%s: wrong key size accepted", name)
+		}
+	}
+	if _, err := container.ParseCipher("rot13"); err == nil {
+		t.Errorf("unknown cipher accepted")
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+
+	for _, k := range []container.KDFParams{
+		{KDF: container.PBKDF2, Params: [3]uint32{1000, 0, 0}, Salt: []byte("salt")},
+		{KDF: container.Scrypt, Params: [3]uint32{10, 8, 1}, Salt: []byte("salt")},
+		{KDF: container.Argon2id, Params: [3]uint32{1, 1024, 1}, Salt: []byte("salt")},
+	} {
+		if p, err := container.ParseKDF(k.KDF.String()); err != nil || p != k.KDF {
+			t.Errorf("%v: name not parsed: %v", k.KDF, err)
+		}
+		a, err := k.DeriveKey([]byte("passphrase"), 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := k.DeriveKey([]byte("passphrase"), 32)
+		c, _ := k.DeriveKey([]byte("Passphrase"), 32)
+		if len(a) != 32 || !bytes.Equal(a, b) || bytes.Equal(a, c) {
+			t.Errorf("%v: unexpected keys", k.KDF)
+		}
+
+		zero := k
+		zero.Params = [3]uint32{}
+		if _, err := zero.DeriveKey([]byte("passphrase"), 32); err == nil {
+			t.Errorf("%v: zero parameters accepted", k.KDF)
+		}
+	}
+	if _, err := (&container.KDFParams{}).DeriveKey([]byte("passphrase"), 32); err == nil {
+		t.Errorf("derived a key without a KDF")
+	}
+}
+
+func TestKDFLimits(t *testing.T) {
+
+	for _, k := range []container.KDFParams{
+		{KDF: container.PBKDF2, Params: [3]uint32{container.MaxPBKDF2Iterations + 1}},
+		{KDF: container.PBKDF2, Params: [3]uint32{1<<32 - 1}},
+		{KDF: container.Scrypt, Params: [3]uint32{container.MaxScryptLogN + 1, 8, 1}},
+		{KDF: container.Scrypt, Params: [3]uint32{10, 1 << 16, 1 << 16}},
+		{KDF: container.Scrypt, Params: [3]uint32{container.MaxScryptLogN, 64, 1}},
+		{KDF: container.Scrypt, Params: [3]uint32{10, 0, 1}},
+		{KDF: container.Argon2id, Params: [3]uint32{container.MaxArgon2Time + 1, 1024, 1}},
+		{KDF: container.Argon2id, Params: [3]uint32{1, 1<<32 - 1, 1}},
+		{KDF: container.Argon2id, Params: [3]uint32{1, 1024, container.MaxArgon2Threads + 1}},
+	} {
+		if err := k.Check(); err == nil {
+			t.Errorf("%v %v: oversized parameters accepted", k.KDF, k.Params)
+		}
+	}
+	for _, kdf := range []container.KDF{container.PBKDF2, container.Scrypt, container.Argon2id} {
+		if err := (&container.KDFParams{KDF: kdf, Params: container.DefaultKDFParams(kdf)}).Check(); err != nil {
+			t.Errorf("%v: default parameters rejected: %v", kdf, err)
+		}
+	}
+
+	// a header with oversized parameters is rejected before deriving
+	pass := []byte("passphrase")
+	var ct bytes.Buffer
+	if _, err := container.Encrypt(&ct, bytes.NewReader([]byte("a message over one block")), &container.Options{Passphrase: pass, KDF: container.Argon2id, KDFParams: [3]uint32{1, 1024, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(ct.Bytes())
+	h, err := container.ReadHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.KDF.Params = [3]uint32{1<<32 - 1, 1<<32 - 1, 255}
+	hdr, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	crafted := append(hdr, ct.Bytes()[ct.Len()-r.Len():]...)
+	if _, err := container.Decrypt(io.Discard, bytes.NewReader(crafted), &container.Options{Passphrase: pass}); err == nil || err == container.ErrMAC {
+		t.Errorf("oversized parameters: unexpected error %v", err)
+	}
+}
+
+func TestMAC(t *testing.T) {
+
+	h := &container.Header{Cipher: container.AES128, Format: cbccts.CS3, Flags: container.FlagMAC, IV: make([]byte, 16)}
+	if h.KeySize() != 16+container.MACKeySize {
+		t.Errorf("unexpected key size %d", h.KeySize())
+	}
+	key, macKey, err := h.SplitKey(bytes.Repeat([]byte{1}, h.KeySize()))
+	if err != nil || len(key) != 16 || len(macKey) != container.MACKeySize {
+		t.Fatalf("unexpected split: %v", err)
+	}
+
+	for _, n := range []int{0, 1, 100, 40000, 100000} {
+		ct := make([]byte, n)
+		for i := range ct {
+			ct[i] = byte(i)
+		}
+		mac, _ := h.NewMAC(macKey)
+		mac.Write(ct)
+		file := append(ct, mac.Sum(nil)...)
+
+		mac, _ = h.NewMAC(macKey)
+		got, err := io.ReadAll(container.NewMACReader(iotest.HalfReader(bytes.NewReader(file)), mac))
+		if err != nil || !bytes.Equal(got, ct) {
+			t.Errorf("%d bytes: verification failed: %v", n, err)
+		}
+
+		for _, bad := range [][]byte{file[:len(file)-1], append(append([]byte(nil), file...), 0)} {
+			mac, _ = h.NewMAC(macKey)
+			if _, err := io.ReadAll(container.NewMACReader(bytes.NewReader(bad), mac)); err != container.ErrMAC {
+				t.Errorf("%d bytes: unexpected error %v", n, err)
+			}
+		}
+
+		// the header is authenticated too
+		g := *h
+		g.Format = cbccts.CS1
+		mac, _ = g.NewMAC(macKey)
+		if _, err := io.ReadAll(container.NewMACReader(bytes.NewReader(file), mac)); err != container.ErrMAC {
+			t.Errorf("%d bytes: header change not detected", n)
+		}
+
+		// without a MAC, the trailer is only stripped
+		if got, err := io.ReadAll(container.NewMACReader(bytes.NewReader(file), nil)); err != nil || !bytes.Equal(got, ct) {
+			t.Errorf("%d bytes: trailer not stripped: %v", n, err)
+		}
+	}
+}
+
+func TestChunked(t *testing.T) {
+
+	h := &container.Header{Cipher: container.AES256, Format: cbccts.CS3, Flags: container.FlagMAC | container.FlagChunked, IV: make([]byte, 16)}
+	key, macKey, _ := h.SplitKey(bytes.Repeat([]byte{3}, h.KeySize()))
+	encrypt := func(pt []byte) []byte {
+		var b bytes.Buffer
+		w, err := container.NewChunkWriter(&b, h, key, macKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(pt)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return b.Bytes()
+	}
+	decrypt := func(ct []byte) ([]byte, error) {
+		r, err := container.NewChunkReader(bytes.NewReader(ct), h, key, macKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return io.ReadAll(r)
+	}
+
+	const cs = container.ChunkSize
+	for _, n := range []int{0, 16, 100, cs, cs + 15, cs + 16, 3*cs + 5} {
+		pt := make([]byte, n)
+		for i := range pt {
+			pt[i] = byte(i * 7)
+		}
+		ct := encrypt(pt)
+		if got, err := decrypt(ct); err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("%d bytes: round trip failed: %v", n, err)
+		}
+
+		c := append([]byte(nil), ct...)
+		c[len(c)/2] ^= 1
+		if _, err := decrypt(c); err == nil {
+			t.Errorf("%d bytes: corruption not detected", n)
+		}
+		if _, err := decrypt(ct[:len(ct)-1]); err != container.ErrTruncated {
+			t.Errorf("%d bytes: truncation: unexpected error %v", n, err)
+		}
+		if _, err := decrypt(append(append([]byte(nil), ct...), 0)); err == nil {
+			t.Errorf("%d bytes: trailing data not detected", n)
+		}
+	}
+
+	// chunks dropped or swapped at chunk boundaries
+	ct := encrypt(make([]byte, 3*cs+5))
+	frame := 5 + cs + container.MACSize
+	if _, err := decrypt(ct[:2*frame]); err != container.ErrTruncated {
+		t.Errorf("dropped final chunk: unexpected error %v", err)
+	}
+	swapped := append(append(append([]byte(nil), ct[frame:2*frame]...), ct[:frame]...), ct[2*frame:]...)
+	if _, err := decrypt(swapped); err != container.ErrMAC {
+		t.Errorf("swapped chunks: unexpected error %v", err)
+	}
+	if _, err := decrypt(append(append([]byte(nil), ct[:frame]...), ct[2*frame:]...)); err != container.ErrMAC {
+		t.Errorf("removed chunk: unexpected error %v", err)
+	}
+
+	// the IVs of the chunks are derived with a key of its own, not with the MAC key
+	one := encrypt(make([]byte, 100))[5:105]
+	ivKey := make([]byte, 32)
+	io.ReadFull(hkdf.Expand(sha256.New, macKey, []byte("cbccts chunk IV key")), ivKey)
+	block, _ := aes.NewCipher(key)
+	nonce := append(append([]byte(nil), h.IV...), make([]byte, 8)...)
+	dec, _ := cbccts.NewDecrypterFromNonce(block, ivKey, nonce, h.Format)
+	dec.CryptBlocks(one, one)
+	if !bytes.Equal(one, make([]byte, 100)) {
+		t.Errorf("unexpected IV key of the chunks")
+	}
+
+	// a short message
+	w, _ := container.NewChunkWriter(io.Discard, h, key, macKey)
+	w.Write(make([]byte, 15))
+	if err := w.Close(); err == nil {
+		t.Errorf("short message accepted")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+
+	msg := bytes.Repeat([]byte("a file for the library API. "), 5000)
+	pass := []byte("correct horse")
+	key := bytes.Repeat([]byte{7}, container.AES128.KeySize()+container.MACKeySize)
+	for _, opts := range []container.Options{
+		{Passphrase: pass, KDF: container.PBKDF2, KDFParams: [3]uint32{1000}},
+		{Passphrase: pass, KDF: container.Scrypt, KDFParams: [3]uint32{10, 8, 1}, Cipher: container.Twofish, Format: cbccts.CS1, Chunked: true},
+		{Passphrase: pass, KDF: container.PBKDF2, KDFParams: [3]uint32{1000}, Compression: container.FlagZstd, NoMAC: true, AllowNoMAC: true},
+		{Key: key, Cipher: container.AES128, Compression: container.FlagGzip},
+	} {
+		var ct bytes.Buffer
+		h, err := container.Encrypt(&ct, bytes.NewReader(msg), &opts)
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		if g, err := container.ReadHeader(bytes.NewReader(ct.Bytes())); err != nil || !bytes.Equal(g.IV, h.IV) || g.Flags != h.Flags {
+			t.Errorf("%+v: header %+v, written %+v: %v", opts, g, h, err)
+		}
+
+		// from a seekable and from a plain reader
+		for _, r := range []io.Reader{bytes.NewReader(ct.Bytes()), iotest.HalfReader(bytes.NewReader(ct.Bytes()))} {
+			var pt bytes.Buffer
+			if _, err := container.Decrypt(&pt, r, &opts); err != nil || !bytes.Equal(pt.Bytes(), msg) {
+				t.Errorf("%+v: round trip failed: %v", opts, err)
+			}
+		}
+		if opts.NoMAC {
+			strict := opts
+			strict.AllowNoMAC = false
+			if _, err := container.Decrypt(io.Discard, bytes.NewReader(ct.Bytes()), &strict); err == nil {
+				t.Errorf("%+v: file without a MAC accepted", opts)
+			}
+			continue
+		}
+
+		// a flipped bit is detected before any plaintext of a seekable file
+		bad := append([]byte(nil), ct.Bytes()...)
+		bad[len(bad)/2] ^= 1
+		var pt bytes.Buffer
+		if _, err := container.Decrypt(&pt, bytes.NewReader(bad), &opts); err != container.ErrMAC || (!opts.Chunked && pt.Len() != 0) {
+			t.Errorf("%+v: tampering: unexpected error %v, %d bytes written", opts, err, pt.Len())
+		}
+	}
+
+	// the wrong kind of key
+	var ct bytes.Buffer
+	opts := &container.Options{Passphrase: pass, KDF: container.PBKDF2, KDFParams: [3]uint32{1000}}
+	if _, err := container.Encrypt(&ct, bytes.NewReader(msg), opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := container.Decrypt(io.Discard, bytes.NewReader(ct.Bytes()), &container.Options{Key: make([]byte, 64)}); err == nil {
+		t.Errorf("a raw key accepted for a passphrase")
+	}
+	if _, err := container.Decrypt(io.Discard, bytes.NewReader(ct.Bytes()), &container.Options{Passphrase: []byte("wrong")}); err != container.ErrMAC {
+		t.Errorf("wrong passphrase: unexpected error %v", err)
+	}
+	if _, err := container.Encrypt(io.Discard, bytes.NewReader(msg), &container.Options{}); err == nil {
+		t.Errorf("no key accepted")
+	}
+
+	// the MAC is the trailer, and SkipMAC recovers a damaged file
+	ct.Reset()
+	w, err := container.NewWriter(&ct, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(ct.Bytes(), w.MAC()) || len(w.MAC()) == 0 {
+		t.Errorf("Writer.MAC is not the trailer")
+	}
+	bad := append([]byte(nil), ct.Bytes()...)
+	bad[len(bad)/2] ^= 1
+	var pt bytes.Buffer
+	skip := *opts
+	skip.SkipMAC = true
+	if _, err := container.Decrypt(&pt, bytes.NewReader(bad), &skip); err != nil || pt.Len() != len(msg) {
+		t.Errorf("SkipMAC: %v, %d bytes", err, pt.Len())
+	}
+
+	// a hole of a sparse file is written without ciphertext
+	var sparse bytes.Buffer
+	sopts := &container.Options{Passphrase: pass, KDF: container.PBKDF2, KDFParams: [3]uint32{1000}, Sparse: true}
+	if w, err = container.NewWriter(&sparse, sopts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHole(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sparse.Len() > len(msg)+4096 {
+		t.Errorf("a hole of 1 MiB takes %d bytes", sparse.Len()-len(msg))
+	}
+	pt.Reset()
+	if _, err := container.Decrypt(&pt, &sparse, sopts); err != nil || !bytes.Equal(pt.Bytes(), append(msg, make([]byte, 1<<20)...)) {
+		t.Errorf("sparse round trip failed: %v", err)
+	}
+}
+
+func TestFS(t *testing.T) {
+
+	key := bytes.Repeat([]byte{9}, container.AES256.KeySize()+container.MACKeySize)
+	opts := &container.Options{Key: key}
+	encrypt := func(pt []byte) []byte {
+		var ct bytes.Buffer
+		if _, err := container.Encrypt(&ct, bytes.NewReader(pt), opts); err != nil {
+			t.Fatal(err)
+		}
+		return ct.Bytes()
+	}
+	msg := bytes.Repeat([]byte("an embedded asset. "), 100)
+	base := fstest.MapFS{
+		"assets/a.txt":    {Data: encrypt(msg)},
+		"assets/b.txt":    {Data: encrypt([]byte("a short file, over a block"))},
+		"assets/plain":    {Data: []byte("not a container")},
+		"assets/tampered": {Data: append(encrypt(msg), 0)},
+	}
+	fsys := container.NewFS(base, opts)
+
+	b, err := fs.ReadFile(fsys, "assets/a.txt")
+	if err != nil || !bytes.Equal(b, msg) {
+		t.Errorf("ReadFile: %v", err)
+	}
+	f, err := fsys.Open("assets/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err != nil || fi.Size() != int64(len(msg)) || fi.Name() != "a.txt" {
+		t.Errorf("Stat: %v, %v", fi, err)
+	}
+	s, ok := f.(io.ReadSeeker)
+	if !ok {
+		t.Fatalf("the file cannot seek")
+	}
+	s.Seek(-10, io.SeekEnd)
+	if b, _ := io.ReadAll(s); !bytes.Equal(b, msg[len(msg)-10:]) {
+		t.Errorf("read after Seek: %q", b)
+	}
+
+	entries, err := fs.ReadDir(fsys, "assets")
+	if err != nil || len(entries) != 4 {
+		t.Errorf("ReadDir: %v, %v", entries, err)
+	}
+	if _, err := fs.ReadFile(fsys, "assets/plain"); !errors.Is(err, container.ErrNotContainer) {
+		t.Errorf("plain file: unexpected error %v", err)
+	}
+	if _, err := fs.ReadFile(fsys, "assets/tampered"); !errors.Is(err, container.ErrMAC) {
+		t.Errorf("tampered file: unexpected error %v", err)
+	}
+	if _, err := fs.ReadFile(container.NewFS(base, &container.Options{Key: make([]byte, len(key))}), "assets/b.txt"); !errors.Is(err, container.ErrMAC) {
+		t.Errorf("wrong key: unexpected error %v", err)
+	}
+	if _, err := fs.ReadFile(fsys, "assets/none"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: unexpected error %v", err)
+	}
+}
+
+func TestSparse(t *testing.T) {
+
+	h := &container.Header{Cipher: container.AES128, Format: cbccts.CS3, Flags: container.FlagMAC | container.FlagChunked | container.FlagSparse, IV: make([]byte, 16)}
+	key, macKey, _ := h.SplitKey(bytes.Repeat([]byte{5}, h.KeySize()))
+
+	// data and holes of a sparse file, including data too short for a chunk before a hole and a hole at the end
+	data := func(n int) []byte { return bytes.Repeat([]byte{0xa5}, n) }
+	for _, parts := range [][]interface{}{
+		{data(4096), int64(1 << 20), data(4096)},
+		{int64(3 << 20), data(100)},
+		{data(5), int64(1 << 20), data(container.ChunkSize + 7), int64(container.ChunkSize), data(1)},
+		{data(container.ChunkSize + 15), int64(1 << 20)},
+		{data(100), int64(100), data(100)},
+	} {
+		var ct bytes.Buffer
+		w, err := container.NewChunkWriter(&ct, h, key, macKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pt []byte
+		for _, p := range parts {
+			switch p := p.(type) {
+			case []byte:
+				w.Write(p)
+				pt = append(pt, p...)
+			case int64:
+				if err := w.WriteHole(p); err != nil {
+					t.Fatal(err)
+				}
+				pt = append(pt, make([]byte, p)...)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if ct.Len() > 3*container.ChunkSize {
+			t.Errorf("holes written as data: %d bytes of ciphertext for %d", ct.Len(), len(pt))
+		}
+
+		r, _ := container.NewChunkReader(bytes.NewReader(ct.Bytes()), h, key, macKey)
+		out, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(out, pt) {
+			t.Errorf("round trip failed: %v", err)
+		}
+
+		// a hole is not a chunk of a container without FlagSparse
+		plain := *h
+		plain.Flags &^= container.FlagSparse
+		r, _ = container.NewChunkReader(bytes.NewReader(ct.Bytes()), &plain, key, macKey)
+		if _, err := io.ReadAll(r); err == nil {
+			t.Errorf("hole accepted without FlagSparse")
+		}
+	}
+
+	// without FlagSparse, a hole is written as zeros
+	plain := *h
+	plain.Flags &^= container.FlagSparse
+	var ct bytes.Buffer
+	w, _ := container.NewChunkWriter(&ct, &plain, key, macKey)
+	w.WriteHole(1 << 20)
+	w.Close()
+	r, _ := container.NewChunkReader(bytes.NewReader(ct.Bytes()), &plain, key, macKey)
+	if out, err := io.ReadAll(r); err != nil || !bytes.Equal(out, make([]byte, 1<<20)) || ct.Len() < 1<<20 {
+		t.Errorf("hole without FlagSparse: %v", err)
+	}
+}
+
+func TestResume(t *testing.T) {
+
+	h := &container.Header{Cipher: container.AES256, Format: cbccts.CS2, Flags: container.FlagMAC | container.FlagChunked, IV: make([]byte, 16)}
+	key, macKey, _ := h.SplitKey(bytes.Repeat([]byte{4}, h.KeySize()))
+	hdr, _ := h.MarshalBinary()
+	pt := make([]byte, 5*container.ChunkSize+1000)
+	for i := range pt {
+		pt[i] = byte(i * 7)
+	}
+	var full bytes.Buffer
+	full.Write(hdr)
+	w, _ := container.NewChunkWriter(&full, h, key, macKey)
+	w.Write(pt)
+	w.Close()
+
+	frame := 5 + container.ChunkSize + container.MACSize
+	name := filepath.Join(t.TempDir(), "ct")
+	for _, c := range []struct {
+		name string
+		cut  func([]byte) []byte
+	}{
+		{"no chunk", func(b []byte) []byte { return b[:len(hdr)] }},
+		{"after a chunk", func(b []byte) []byte { return b[:len(hdr)+2*frame] }},
+		{"within a chunk", func(b []byte) []byte { return b[:len(hdr)+3*frame-100] }},
+		{"torn chunk", func(b []byte) []byte {
+			b = append([]byte(nil), b[:len(hdr)+4*frame]...)
+			zero := b[len(b)-500:]
+			for i := range zero {
+				zero[i] = 0
+			}
+			return b
+		}},
+	} {
+		if err := os.WriteFile(name, c.cut(full.Bytes()), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.OpenFile(name, os.O_RDWR, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src := bytes.NewReader(pt)
+		w, err := container.ResumeChunkWriter(f, h, src, key, macKey)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		io.Copy(w, src)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		if b, _ := os.ReadFile(name); !bytes.Equal(b, full.Bytes()) {
+			t.Errorf("%s: the resumed file differs", c.name)
+		}
+	}
+
+	// a complete file, and a changed plaintext
+	f, _ := os.OpenFile(name, os.O_RDWR, 0)
+	defer f.Close()
+	if _, err := container.ResumeChunkWriter(f, h, bytes.NewReader(pt), key, macKey); err == nil {
+		t.Errorf("complete file resumed")
+	}
+	changed := append([]byte(nil), pt...)
+	changed[100] ^= 1
+	if _, err := container.ResumeChunkWriter(f, h, bytes.NewReader(changed), key, macKey); err == nil {
+		t.Errorf("changed plaintext accepted")
+	}
+}
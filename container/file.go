@@ -0,0 +1,303 @@
+/*
+	file.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"crypto/rand"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// Options are the key and the settings of Encrypt and NewWriter, and the key of Decrypt and NewReader.
+// Exactly one of Passphrase and Key is set.
+type Options struct {
+	Passphrase []byte // derive the key material with KDF; the parameters and a random salt are written in the header
+	Key        []byte // raw key material of Header.KeySize bytes: the cipher key, followed by a MAC key of MACKeySize without NoMAC
+
+	Cipher      Cipher        // AES256 if zero
+	Format      cbccts.Format // CS3 if zero
+	KDF         KDF           // KDF of Passphrase; Argon2id if None
+	KDFParams   [3]uint32     // DefaultKDFParams of the KDF if zero
+	NoMAC       bool          // omit the MAC, leaving the file without an integrity check
+	Chunked     bool          // write the ciphertext as chunks with their own MACs, for large files
+	Sparse      bool          // write the holes of Writer.WriteHole as hole chunks; implies Chunked, and excludes Compression
+	Compression Flags         // FlagGzip, FlagZstd or 0
+
+	AllowNoMAC bool // Decrypt accepts a file without a MAC
+	SkipMAC    bool // Decrypt does not check the MAC of an unchunked file, nor requires one, to recover a damaged file; chunks are always checked
+}
+
+// Encrypt reads the plaintext from r and writes it to w as a container, the same as the file written by the cbccts command.
+// It returns the header written.
+func Encrypt(w io.Writer, r io.Reader, opts *Options) (*Header, error) {
+	ew, err := NewWriter(w, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(ew, r); err != nil {
+		return nil, err
+	}
+	if err := ew.Close(); err != nil {
+		return nil, err
+	}
+	return ew.Header(), nil
+}
+
+// Decrypt reads a container from r and writes its plaintext to w. It returns the header read.
+//
+// If r is an io.ReadSeeker, the MAC of an unchunked container is verified before any plaintext is written;
+// otherwise the MAC is checked at the end, and the plaintext written before an error must be discarded.
+// Chunks are verified one by one as they are read.
+func Decrypt(w io.Writer, r io.Reader, opts *Options) (*Header, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	dr, err := NewReader(r, h, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	if _, err := io.Copy(w, dr); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Writer writes a container: the header first, the compressed and encrypted plaintext, and the MAC on Close.
+type Writer struct {
+	h   *Header
+	w   io.Writer
+	ew  io.WriteCloser // the encrypter, a cbccts.Writer or a ChunkWriter
+	zw  io.WriteCloser // the compressor over ew
+	mac hash.Hash      // the MAC of an unchunked container
+	sum []byte         // the MAC written by Close
+}
+
+// NewWriter creates the header of a container by opts, with a random IV and salt, and writes it to w.
+func NewWriter(w io.Writer, opts *Options) (*Writer, error) {
+	h := &Header{Cipher: opts.Cipher, Format: opts.Format, Flags: opts.Compression}
+	if h.Cipher == 0 {
+		h.Cipher = AES256
+	}
+	if h.Format == 0 {
+		h.Format = cbccts.CS3
+	}
+	if !opts.NoMAC {
+		h.Flags |= FlagMAC
+	}
+	if opts.Chunked || opts.Sparse {
+		h.Flags |= FlagChunked
+	}
+	if opts.Sparse {
+		if opts.Compression != 0 {
+			return nil, fmt.Errorf("container: a sparse container cannot be compressed")
+		}
+		h.Flags |= FlagSparse
+	}
+	if opts.Passphrase != nil {
+		h.KDF.KDF, h.KDF.Params = opts.KDF, opts.KDFParams
+		if h.KDF.KDF == None {
+			h.KDF.KDF = Argon2id
+		}
+		if h.KDF.Params == [3]uint32{} {
+			h.KDF.Params = DefaultKDFParams(h.KDF.KDF)
+		}
+		h.KDF.Salt = make([]byte, 16)
+		if _, err := rand.Read(h.KDF.Salt); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := ciphers[h.Cipher]; !ok {
+		return nil, fmt.Errorf("container: unknown cipher %d", h.Cipher)
+	}
+	h.IV = make([]byte, h.Cipher.BlockSize())
+	if _, err := rand.Read(h.IV); err != nil {
+		return nil, err
+	}
+	hdr, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	key, macKey, err := opts.keys(h)
+	if err != nil {
+		return nil, err
+	}
+
+	cw := &Writer{h: h, w: w}
+	if h.Flags&FlagChunked != 0 {
+		if cw.ew, err = NewChunkWriter(w, h, key, macKey); err != nil {
+			return nil, err
+		}
+	} else {
+		block, err := h.Cipher.NewBlock(key)
+		if err != nil {
+			return nil, err
+		}
+		ww := w
+		if macKey != nil {
+			if cw.mac, err = h.NewMAC(macKey); err != nil {
+				return nil, err
+			}
+			ww = io.MultiWriter(w, cw.mac)
+		}
+		cw.ew = cbccts.NewWriter(ww, cbccts.NewCBCCTSEncrypter(block, h.IV, h.Format))
+	}
+	if cw.zw, err = h.NewCompressor(cw.ew); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Header returns the header written.
+func (w *Writer) Header() *Header {
+	return w.h
+}
+
+// Write writes plaintext.
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.zw.Write(p)
+}
+
+// WriteHole writes n zero bytes, stored as hole chunks without ciphertext in a sparse container, and as zeros otherwise.
+func (w *Writer) WriteHole(n int64) error {
+	if c, ok := w.ew.(*ChunkWriter); ok && w.h.Compression() == "none" {
+		return c.WriteHole(n)
+	}
+	_, err := io.CopyN(w.zw, zeroReader{}, n)
+	return err
+}
+
+// Close writes the final blocks and the MAC. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.zw.Close(); err != nil {
+		return err
+	}
+	if err := w.ew.Close(); err != nil {
+		return err
+	}
+	if w.mac != nil && w.sum == nil {
+		w.sum = w.mac.Sum(nil)
+		if _, err := w.w.Write(w.sum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MAC returns the MAC written after the ciphertext by Close, or nil if there is none, as in a chunked container.
+func (w *Writer) MAC() []byte {
+	return w.sum
+}
+
+// Reader reads the plaintext of a container.
+type Reader struct {
+	pr io.Reader     // the decrypter
+	zr io.ReadCloser // the decompressor over pr
+}
+
+// NewReader returns a Reader of the plaintext of the container with the header h, read by ReadHeader from r.
+// The MAC is verified as by Decrypt; the Reader returns an error instead of io.EOF if it does not match.
+func NewReader(r io.Reader, h *Header, opts *Options) (*Reader, error) {
+	if h.Flags&FlagMAC == 0 && !opts.AllowNoMAC && !opts.SkipMAC {
+		return nil, fmt.Errorf("container: the file has no MAC")
+	}
+	key, macKey, err := opts.keys(h)
+	if err != nil {
+		return nil, err
+	}
+
+	dr := new(Reader)
+	if h.Flags&FlagChunked != 0 {
+		if dr.pr, err = NewChunkReader(r, h, key, macKey); err != nil {
+			return nil, err
+		}
+	} else {
+		block, err := h.Cipher.NewBlock(key)
+		if err != nil {
+			return nil, err
+		}
+		if macKey != nil {
+			var mac hash.Hash
+			if !opts.SkipMAC {
+				if mac, err = h.NewMAC(macKey); err != nil {
+					return nil, err
+				}
+			}
+			if rs, ok := r.(io.ReadSeeker); ok && mac != nil {
+				// verify the whole file, and read it again
+				pos, err := rs.Seek(0, io.SeekCurrent)
+				if err != nil {
+					return nil, err
+				}
+				if _, err := io.Copy(io.Discard, NewMACReader(rs, mac)); err != nil {
+					return nil, err
+				}
+				if _, err := rs.Seek(pos, io.SeekStart); err != nil {
+					return nil, err
+				}
+				mac = nil
+			}
+			r = NewMACReader(r, mac)
+		}
+		dr.pr = cbccts.NewReader(r, cbccts.NewCBCCTSDecrypter(block, h.IV, h.Format))
+	}
+	if dr.zr, err = h.NewDecompressor(dr.pr); err != nil {
+		return nil, err
+	}
+	return dr, nil
+}
+
+// Read reads plaintext.
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.zr.Read(p)
+	if err == io.EOF {
+		// the rest of the input, after the end of the compressed stream, is still verified
+		if _, e := io.Copy(io.Discard, r.pr); e != nil {
+			err = e
+		}
+	}
+	return n, err
+}
+
+// Close releases the decompressor. It does not close the underlying reader.
+func (r *Reader) Close() error {
+	return r.zr.Close()
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// the cipher key and the MAC key of a container
+func (opts *Options) keys(h *Header) (key, macKey []byte, err error) {
+	if (opts.Passphrase == nil) == (opts.Key == nil) {
+		return nil, nil, fmt.Errorf("container: exactly one of a passphrase and a key is needed")
+	}
+	material := opts.Key
+	if opts.Passphrase != nil {
+		if h.KDF.KDF == None {
+			return nil, nil, fmt.Errorf("container: the key of the file is not derived from a passphrase")
+		}
+		if material, err = h.KDF.DeriveKey(opts.Passphrase, h.KeySize()); err != nil {
+			return nil, nil, err
+		}
+	} else if h.KDF.KDF != None {
+		return nil, nil, fmt.Errorf("container: the key of the file is derived from a passphrase")
+	}
+	return h.SplitKey(material)
+}
@@ -0,0 +1,64 @@
+/*
+	fs.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"bytes"
+	"io/fs"
+)
+
+// NewFS returns a read-only view of base in which every file is a container, decrypted with the key of opts.
+//
+// Open reads, verifies and decrypts a whole file into memory, so that the file returned can seek, as http.FS requires,
+// and no unverified plaintext is ever read; a file that is not a container, or fails to verify, is an error.
+// With a passphrase, every Open runs the KDF, as each file has its own salt; a raw key is the better choice for many files.
+// Directories are those of base, and the sizes of their entries are those of the containers.
+func NewFS(base fs.FS, opts *Options) fs.FS {
+	return &decryptedFS{base, opts}
+}
+
+type decryptedFS struct {
+	base fs.FS
+	opts *Options
+}
+
+func (d *decryptedFS) Open(name string) (fs.File, error) {
+	f, err := d.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi.IsDir() {
+		return f, nil
+	}
+	defer f.Close()
+	var pt bytes.Buffer
+	if _, err := Decrypt(&pt, f, d.opts); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &decryptedFile{bytes.NewReader(pt.Bytes()), decryptedInfo{fi, int64(pt.Len())}}, nil
+}
+
+// a decrypted file in memory
+type decryptedFile struct {
+	*bytes.Reader
+	info decryptedInfo
+}
+
+func (f *decryptedFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *decryptedFile) Close() error               { return nil }
+
+// the FileInfo of a container, with the size of its plaintext
+type decryptedInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (fi decryptedInfo) Size() int64 { return fi.size }
@@ -0,0 +1,135 @@
+/*
+	kdf.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF is a passphrase-based key derivation function, by its id in the header.
+type KDF byte
+
+const (
+	None     KDF = 0 // the key is not derived from a passphrase
+	PBKDF2   KDF = 1 // PBKDF2-HMAC-SHA256; params: iterations
+	Scrypt   KDF = 2 // scrypt; params: log2 N, r, p
+	Argon2id KDF = 3 // Argon2id; params: time, memory in KiB, threads
+)
+
+var kdfNames = map[KDF]string{
+	None:     "none",
+	PBKDF2:   "pbkdf2",
+	Scrypt:   "scrypt",
+	Argon2id: "argon2id",
+}
+
+// ParseKDF returns the KDF of a name: "pbkdf2", "scrypt" or "argon2id".
+func ParseKDF(name string) (KDF, error) {
+	name = strings.ToLower(name)
+	for k, n := range kdfNames {
+		if n == name && k != None {
+			return k, nil
+		}
+	}
+	return None, fmt.Errorf("container: unknown KDF %q", name)
+}
+
+func (k KDF) String() string {
+	if n, ok := kdfNames[k]; ok {
+		return n
+	}
+	return fmt.Sprintf("kdf(%d)", byte(k))
+}
+
+// the parameters of the KDFs used when none are given, after the OWASP recommendations of 2023
+var kdfDefaults = map[KDF][3]uint32{
+	PBKDF2:   {600000, 0, 0},
+	Scrypt:   {17, 8, 1},
+	Argon2id: {3, 64 * 1024, 4},
+}
+
+// DefaultKDFParams returns the parameters of a KDF used when none are given, after the OWASP recommendations of 2023;
+// zero for None.
+func DefaultKDFParams(k KDF) [3]uint32 {
+	return kdfDefaults[k]
+}
+
+// KDFParams is a KDF with its parameters and salt.
+type KDFParams struct {
+	KDF    KDF
+	Params [3]uint32
+	Salt   []byte
+}
+
+// Maximum costs of the KDFs. The parameters come from the header, before anything is authenticated,
+// so that a crafted file could otherwise make DeriveKey run for hours or exhaust the memory.
+const (
+	MaxPBKDF2Iterations = 10000000
+	MaxScryptLogN       = 22      // and 128·r·N must not exceed MaxKDFMemory
+	MaxScryptRP         = 1 << 10 // r·p must be less
+	MaxArgon2Time       = 16
+	MaxArgon2Threads    = 255
+	MaxKDFMemory        = 4 << 30 // bytes of memory of scrypt and Argon2id
+)
+
+// Check returns an error if the parameters are not valid, or exceed the maximum costs.
+func (k *KDFParams) Check() error {
+	p := k.Params
+	switch k.KDF {
+	case PBKDF2:
+		if p[0] == 0 || p[0] > MaxPBKDF2Iterations {
+			return fmt.Errorf("container: invalid PBKDF2 iterations %d; must be 1 to %d", p[0], MaxPBKDF2Iterations)
+		}
+	case Scrypt:
+		if p[0] == 0 || p[0] > MaxScryptLogN {
+			return fmt.Errorf("container: invalid scrypt cost 2^%d; must be up to 2^%d", p[0], MaxScryptLogN)
+		}
+		if r, pp := uint64(p[1]), uint64(p[2]); r == 0 || pp == 0 || r*pp >= MaxScryptRP || 128*r<<p[0] > MaxKDFMemory {
+			return fmt.Errorf("container: invalid scrypt parameters r=%d p=%d", p[1], p[2])
+		}
+	case Argon2id:
+		if p[0] == 0 || p[0] > MaxArgon2Time || p[1] == 0 || uint64(p[1])*1024 > MaxKDFMemory || p[2] == 0 || p[2] > MaxArgon2Threads {
+			return fmt.Errorf("container: invalid Argon2id parameters %v", p)
+		}
+	default:
+		return fmt.Errorf("container: no passphrase KDF")
+	}
+	return nil
+}
+
+// DeriveKey derives a key of keyLen bytes from a passphrase. The parameters are checked first, see Check.
+func (k *KDFParams) DeriveKey(passphrase []byte, keyLen int) ([]byte, error) {
+	if err := k.Check(); err != nil {
+		return nil, err
+	}
+	p := k.Params
+	switch k.KDF {
+	case PBKDF2:
+		return pbkdf2.Key(passphrase, k.Salt, int(p[0]), keyLen, sha256.New), nil
+	case Scrypt:
+		return scrypt.Key(passphrase, k.Salt, 1<<p[0], int(p[1]), int(p[2]), keyLen)
+	}
+	return argon2.IDKey(passphrase, k.Salt, p[0], p[1], uint8(p[2]), uint32(keyLen)), nil
+}
+
+func (k *KDFParams) String() string {
+	p := k.Params
+	switch k.KDF {
+	case PBKDF2:
+		return fmt.Sprintf("pbkdf2-sha256 iterations=%d", p[0])
+	case Scrypt:
+		return fmt.Sprintf("scrypt N=2^%d r=%d p=%d", p[0], p[1], p[2])
+	case Argon2id:
+		return fmt.Sprintf("argon2id t=%d m=%dKiB p=%d", p[0], p[1], p[2])
+	}
+	return k.KDF.String()
+}
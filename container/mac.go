@@ -0,0 +1,119 @@
+/*
+	mac.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// FlagMAC marks a container with an HMAC-SHA-256 trailer over the header and the ciphertext, encrypt-then-MAC.
+const FlagMAC Flags = 1 << 0
+
+const (
+	MACSize    = sha256.Size // size of the trailer
+	MACKeySize = 32          // size of the MAC key, after the cipher key in the key material
+)
+
+// ErrMAC is returned when the trailer of a container does not match.
+var ErrMAC = errors.New("container: MAC verification failed")
+
+// KeySize returns the bytes of key material of a container: the cipher key, followed by the MAC key if the header has FlagMAC.
+// A passphrase is derived to this size.
+func (h *Header) KeySize() int {
+	n := h.Cipher.KeySize()
+	if h.Flags&FlagMAC != 0 {
+		n += MACKeySize
+	}
+	return n
+}
+
+// SplitKey splits key material of KeySize bytes into the cipher key and the MAC key, which is nil without FlagMAC.
+func (h *Header) SplitKey(key []byte) (cipherKey, macKey []byte, err error) {
+	if len(key) != h.KeySize() {
+		return nil, nil, fmt.Errorf("container: key material must be %d bytes", h.KeySize())
+	}
+	n := h.Cipher.KeySize()
+	if h.Flags&FlagMAC != 0 {
+		macKey = key[n:]
+	}
+	return key[:n], macKey, nil
+}
+
+// NewMAC returns the HMAC-SHA-256 of a container with the header already written to it.
+// The ciphertext is written next, and the sum is the trailer.
+func (h *Header) NewMAC(macKey []byte) (hash.Hash, error) {
+	b, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(b)
+	return mac, nil
+}
+
+// MACReader reads the ciphertext of a container from an underlying reader positioned after the header,
+// writing it to a MAC and holding back the trailer. At the end of the input, Read returns io.EOF if the trailer matches,
+// or ErrMAC otherwise; the data already read is then not authentic.
+type MACReader struct {
+	r   io.Reader
+	mac hash.Hash
+	buf []byte
+	off int // start of the data not read yet
+	end int // end of the data read from r; the last MACSize bytes are held back
+	err error
+}
+
+// NewMACReader returns a MACReader verifying with mac, made by Header.NewMAC.
+// mac may be nil to strip the trailer without verifying it.
+func NewMACReader(r io.Reader, mac hash.Hash) *MACReader {
+	return &MACReader{r: r, mac: mac, buf: make([]byte, 32*1024+MACSize)}
+}
+
+func (m *MACReader) Read(p []byte) (int, error) {
+	for m.off == m.ready() {
+		if m.err != nil {
+			return 0, m.err
+		}
+		m.fill()
+	}
+	n := copy(p, m.buf[m.off:m.ready()])
+	m.off += n
+	return n, nil
+}
+
+// end of the data that may be released
+func (m *MACReader) ready() int {
+	if m.end < MACSize {
+		return 0
+	}
+	return m.end - MACSize
+}
+
+func (m *MACReader) fill() {
+	m.end = copy(m.buf, m.buf[m.off:m.end])
+	m.off = 0
+	n, err := io.ReadAtLeast(m.r, m.buf[m.end:], MACSize+1)
+	m.end += n
+	if m.mac != nil {
+		m.mac.Write(m.buf[:m.ready()])
+	}
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		if m.end < MACSize || (m.mac != nil && !hmac.Equal(m.mac.Sum(nil), m.buf[m.ready():m.end])) {
+			m.err = ErrMAC
+		} else {
+			m.err = io.EOF
+		}
+	default:
+		m.err = err
+	}
+}
@@ -0,0 +1,119 @@
+/*
+	resume.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ResumeFile is a container file to resume, such as an *os.File opened for reading and writing.
+type ResumeFile interface {
+	io.ReadWriteSeeker
+	Truncate(size int64) error
+}
+
+// ResumeChunkWriter continues writing a chunked container that was interrupted before its final chunk, such as by a crash,
+// so that a long encryption need not start over. The chunks are checkpoints: each is encrypted and authenticated on its own,
+// and the state of the writer after a chunk is only its sequence number.
+//
+// h is the header of f, read with ReadHeader to derive the keys, and src is the plaintext from its start.
+// Every chunk in f is verified, decrypted, and compared with the plaintext read from src. f is cut after the last chunk
+// that is whole and verifies, as a crash may leave the last chunk torn, and the returned ChunkWriter writes from there on;
+// src is then positioned at the plaintext to write next. It is an error if f is already complete,
+// or if its plaintext differs from src.
+func ResumeChunkWriter(f ResumeFile, h *Header, src io.Reader, cipherKey, macKey []byte) (*ChunkWriter, error) {
+	hdr, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(int64(len(hdr)), io.SeekStart); err != nil {
+		return nil, err
+	}
+	cf := &countReader{r: f, n: int64(len(hdr))}
+	r, err := NewChunkReader(cf, h, cipherKey, macKey)
+	if err != nil {
+		return nil, err
+	}
+
+	end := cf.n // the end of the verified chunks
+	pt := make([]byte, ChunkSize+r.block.BlockSize())
+	defer zero(pt)
+	for {
+		err := r.readChunk()
+		if err == ErrMAC || errors.Is(err, errInvalidChunk) {
+			// only the last chunk may be torn; a chunk before others fails for a wrong key or corruption
+			size, e := f.Seek(0, io.SeekEnd)
+			if e != nil {
+				return nil, e
+			}
+			if size-end > int64(5+ChunkSize+r.block.BlockSize()+MACSize) {
+				return nil, err
+			}
+			break
+		}
+		if err == ErrTruncated {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if r.final {
+			return nil, fmt.Errorf("container: the file is complete")
+		}
+		n := int64(len(r.out))
+		if r.hole > 0 {
+			n = r.hole
+		}
+		for n > 0 {
+			b := pt
+			if int64(len(b)) > n {
+				b = b[:n]
+			}
+			if _, err := io.ReadFull(src, b); err != nil {
+				return nil, fmt.Errorf("container: reading the plaintext: %v", err)
+			}
+			same := true
+			if r.hole > 0 {
+				for _, c := range b {
+					same = same && c == 0
+				}
+			} else {
+				same = bytes.Equal(b, r.out[:len(b)])
+				r.out = r.out[len(b):]
+			}
+			if !same {
+				return nil, fmt.Errorf("container: the plaintext differs from the file already written")
+			}
+			n -= int64(len(b))
+		}
+		zero(r.buf)
+		r.hole = 0
+		end = cf.n
+	}
+
+	if err := f.Truncate(end); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(end, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &ChunkWriter{chunker: r.chunker, w: f, buf: make([]byte, 0, ChunkSize+r.block.BlockSize())}, nil
+}
+
+// counts the bytes read
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,92 @@
+/*
+	sparse.go
+	2026-10, github.com/mixcode
+*/
+
+package container
+
+import (
+	"fmt"
+)
+
+// FlagSparse marks a chunked container that may hold holes: runs of zeros stored as a chunk without ciphertext,
+// as the holes of a sparse file such as a disk image. It requires FlagChunked.
+//
+// The first byte of a hole chunk is 2, and its length is the number of zero bytes it stands for; its MAC is that of a data chunk.
+// The data chunks around a hole may be shorter than ChunkSize, but at least a block long.
+const FlagSparse Flags = 1 << 4
+
+// kinds of chunks, the first byte of a chunk
+const (
+	chunkFinal = 1 << 0
+	chunkHole  = 1 << 1
+)
+
+// the longest hole written as a single chunk
+const maxHole = 1 << 30
+
+// WriteHole writes n zero bytes, which a container with FlagSparse stores as hole chunks without ciphertext.
+// Holes shorter than a chunk, and any hole without FlagSparse, are written as zeros.
+func (w *ChunkWriter) WriteHole(n int64) error {
+	if n < 0 {
+		return fmt.Errorf("container: negative hole")
+	}
+	if n < ChunkSize || w.h.Flags&FlagSparse == 0 {
+		return w.writeZeros(n)
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	// the data before the hole is written, made at least a block long with zeros of the hole
+	blocksz := int64(w.block.BlockSize())
+	if k := blocksz - int64(len(w.buf)); len(w.buf) > 0 && k > 0 {
+		if w.err = w.writeZeros(k); w.err != nil {
+			return w.err
+		}
+		n -= k
+	}
+	if len(w.buf) > ChunkSize {
+		// too long for a chunk, and split into two of at least a block
+		k := len(w.buf) - int(blocksz)
+		if w.err = w.writeChunk(w.buf[:k], false); w.err != nil {
+			return w.err
+		}
+		w.buf = w.buf[:copy(w.buf, w.buf[k:])]
+	}
+	if len(w.buf) > 0 {
+		if w.err = w.writeChunk(w.buf, false); w.err != nil {
+			return w.err
+		}
+		w.buf = w.buf[:0]
+	}
+
+	// the last block of the hole is kept as data, so that the final chunk after the hole is at least a block long
+	for n -= blocksz; n > 0; {
+		k := n
+		if k > maxHole {
+			k = maxHole
+		}
+		if w.err = w.writeFrame(chunkHole, uint32(k), nil); w.err != nil {
+			return w.err
+		}
+		n -= k
+	}
+	return w.writeZeros(blocksz)
+}
+
+// write zeros as data
+func (w *ChunkWriter) writeZeros(n int64) error {
+	var zeros [4096]byte
+	for n > 0 {
+		k := int64(len(zeros))
+		if k > n {
+			k = n
+		}
+		if _, err := w.Write(zeros[:k]); err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
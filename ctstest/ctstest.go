@@ -0,0 +1,79 @@
+/*
+	ctstest.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package ctstest checks the equivalences between the CTS formats of package cbccts, for the tests of packages built on it.
+
+	CS2 is CS1 on data aligned to the block size, and CS3 on other data. The functions here check these properties
+	on any block cipher and data, for both encryption and decryption, and return an error describing the first difference.
+*/
+package ctstest
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// CS2EqualsCS1 checks that CS2 encrypts data aligned to the block size as CS1 does, and decrypts the result back.
+func CS2EqualsCS1(b cipher.Block, iv, data []byte) error {
+	if len(data) == 0 || len(data)%b.BlockSize() != 0 {
+		return fmt.Errorf("ctstest: %d bytes are not aligned to the block size %d", len(data), b.BlockSize())
+	}
+	return equal(b, iv, data, cbccts.CS1)
+}
+
+// CS2EqualsCS3 checks that CS2 encrypts data not aligned to the block size, at least a block long, as CS3 does,
+// and decrypts the result back.
+func CS2EqualsCS3(b cipher.Block, iv, data []byte) error {
+	if len(data) < b.BlockSize() || len(data)%b.BlockSize() == 0 {
+		return fmt.Errorf("ctstest: %d bytes are not an unaligned message of the block size %d", len(data), b.BlockSize())
+	}
+	return equal(b, iv, data, cbccts.CS3)
+}
+
+// CheckFormats checks CS2EqualsCS1 or CS2EqualsCS3 with a random IV and random data of every length
+// from one block to maxLen bytes.
+func CheckFormats(b cipher.Block, maxLen int) error {
+	bs := b.BlockSize()
+	iv := make([]byte, bs)
+	data := make([]byte, maxLen)
+	if _, err := rand.Read(data); err != nil {
+		return err
+	}
+	for n := bs; n <= maxLen; n++ {
+		if _, err := rand.Read(iv); err != nil {
+			return err
+		}
+		check := CS2EqualsCS3
+		if n%bs == 0 {
+			check = CS2EqualsCS1
+		}
+		if err := check(b, iv, data[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compare CS2 with the format f on data
+func equal(b cipher.Block, iv, data []byte, f cbccts.Format) error {
+	n := len(data)
+	ct, expect := make([]byte, n), make([]byte, n)
+	cbccts.NewCBCCTSEncrypter(b, iv, cbccts.CS2).CryptBlocks(ct, data)
+	cbccts.NewCBCCTSEncrypter(b, iv, f).CryptBlocks(expect, data)
+	if !bytes.Equal(ct, expect) {
+		return fmt.Errorf("ctstest: block size %d, %d bytes: CS2 and CS%d ciphertexts differ", b.BlockSize(), n, f)
+	}
+	pt := make([]byte, n)
+	cbccts.NewCBCCTSDecrypter(b, iv, cbccts.CS2).CryptBlocks(pt, expect)
+	if !bytes.Equal(pt, data) {
+		return fmt.Errorf("ctstest: block size %d, %d bytes: CS2 does not decrypt the CS%d ciphertext", b.BlockSize(), n, f)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package ctstest_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/ctstest"
+)
+
+func TestCheckFormats(t *testing.T) {
+
+	aesCipher, _ := aes.NewCipher(make([]byte, 16))
+	desCipher, _ := des.NewCipher(make([]byte, 8))
+	for _, b := range []cipher.Block{aesCipher, desCipher} {
+		if err := ctstest.CheckFormats(b, 10*b.BlockSize()+1); err != nil {
+			t.Error(err)
+		}
+
+		// the lengths each property applies to
+		bs := b.BlockSize()
+		iv := make([]byte, bs)
+		if err := ctstest.CS2EqualsCS1(b, iv, make([]byte, bs+1)); err == nil {
+			t.Errorf("block size %d: CS2EqualsCS1 accepted unaligned data", bs)
+		}
+		for _, n := range []int{bs - 1, 2 * bs} {
+			if err := ctstest.CS2EqualsCS3(b, iv, make([]byte, n)); err == nil {
+				t.Errorf("block size %d: CS2EqualsCS3 accepted %d bytes", bs, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+/*
+	decryptfrom.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"fmt"
+)
+
+// DecryptFrom decrypts the end of a message, from the block at blockIndex, without the ciphertext before it:
+// a CBC block depends only on the ciphertext block before it, which is passed as prevCipherBlock; for block 0, it is the IV.
+// src is the ciphertext from the offset blockIndex*BlockSize to the end of the message, and dst receives its plaintext.
+//
+// The final two blocks, reordered by CS2 and CS3 and joined by the stolen ciphertext, are decrypted together,
+// so src must hold the whole of them: it may not start at the last block of a message of more than one block.
+// The chaining value of the decrypter is set to prevCipherBlock first, as Reset does, and is left at the end of the message.
+func (d *Decrypter) DecryptFrom(blockIndex int, prevCipherBlock, dst, src []byte) {
+	blocksz := d.BlockSize()
+	if blockIndex < 0 {
+		panic(fmt.Errorf("negative block index"))
+	}
+	if len(prevCipherBlock) != blocksz {
+		panic(fmt.Errorf("the previous ciphertext block must be one block long"))
+	}
+	if blockIndex > 0 && len(src) == blocksz && d.mode == CS3 {
+		// the last block in the stream is the second last in CBC order
+		panic(fmt.Errorf("the final two blocks must be decrypted together"))
+	}
+	d.Reset(prevCipherBlock)
+	d.CryptBlocks(dst, src)
+}
@@ -0,0 +1,67 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"math/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestDecryptFrom(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rnd.Read(b)
+		return b
+	}
+	aesCipher, _ := aes.NewCipher(random(16))
+	desCipher, _ := des.NewCipher(random(8))
+	for _, b := range []cipher.Block{aesCipher, desCipher} {
+		bs := b.BlockSize()
+		for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+			for _, n := range []int{bs, bs + 1, 2 * bs, 2*bs + 3, 5 * bs, 7*bs + bs - 1} {
+				iv, pt := random(bs), random(n)
+				ct := make([]byte, n)
+				cbccts.NewCBCCTSEncrypter(b, iv, mode).CryptBlocks(ct, pt)
+
+				// every start up to the final two blocks
+				for i := 0; i*bs < n; i++ {
+					last := i*bs+bs == n && n > bs
+					if last && mode == cbccts.CS3 {
+						continue
+					}
+					prev := iv
+					if i > 0 {
+						prev = ct[(i-1)*bs : i*bs]
+					}
+					if i*bs+bs > n {
+						break // within a partial block
+					}
+					dst := make([]byte, n-i*bs)
+					d := cbccts.NewCBCCTSDecrypter(b, make([]byte, bs), mode).(*cbccts.Decrypter)
+					d.DecryptFrom(i, prev, dst, ct[i*bs:])
+					if !bytes.Equal(dst, pt[i*bs:]) {
+						t.Errorf("block size %d, CS%d, %d bytes from block %d: decryption differs", bs, mode, n, i)
+					}
+				}
+			}
+		}
+	}
+
+	// the last block of a CS3 message alone
+	iv := make([]byte, 16)
+	ct := make([]byte, 48)
+	cbccts.NewCBCCTSEncrypter(aesCipher, iv, cbccts.CS3).CryptBlocks(ct, ct)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("the last block alone accepted")
+		}
+	}()
+	d := cbccts.NewCBCCTSDecrypter(aesCipher, iv, cbccts.CS3).(*cbccts.Decrypter)
+	d.DecryptFrom(2, ct[16:32], make([]byte, 16), ct[32:])
+}
@@ -0,0 +1,64 @@
+/*
+	dmcrypt.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// Plain64 generates per-sector IVs with the "plain64" scheme of Linux dm-crypt:
+// the sector number as a 64-bit little-endian integer padded with zeros to a block.
+// The IV is predictable, which allows watermarking attacks on CBC; ESSIV should be preferred where there is a choice.
+type Plain64 struct {
+	size int
+}
+
+// NewPlain64 creates a plain64 generator of IVs of blockSize bytes, at least 8.
+func NewPlain64(blockSize int) *Plain64 {
+	if blockSize < 8 {
+		panic(fmt.Errorf("block size too small for a plain64 IV"))
+	}
+	return &Plain64{size: blockSize}
+}
+
+// BlockSize returns the size of the IV.
+func (p *Plain64) BlockSize() int {
+	return p.size
+}
+
+// PutIV writes the IV for a sector into iv, which must be one block long.
+func (p *Plain64) PutIV(iv []byte, sectorNum uint64) {
+	for i := range iv {
+		iv[i] = 0
+	}
+	binary.LittleEndian.PutUint64(iv, sectorNum)
+}
+
+// DMCryptSectorSize is the sector size of dm-crypt, and the unit of its sector numbers.
+const DMCryptSectorSize = 512
+
+// NewDMCryptSectorCipher creates a SectorCipher whose sectors are bit-compatible with those of a Linux dm-crypt device
+// of cipher aes-cbc-plain64, or aes-cbc-essiv:sha256 if essiv is true, with the AES key of 16, 24 or 32 bytes.
+// The mode is CS1, which for sectors of whole blocks, such as those of dm-crypt, is plain CBC.
+//
+// The sector number is that of dm-crypt: the offset in the device divided by DMCryptSectorSize, plus the iv_offset of the table.
+// With a larger sector_size, sectors are numbered in units of 512 bytes all the same, unless the table has iv_large_sectors.
+func NewDMCryptSectorCipher(key []byte, essiv bool) (*SectorCipher, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var ivgen IVGenerator = NewPlain64(b.BlockSize())
+	if essiv {
+		if ivgen, err = NewESSIV(aes.NewCipher, key, sha256.New); err != nil {
+			return nil, err
+		}
+	}
+	return NewSectorCipher(b, ivgen, CS1), nil
+}
@@ -0,0 +1,55 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestDMCrypt(t *testing.T) {
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	ac, _ := aes.NewCipher(key)
+	salt := sha256.Sum256(key)
+	ec, _ := aes.NewCipher(salt[:])
+
+	data := make([]byte, cbccts.DMCryptSectorSize)
+	rand.Read(data)
+
+	for _, essiv := range []bool{false, true} {
+		sc, err := cbccts.NewDMCryptSectorCipher(key, essiv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sector := range []uint64{0, 1, 7, 0x0123456789abcdef} {
+			// dm-crypt: CBC of the sector, with the IV of its number
+			iv := make([]byte, aes.BlockSize)
+			binary.LittleEndian.PutUint64(iv, sector)
+			if essiv {
+				ec.Encrypt(iv, iv)
+			}
+			expect := make([]byte, len(data))
+			cipher.NewCBCEncrypter(ac, iv).CryptBlocks(expect, data)
+			ct := make([]byte, len(data))
+			sc.EncryptSector(ct, data, sector)
+			if !bytes.Equal(ct, expect) {
+				t.Errorf("essiv %v, sector %x: not dm-crypt compatible", essiv, sector)
+			}
+			sc.DecryptSector(ct, ct, sector)
+			if !bytes.Equal(ct, data) {
+				t.Errorf("essiv %v, sector %x: decryption failed", essiv, sector)
+			}
+		}
+	}
+
+	if _, err := cbccts.NewDMCryptSectorCipher(key[:20], true); err == nil {
+		t.Errorf("invalid key size accepted")
+	}
+}
@@ -0,0 +1,95 @@
+//go:build dudect
+// +build dudect
+
+package cbccts_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/internal/dudect"
+)
+
+// The timing leak tests take a while and depend on a quiet machine; run them with
+//
+//	go test -tags dudect -run Dudect -v
+//
+// The default tail processing is expected to leak the length of the last block, and is only reported.
+
+const dudectMeasurements = 200000
+
+func TestDudectLastBlockLength(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	buf := make([]byte, 4*aes.BlockSize)
+
+	// class 0: a last block of one byte; class 1: a last block of random length
+	for _, ct := range []bool{false, true} {
+		for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+			enc := cbccts.NewCBCCTSEncrypter(ac, iv, mode)
+			dec := cbccts.NewCBCCTSDecrypter(ac, iv, mode)
+			if ct {
+				enc, dec = cbccts.WithConstantTime(enc), cbccts.WithConstantTime(dec)
+			}
+			// the random lengths are drawn beforehand, so that both classes do the same untimed work
+			lengths := make([]byte, dudectMeasurements)
+			rand.Read(lengths)
+			var text []byte
+			i := 0
+			prepare := func(class int) {
+				last := 1 + int(lengths[i])%aes.BlockSize
+				if class == 0 {
+					last = 1
+				}
+				i++
+				text = buf[:3*aes.BlockSize+last]
+			}
+			r := dudect.Run(dudectMeasurements, 1, 0.9, prepare, func() {
+				enc.CryptBlocks(text, text)
+				dec.CryptBlocks(text, text)
+			})
+			switch {
+			case !r.Leaks():
+				t.Logf("constant time %v, CS%d: t = %.2f", ct, mode, r.T)
+			case ct:
+				t.Errorf("constant time %v, CS%d: timing leak of the last block length, t = %.2f, means %v", ct, mode, r.T, r.Means)
+			default:
+				t.Logf("constant time %v, CS%d: leaks as expected, t = %.2f", ct, mode, r.T)
+			}
+		}
+	}
+}
+
+func TestDudectKey(t *testing.T) {
+
+	// class 0: a fixed key; class 1: random keys. The cipher of either class is made outside the measurement.
+	n := dudectMeasurements / 10
+	keys := make([]byte, 16*n)
+	rand.Read(keys)
+	fixed := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	text := make([]byte, 3*aes.BlockSize+5)
+	var enc cipher.BlockMode
+	i := 0
+	prepare := func(class int) {
+		key := keys[16*i : 16*i+16]
+		if class == 0 {
+			key = fixed
+		}
+		i++
+		b, _ := aes.NewCipher(key)
+		enc = cbccts.NewCBCCTSEncrypter(b, iv, cbccts.CS3)
+	}
+	r := dudect.Run(n, 1, 0.9, prepare, func() {
+		enc.CryptBlocks(text, text)
+	})
+	if r.Leaks() {
+		t.Errorf("timing leak of the key, t = %.2f, means %v; the AES of this platform may not be constant time", r.T, r.Means)
+	} else {
+		t.Logf("t = %.2f", r.T)
+	}
+}
@@ -0,0 +1,147 @@
+package eme_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/eme"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+func TestEME(t *testing.T) {
+
+	key := make([]byte, 0x20)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	ac, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := eme.New(ac)
+
+	// for a single block, EME reduces to C = E(E(E(P xor L) xor T) xor T) xor L, where L = 2*E(0)
+	l := make([]byte, aes.BlockSize)
+	ac.Encrypt(l, l)
+	double := func(b []byte) {
+		var carry byte
+		for j := range b {
+			c := b[j] >> 7
+			b[j] = b[j]<<1 | carry
+			carry = c
+		}
+		if carry != 0 {
+			b[0] ^= 0x87
+		}
+	}
+	double(l)
+	tweak := make([]byte, aes.BlockSize)
+	tweak[0] = 0x55
+	p := make([]byte, aes.BlockSize)
+	for i := range p {
+		p[i] = byte(i)
+	}
+	expect := make([]byte, aes.BlockSize)
+	for i := range expect {
+		expect[i] = p[i] ^ l[i]
+	}
+	ac.Encrypt(expect, expect) // PPP_1
+	for i := range expect {
+		expect[i] ^= tweak[i] // MP
+	}
+	ac.Encrypt(expect, expect) // MC
+	for i := range expect {
+		expect[i] ^= tweak[i] // CCC_1
+	}
+	ac.Encrypt(expect, expect)
+	for i := range expect {
+		expect[i] ^= l[i]
+	}
+	got := make([]byte, aes.BlockSize)
+	c.Encrypt(got, p, tweak)
+	if !bytes.Equal(got, expect) {
+		t.Errorf("single block: expected %x, got %x", expect, got)
+	}
+
+	for m := 1; m <= 128; m++ {
+		data := make([]byte, m*aes.BlockSize)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		enc := make([]byte, len(data))
+		c.EncryptSector(enc, data, uint64(m))
+
+		// a single bit flip in the plaintext changes every block of the ciphertext
+		data[len(data)-1] ^= 1
+		enc2 := make([]byte, len(data))
+		c.EncryptSector(enc2, data, uint64(m))
+		data[len(data)-1] ^= 1
+		for j := 0; j < len(enc); j += aes.BlockSize {
+			if bytes.Equal(enc[j:j+aes.BlockSize], enc2[j:j+aes.BlockSize]) {
+				t.Errorf("bit flip not diffused: %d blocks, block %d", m, j/aes.BlockSize)
+			}
+		}
+
+		// the tweak changes the ciphertext
+		c.EncryptSector(enc2, data, uint64(m)+1)
+		if bytes.Equal(enc, enc2) {
+			t.Errorf("tweak ignored: %d blocks", m)
+		}
+
+		// in-place decryption
+		c.DecryptSector(enc, enc, uint64(m))
+		if !bytes.Equal(enc, data) {
+			t.Errorf("round trip failed: %d blocks", m)
+		}
+	}
+}
+
+// a published known answer; Iterations is the number of times the plaintext is encrypted to the ciphertext
+type knownAnswer struct {
+	Description string           `json:"description"`
+	Key         vectors.HexBytes `json:"key"`
+	Tweak       vectors.HexBytes `json:"tweak"`
+	Plaintext   vectors.HexBytes `json:"plaintext"`
+	Ciphertext  vectors.HexBytes `json:"ciphertext"`
+	Iterations  int              `json:"iterations"`
+}
+
+// the EME-32-AES vectors of Halevi, from the IEEE P1619 draft "Draft Standard for Tweakable Wide-block Encryption"
+// (2005) and the P1619 mailing list post "Test vectors for LRW and EME" (2004)
+func TestKnownAnswers(t *testing.T) {
+
+	b, err := ioutil.ReadFile("testdata/eme32.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests []knownAnswer
+	if err := json.Unmarshal(b, &tests); err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) == 0 {
+		t.Fatal("no test vectors")
+	}
+	for _, v := range tests {
+		ac, err := aes.NewCipher(v.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := eme.New(ac)
+		out := append([]byte(nil), v.Plaintext...)
+		for i := 0; i < v.Iterations; i++ {
+			c.Encrypt(out, out, v.Tweak)
+		}
+		if !bytes.Equal(out, v.Ciphertext) {
+			t.Errorf("%s: encryption mismatch", v.Description)
+		}
+		for i := 0; i < v.Iterations; i++ {
+			c.Decrypt(out, out, v.Tweak)
+		}
+		if !bytes.Equal(out, v.Plaintext) {
+			t.Errorf("%s: decryption mismatch", v.Description)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+/*
+	essiv.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"hash"
+)
+
+// ESSIV generates per-sector IVs with the "Encrypted Salt-Sector IV" scheme of Linux dm-crypt.
+// The IV of a sector is Enc(hash(key), sectorNumber), where the sector number is a 64-bit little-endian integer padded with zeros to a block.
+// Unlike a plain sector number, the IV is unpredictable without the key, which CBC requires.
+type ESSIV struct {
+	block cipher.Block // keyed with hash(key)
+}
+
+// NewESSIV creates an ESSIV generator for the data encryption key.
+// newCipher creates the block cipher (e.g. aes.NewCipher) keyed with the hash of key,
+// so the hash size must be a valid key size for the cipher (e.g. SHA-256 for AES).
+// The IV cipher should be the same algorithm as the data cipher, for the IV to be one block of the data cipher.
+func NewESSIV(newCipher func([]byte) (cipher.Block, error), key []byte, h func() hash.Hash) (*ESSIV, error) {
+	d := h()
+	d.Write(key)
+	salt := d.Sum(nil)
+	b, err := newCipher(salt)
+	for i := range salt {
+		salt[i] = 0
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ESSIV{block: b}, nil
+}
+
+// BlockSize returns the size of the IV.
+func (e *ESSIV) BlockSize() int {
+	return e.block.BlockSize()
+}
+
+// IV returns the IV for a sector.
+func (e *ESSIV) IV(sectorNum uint64) []byte {
+	iv := make([]byte, e.block.BlockSize())
+	e.PutIV(iv, sectorNum)
+	return iv
+}
+
+// PutIV writes the IV for a sector into iv, which must be one block long.
+func (e *ESSIV) PutIV(iv []byte, sectorNum uint64) {
+	for i := range iv {
+		iv[i] = 0
+	}
+	binary.LittleEndian.PutUint64(iv, sectorNum)
+	e.block.Encrypt(iv, iv)
+}
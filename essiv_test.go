@@ -0,0 +1,39 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestESSIV(t *testing.T) {
+
+	key := make([]byte, 0x20)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	essiv, err := cbccts.NewESSIV(aes.NewCipher, key, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// expected values are AES-256-ECB(SHA-256(key), le64(sector) || zeros), computed with OpenSSL
+	testCase := []struct {
+		sector uint64
+		expect string
+	}{
+		{0, "a73d5fb0e4041090ca6dc1b820cdaf51"},
+		{42, "f6159f0b8fab8469a3c307fc13c58a70"},
+		{0x0123456789abcdef, "4cbb28cd1e959d7dbe7bfad62f130a02"},
+	}
+	for i, c := range testCase {
+		expect, _ := hex.DecodeString(c.expect)
+		if iv := essiv.IV(c.sector); !bytes.Equal(iv, expect) {
+			t.Errorf("case %d: expected %x, got %x", i, expect, iv)
+		}
+	}
+}
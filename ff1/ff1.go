@@ -0,0 +1,287 @@
+/*
+	ff1.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package ff1 implements the FF1 format-preserving encryption mode (NIST SP 800-38G).
+
+	FF1 encrypts a string of numerals in a given radix into another string of the same length and radix,
+	e.g. a 16-digit card number into another 16-digit number.
+	It fills the gap below the one-block minimum of CBC-CTS for short numeric or alphanumeric fields.
+
+	FF1 is specified for AES only, though any 128-bit block cipher is accepted.
+*/
+package ff1
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+const (
+	blockSize = 16
+	rounds    = 10
+	minDomain = 1000000 // radix^minlen must be at least a million
+	maxRadix  = 1 << 16
+	maxLen    = math.MaxUint32
+)
+
+// Commonly used alphabets.
+const (
+	Digits       = "0123456789"
+	Alphanumeric = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// ErrInvalidLength is returned when a numeral string is too short or too long for the radix.
+var ErrInvalidLength = errors.New("ff1: invalid input length")
+
+// Cipher is an FF1 cipher for a fixed radix. It is safe for concurrent use.
+type Cipher struct {
+	block    cipher.Block
+	radix    int
+	minLen   int
+	alphabet []rune // optional; used by the string methods
+	index    map[rune]uint16
+}
+
+// NewCipher creates an FF1 cipher over numerals of the given radix (2 to 65536).
+func NewCipher(b cipher.Block, radix int) (*Cipher, error) {
+	if b.BlockSize() != blockSize {
+		return nil, fmt.Errorf("ff1: cipher does not have a block size of %d", blockSize)
+	}
+	if radix < 2 || radix > maxRadix {
+		return nil, fmt.Errorf("ff1: invalid radix %d", radix)
+	}
+	minLen := int(math.Ceil(math.Log(minDomain) / math.Log(float64(radix))))
+	if minLen < 2 {
+		minLen = 2
+	}
+	return &Cipher{
+		block:  b,
+		radix:  radix,
+		minLen: minLen,
+	}, nil
+}
+
+// NewStringCipher creates an FF1 cipher over strings of an alphabet, e.g. Digits.
+// The radix is the number of characters in the alphabet.
+func NewStringCipher(b cipher.Block, alphabet string) (*Cipher, error) {
+	runes := []rune(alphabet)
+	c, err := NewCipher(b, len(runes))
+	if err != nil {
+		return nil, err
+	}
+	c.alphabet = runes
+	c.index = make(map[rune]uint16, len(runes))
+	for i, r := range runes {
+		if _, dup := c.index[r]; dup {
+			return nil, fmt.Errorf("ff1: duplicate character %q in alphabet", r)
+		}
+		c.index[r] = uint16(i)
+	}
+	return c, nil
+}
+
+// Radix returns the radix of the cipher.
+func (c *Cipher) Radix() int {
+	return c.radix
+}
+
+// MinLen returns the minimum length of a numeral string.
+func (c *Cipher) MinLen() int {
+	return c.minLen
+}
+
+// Encrypt encrypts a string of characters of the alphabet given to NewStringCipher.
+func (c *Cipher) Encrypt(plaintext string, tweak []byte) (string, error) {
+	x, err := c.numerals(plaintext)
+	if err != nil {
+		return "", err
+	}
+	y, err := c.EncryptNumerals(x, tweak)
+	if err != nil {
+		return "", err
+	}
+	return c.str(y), nil
+}
+
+// Decrypt decrypts a string of characters of the alphabet given to NewStringCipher.
+func (c *Cipher) Decrypt(ciphertext string, tweak []byte) (string, error) {
+	x, err := c.numerals(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	y, err := c.DecryptNumerals(x, tweak)
+	if err != nil {
+		return "", err
+	}
+	return c.str(y), nil
+}
+
+// EncryptNumerals encrypts a numeral string. Each numeral must be less than the radix.
+func (c *Cipher) EncryptNumerals(x []uint16, tweak []byte) ([]uint16, error) {
+	return c.crypt(x, tweak, true)
+}
+
+// DecryptNumerals decrypts a numeral string. Each numeral must be less than the radix.
+func (c *Cipher) DecryptNumerals(x []uint16, tweak []byte) ([]uint16, error) {
+	return c.crypt(x, tweak, false)
+}
+
+// convert a string to numerals
+func (c *Cipher) numerals(s string) ([]uint16, error) {
+	if c.alphabet == nil {
+		panic(fmt.Errorf("ff1: no alphabet; use NewStringCipher"))
+	}
+	x := make([]uint16, 0, len(s))
+	for _, r := range s {
+		v, ok := c.index[r]
+		if !ok {
+			return nil, fmt.Errorf("ff1: character %q not in the alphabet", r)
+		}
+		x = append(x, v)
+	}
+	return x, nil
+}
+
+// convert numerals to a string
+func (c *Cipher) str(x []uint16) string {
+	var sb strings.Builder
+	for _, v := range x {
+		sb.WriteRune(c.alphabet[v])
+	}
+	return sb.String()
+}
+
+// FF1 algorithms 7 and 8 of SP 800-38G
+func (c *Cipher) crypt(x []uint16, tweak []byte, encrypt bool) ([]uint16, error) {
+	n := len(x)
+	if n < c.minLen || uint64(n) > maxLen {
+		return nil, ErrInvalidLength
+	}
+	for _, v := range x {
+		if int(v) >= c.radix {
+			return nil, fmt.Errorf("ff1: numeral %d out of radix %d", v, c.radix)
+		}
+	}
+	t := len(tweak)
+
+	u := n / 2
+	v := n - u
+	radix := big.NewInt(int64(c.radix))
+
+	// byte lengths of NUM(B) and of the round output
+	b := int(math.Ceil(math.Ceil(float64(v)*math.Log2(float64(c.radix))) / 8))
+	d := 4*((b+3)/4) + 4
+
+	// the fixed block P
+	P := make([]byte, blockSize)
+	P[0], P[1], P[2] = 1, 2, 1
+	P[3], P[4], P[5] = byte(c.radix>>16), byte(c.radix>>8), byte(c.radix)
+	P[6] = 10
+	P[7] = byte(u)
+	binary.BigEndian.PutUint32(P[8:12], uint32(n))
+	binary.BigEndian.PutUint32(P[12:16], uint32(t))
+
+	// Q = T || zero padding || round || NUM(B)
+	qlen := t + b + 1
+	qlen += (blockSize - qlen%blockSize) % blockSize
+	Q := make([]byte, qlen)
+	copy(Q, tweak)
+
+	modU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(radix, big.NewInt(int64(v)), nil)
+
+	A, B := num(x[:u], radix), num(x[u:], radix)
+	if !encrypt {
+		A, B = B, A
+	}
+	R := make([]byte, blockSize)
+	S := make([]byte, (d+blockSize-1)/blockSize*blockSize)
+	y := new(big.Int)
+
+	for k := 0; k < rounds; k++ {
+		i := k
+		if !encrypt {
+			i = rounds - 1 - k
+		}
+
+		// Q carries the half which is not modified in this round
+		Q[qlen-b-1] = byte(i)
+		nb := B.Bytes()
+		for j := qlen - b; j < qlen; j++ {
+			Q[j] = 0
+		}
+		copy(Q[qlen-len(nb):], nb)
+
+		// R = PRF(P || Q), i.e. CBC-MAC with a zero IV
+		copy(R, P)
+		c.block.Encrypt(R, R)
+		for j := 0; j < qlen; j += blockSize {
+			for l := 0; l < blockSize; l++ {
+				R[l] ^= Q[j+l]
+			}
+			c.block.Encrypt(R, R)
+		}
+
+		// S = R || CIPH(R xor [1]) || CIPH(R xor [2]) ...
+		copy(S, R)
+		for j := 1; j*blockSize < d; j++ {
+			blk := S[j*blockSize : (j+1)*blockSize]
+			copy(blk, R)
+			var ctr [8]byte
+			binary.BigEndian.PutUint64(ctr[:], uint64(j))
+			for l := 0; l < 8; l++ {
+				blk[blockSize-8+l] ^= ctr[l]
+			}
+			c.block.Encrypt(blk, blk)
+		}
+		y.SetBytes(S[:d])
+
+		m := modU
+		if i%2 == 1 {
+			m = modV
+		}
+		if encrypt {
+			A.Add(A, y)
+		} else {
+			A.Sub(A, y)
+		}
+		A.Mod(A, m)
+		A, B = B, A
+	}
+
+	if !encrypt {
+		A, B = B, A
+	}
+	out := make([]uint16, n)
+	str(out[:u], A, radix)
+	str(out[u:], B, radix)
+	return out, nil
+}
+
+// NUM_radix: the number represented by a numeral string, most significant numeral first
+func num(x []uint16, radix *big.Int) *big.Int {
+	r := new(big.Int)
+	for _, v := range x {
+		r.Mul(r, radix)
+		r.Add(r, big.NewInt(int64(v)))
+	}
+	return r
+}
+
+// STR_m_radix: fill out with the numerals of v
+func str(out []uint16, v *big.Int, radix *big.Int) {
+	v = new(big.Int).Set(v)
+	mod := new(big.Int)
+	for i := len(out) - 1; i >= 0; i-- {
+		v.DivMod(v, radix, mod)
+		out[i] = uint16(mod.Int64())
+	}
+}
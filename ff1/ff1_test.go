@@ -0,0 +1,93 @@
+package ff1_test
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/ff1"
+)
+
+func TestFF1(t *testing.T) {
+
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// NIST SP 800-38G FF1 samples
+	type testParam struct {
+		key, tweak string
+		alphabet   string
+		pt, ct     string
+	}
+	testCase := []testParam{
+		{"2b7e151628aed2a6abf7158809cf4f3c", "", ff1.Digits, "0123456789", "2433477484"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", "39383736353433323130", ff1.Digits, "0123456789", "6124200773"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", "3737373770717273373737", ff1.Alphanumeric, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{"2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f", "", ff1.Digits, "0123456789", "2830668132"},
+		{"2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f7f036d6f04fc6a94", "", ff1.Digits, "0123456789", "6657667009"},
+	}
+
+	for i, c := range testCase {
+		b, err := aes.NewCipher(unhex(c.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fc, err := ff1.NewStringCipher(b, c.alphabet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, err := fc.Encrypt(c.pt, unhex(c.tweak))
+		if err != nil || ct != c.ct {
+			t.Errorf("encrypt failed: case %d, expected %s, got %s, %v", i, c.ct, ct, err)
+		}
+		pt, err := fc.Decrypt(c.ct, unhex(c.tweak))
+		if err != nil || pt != c.pt {
+			t.Errorf("decrypt failed: case %d, expected %s, got %s, %v", i, c.pt, pt, err)
+		}
+	}
+
+	// round trip over lengths and radixes
+	b, err := aes.NewCipher(unhex("000102030405060708090a0b0c0d0e0f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, radix := range []int{2, 10, 26, 256, 65536} {
+		fc, err := ff1.NewCipher(b, radix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := fc.MinLen(); n < fc.MinLen()+40; n++ {
+			x := make([]uint16, n)
+			for i := range x {
+				x[i] = uint16((i * 7919) % radix)
+			}
+			y, err := fc.EncryptNumerals(x, []byte("tweak"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			z, err := fc.DecryptNumerals(y, []byte("tweak"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range x {
+				if x[i] != z[i] {
+					t.Fatalf("round trip failed: radix %d, length %d", radix, n)
+				}
+			}
+		}
+		if _, err := fc.EncryptNumerals(make([]uint16, fc.MinLen()-1), nil); err != ff1.ErrInvalidLength {
+			t.Errorf("short input accepted: radix %d", radix)
+		}
+	}
+
+	// characters out of the alphabet are rejected
+	fc, _ := ff1.NewStringCipher(b, ff1.Digits)
+	if _, err := fc.Encrypt("12345a7890", nil); err == nil {
+		t.Errorf("invalid character accepted")
+	}
+}
@@ -0,0 +1,43 @@
+/*
+	format.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseFormat parses the name of a ciphertext format, "cs1", "cs2" or "cs3" in any case.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToUpper(s) {
+	case "CS1":
+		return CS1, nil
+	case "CS2":
+		return CS2, nil
+	case "CS3":
+		return CS3, nil
+	}
+	return 0, fmt.Errorf("cbccts: unknown format %q; must be cs1, cs2 or cs3", s)
+}
+
+// MarshalText implements encoding.TextMarshaler, so that a Format is written to JSON and other configuration formats
+// as "cs1", "cs2" or "cs3".
+func (f Format) MarshalText() ([]byte, error) {
+	if f < CS1 || f > CS3 {
+		return nil, fmt.Errorf("cbccts: invalid format %d", int(f))
+	}
+	return []byte(fmt.Sprintf("cs%d", int(f))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the names of ParseFormat.
+func (f *Format) UnmarshalText(text []byte) error {
+	p, err := ParseFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = p
+	return nil
+}
@@ -0,0 +1,62 @@
+package cbccts_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestParseFormat(t *testing.T) {
+
+	for s, want := range map[string]cbccts.Format{"cs1": cbccts.CS1, "CS2": cbccts.CS2, "Cs3": cbccts.CS3} {
+		if f, err := cbccts.ParseFormat(s); err != nil || f != want {
+			t.Errorf("%q: got %d, %v", s, f, err)
+		}
+	}
+	for _, s := range []string{"", "cs0", "cs4", "3", "cs3 "} {
+		if _, err := cbccts.ParseFormat(s); err == nil {
+			t.Errorf("%q accepted", s)
+		}
+	}
+}
+
+func TestFormatText(t *testing.T) {
+
+	type config struct {
+		Format cbccts.Format `json:"format"`
+	}
+	for _, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		b, err := json.Marshal(config{f})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf(`{"format":"cs%d"}`, f); string(b) != want {
+			t.Errorf("CS%d: %s, expected %s", f, b, want)
+		}
+		var c config
+		if err := json.Unmarshal(b, &c); err != nil || c.Format != f {
+			t.Errorf("CS%d: unmarshaled %d, %v", f, c.Format, err)
+		}
+	}
+
+	var c config
+	if err := json.Unmarshal([]byte(`{"format":"CS3"}`), &c); err != nil || c.Format != cbccts.CS3 {
+		t.Errorf("upper case: %d, %v", c.Format, err)
+	}
+	for _, s := range []string{`{"format":"cs4"}`, `{"format":""}`, `{"format":3}`} {
+		if err := json.Unmarshal([]byte(s), &c); err == nil {
+			t.Errorf("%s accepted", s)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"format":"cs9"}`), &c); err == nil || !strings.Contains(err.Error(), "cs1, cs2 or cs3") {
+		t.Errorf("undescriptive error: %v", err)
+	}
+	for _, f := range []cbccts.Format{0, 4} {
+		if _, err := json.Marshal(config{f}); err == nil {
+			t.Errorf("format %d marshaled", f)
+		}
+	}
+}
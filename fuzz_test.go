@@ -0,0 +1,150 @@
+//go:build go1.18
+// +build go1.18
+
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/twofish"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/ctstest"
+)
+
+// the block ciphers of the fuzz targets, with their key sizes
+var fuzzCiphers = []struct {
+	newCipher func([]byte) (cipher.Block, error)
+	keySizes  []int
+}{
+	{aes.NewCipher, []int{16, 24, 32}},
+	{des.NewCipher, []int{8}},
+	{des.NewTripleDESCipher, []int{24}},
+	{func(k []byte) (cipher.Block, error) { return blowfish.NewCipher(k) }, []int{4, 16, 56}},
+	{func(k []byte) (cipher.Block, error) { return twofish.NewCipher(k) }, []int{16, 24, 32}},
+}
+
+// a cipher and an IV chosen by the fuzzer; the key and the IV are derived from seed
+func fuzzCipher(t *testing.T, cipherSel, keySel uint8, seed []byte) (cipher.Block, []byte) {
+	c := fuzzCiphers[int(cipherSel)%len(fuzzCiphers)]
+	k := sha512.Sum512(seed)
+	b, err := c.newCipher(k[:c.keySizes[int(keySel)%len(c.keySizes)]])
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := sha256.Sum256(seed)
+	return b, iv[:b.BlockSize()]
+}
+
+// seed corpora at the boundary lengths of both block sizes
+func fuzzSeeds(f *testing.F, add func(cipherSel, keySel uint8, msg []byte)) {
+	for i := range fuzzCiphers {
+		for _, n := range []int{8, 9, 15, 16, 17, 23, 24, 31, 32, 33, 47, 48, 49, 100} {
+			msg := make([]byte, n)
+			for j := range msg {
+				msg[j] = byte(j * 31)
+			}
+			add(uint8(i), uint8(n), msg)
+		}
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	fuzzSeeds(f, func(cipherSel, keySel uint8, msg []byte) {
+		for _, format := range []uint8{1, 2, 3} {
+			f.Add(cipherSel, keySel, format, msg)
+		}
+	})
+	f.Fuzz(func(t *testing.T, cipherSel, keySel, format uint8, msg []byte) {
+		b, iv := fuzzCipher(t, cipherSel, keySel, msg)
+		if len(msg) < b.BlockSize() {
+			t.Skip()
+		}
+		mode := cbccts.Format(format%3 + 1)
+		ct := make([]byte, len(msg))
+		cbccts.NewCBCCTSEncrypter(b, iv, mode).CryptBlocks(ct, msg)
+		pt := make([]byte, len(msg))
+		cbccts.NewCBCCTSDecrypter(b, iv, mode).CryptBlocks(pt, ct)
+		if !bytes.Equal(pt, msg) {
+			t.Fatalf("block size %d, CS%d, %d bytes: round trip failed", b.BlockSize(), mode, len(msg))
+		}
+	})
+}
+
+// CS1 and CS2 agree on aligned data, CS2 and CS3 on unaligned data, and CS1 and CS3 differ in the order of the last two blocks
+func FuzzCrossFormat(f *testing.F) {
+	fuzzSeeds(f, func(cipherSel, keySel uint8, msg []byte) {
+		f.Add(cipherSel, keySel, msg)
+	})
+	f.Fuzz(func(t *testing.T, cipherSel, keySel uint8, msg []byte) {
+		b, iv := fuzzCipher(t, cipherSel, keySel, msg)
+		bs, n := b.BlockSize(), len(msg)
+		if n < bs {
+			t.Skip()
+		}
+		var ct [4][]byte
+		for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS3} {
+			ct[mode] = make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(b, iv, mode).CryptBlocks(ct[mode], msg)
+		}
+		check := ctstest.CS2EqualsCS3
+		if n%bs == 0 {
+			check = ctstest.CS2EqualsCS1
+		}
+		if err := check(b, iv, msg); err != nil {
+			t.Fatal(err)
+		}
+
+		// CS3 puts the last full block before the partial one, or swaps the last two blocks of aligned data
+		tail := n % bs
+		if tail == 0 {
+			tail = bs
+		}
+		if n > bs {
+			cs1, cs3 := ct[cbccts.CS1], ct[cbccts.CS3]
+			full := n - tail - bs
+			swapped := append(append(append([]byte(nil), cs1[:full]...), cs1[full+tail:]...), cs1[full:full+tail]...)
+			if !bytes.Equal(swapped, cs3) {
+				t.Fatalf("block size %d, %d bytes: CS1 and CS3 differ other than in the order of the last two blocks", bs, n)
+			}
+		} else if !bytes.Equal(ct[cbccts.CS1], ct[cbccts.CS3]) {
+			t.Fatalf("block size %d: a single block differs between CS1 and CS3", bs)
+		}
+	})
+}
+
+// CS1 and CS2 on aligned data are plain CBC, as cipher.NewCBCEncrypter and cipher.NewCBCDecrypter
+func FuzzCBC(f *testing.F) {
+	fuzzSeeds(f, func(cipherSel, keySel uint8, msg []byte) {
+		f.Add(cipherSel, keySel, msg)
+	})
+	f.Fuzz(func(t *testing.T, cipherSel, keySel uint8, msg []byte) {
+		b, iv := fuzzCipher(t, cipherSel, keySel, msg)
+		bs := b.BlockSize()
+		msg = msg[:len(msg)/bs*bs]
+		if len(msg) == 0 {
+			t.Skip()
+		}
+		expect := make([]byte, len(msg))
+		cipher.NewCBCEncrypter(b, iv).CryptBlocks(expect, msg)
+		for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2} {
+			ct := make([]byte, len(msg))
+			cbccts.NewCBCCTSEncrypter(b, iv, mode).CryptBlocks(ct, msg)
+			if !bytes.Equal(ct, expect) {
+				t.Fatalf("block size %d, CS%d, %d bytes: differs from CBC", bs, mode, len(msg))
+			}
+			pt := make([]byte, len(msg))
+			cbccts.NewCBCCTSDecrypter(b, iv, mode).CryptBlocks(pt, expect)
+			if !bytes.Equal(pt, msg) {
+				t.Fatalf("block size %d, CS%d, %d bytes: does not decrypt CBC", bs, mode, len(msg))
+			}
+		}
+	})
+}
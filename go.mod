@@ -1,3 +1,8 @@
 module github.com/mixcode/golib-cbccts
 
 go 1.16
+
+require (
+	github.com/klauspost/compress v1.15.15
+	golang.org/x/crypto v0.6.0
+)
@@ -0,0 +1,136 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/blowfish"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/internal/ref"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+var updateGolden = flag.Bool("update", false, "regenerate testdata/golden.json; the ciphertexts are checked against the reference implementation")
+
+const goldenFile = "golden.json"
+
+// an entry of the golden corpus
+type goldenVector struct {
+	Cipher     string           `json:"cipher"`
+	Format     string           `json:"format"`
+	Key        vectors.HexBytes `json:"key"`
+	IV         vectors.HexBytes `json:"iv"`
+	Plaintext  vectors.HexBytes `json:"plaintext"`
+	Ciphertext vectors.HexBytes `json:"ciphertext"`
+}
+
+// the ciphers of the golden corpus, with their key sizes
+var goldenCiphers = map[string]struct {
+	newCipher func([]byte) (cipher.Block, error)
+	keySizes  []int
+}{
+	"AES":      {aes.NewCipher, []int{16, 24, 32}},
+	"DES":      {des.NewCipher, []int{8}},
+	"3DES":     {des.NewTripleDESCipher, []int{24}},
+	"Blowfish": {func(k []byte) (cipher.Block, error) { return blowfish.NewCipher(k) }, []int{16}},
+
+	// the large-block test ciphers of TestLargeBlock
+	"Lane256":  {func(k []byte) (cipher.Block, error) { return newLaneBlock(k, 32) }, []int{16}},
+	"Lane512":  {func(k []byte) (cipher.Block, error) { return newLaneBlock(k, 64) }, []int{16}},
+	"Lane1024": {func(k []byte) (cipher.Block, error) { return newLaneBlock(k, 128) }, []int{16}},
+}
+
+// the output of the codec must never change; a change of the tail handling that alters a byte fails here
+func TestGolden(t *testing.T) {
+
+	path := filepath.Join("testdata", goldenFile)
+	if *updateGolden {
+		writeGoldenCorpus(t, path)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var corpus []goldenVector
+	if err := json.Unmarshal(b, &corpus); err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for i, v := range corpus {
+		c, ok := goldenCiphers[v.Cipher]
+		if !ok {
+			t.Fatalf("vector %d: unknown cipher %q", i, v.Cipher)
+		}
+		seen[v.Cipher] = true
+		f, err := cbccts.ParseFormat(v.Format)
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		blk, err := c.newCipher(v.Key)
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		out := make([]byte, len(v.Plaintext))
+		cbccts.NewCBCCTSEncrypter(blk, v.IV, f).CryptBlocks(out, v.Plaintext)
+		if !bytes.Equal(out, v.Ciphertext) {
+			t.Errorf("vector %d: %s %s, %d bytes: ciphertext changed", i, v.Cipher, v.Format, len(v.Plaintext))
+		}
+		cbccts.NewCBCCTSDecrypter(blk, v.IV, f).CryptBlocks(out, v.Ciphertext)
+		if !bytes.Equal(out, v.Plaintext) {
+			t.Errorf("vector %d: %s %s, %d bytes: plaintext changed", i, v.Cipher, v.Format, len(v.Plaintext))
+		}
+	}
+	if len(seen) != len(goldenCiphers) {
+		t.Errorf("the corpus covers %d of %d ciphers", len(seen), len(goldenCiphers))
+	}
+}
+
+// generate the corpus: lengths around one to three blocks of each cipher, in every format and key size
+func writeGoldenCorpus(t *testing.T, path string) {
+	rnd := rand.New(rand.NewSource(403))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rnd.Read(b)
+		return b
+	}
+	var corpus []goldenVector
+	for _, name := range []string{"AES", "DES", "3DES", "Blowfish", "Lane256", "Lane512", "Lane1024"} {
+		c := goldenCiphers[name]
+		for _, ks := range c.keySizes {
+			key := random(ks)
+			blk, err := c.newCipher(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bs := blk.BlockSize()
+			for _, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+				for _, n := range []int{bs, bs + 1, 2*bs - 1, 2 * bs, 2*bs + 1, 3*bs - 1, 3 * bs, 3*bs + 1} {
+					iv, pt := random(bs), random(n)
+					ct := make([]byte, n)
+					cbccts.NewCBCCTSEncrypter(blk, iv, f).CryptBlocks(ct, pt)
+					if !bytes.Equal(ct, ref.Encrypt(blk, iv, f, pt)) {
+						t.Fatalf("%s CS%d, %d bytes: the codec differs from the reference", name, f, n)
+					}
+					corpus = append(corpus, goldenVector{name, fmt.Sprintf("CS%d", f), key, iv, pt, ct})
+				}
+			}
+		}
+	}
+	b, err := json.MarshalIndent(corpus, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+	hctr2.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package hctr2 implements the HCTR2 length-preserving tweakable encryption mode
+	(Crowley, Huckleberry and Biggers, "Length-preserving encryption with HCTR2", 2021).
+
+	HCTR2 is used by Linux fscrypt for filename encryption. Like CBC-CTS, it handles messages of any length
+	from one block, but as a wide-block mode every bit of the ciphertext depends on every bit of the plaintext and the tweak.
+*/
+package hctr2
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+)
+
+const blockSize = 16
+
+// Cipher is an HCTR2 cipher. It is safe for concurrent use.
+type Cipher struct {
+	block cipher.Block
+	h     [blockSize]byte // the hash key, E(0)
+	l     [blockSize]byte // E(1)
+}
+
+// New creates an HCTR2 cipher with a 128-bit block cipher, normally AES.
+func New(b cipher.Block) *Cipher {
+	if b.BlockSize() != blockSize {
+		panic(fmt.Errorf("hctr2: cipher does not have a block size of %d", blockSize))
+	}
+	c := &Cipher{block: b}
+	b.Encrypt(c.h[:], c.h[:])
+	c.l[0] = 1
+	b.Encrypt(c.l[:], c.l[:])
+	return c
+}
+
+// Encrypt encrypts src with a tweak of any length into dst.
+// src must be at least one block long. dst and src must overlap entirely or not at all.
+func (c *Cipher) Encrypt(dst, src, tweak []byte) {
+	c.crypt(dst, src, tweak, true)
+}
+
+// Decrypt decrypts src with a tweak of any length into dst.
+// src must be at least one block long. dst and src must overlap entirely or not at all.
+func (c *Cipher) Decrypt(dst, src, tweak []byte) {
+	c.crypt(dst, src, tweak, false)
+}
+
+// EncryptSector encrypts a sector, with the sector number as a 128-bit little-endian tweak.
+func (c *Cipher) EncryptSector(dst, src []byte, sectorNum uint64) {
+	var tweak [blockSize]byte
+	binary.LittleEndian.PutUint64(tweak[:], sectorNum)
+	c.Encrypt(dst, src, tweak[:])
+}
+
+// DecryptSector decrypts a sector, with the sector number as a 128-bit little-endian tweak.
+func (c *Cipher) DecryptSector(dst, src []byte, sectorNum uint64) {
+	var tweak [blockSize]byte
+	binary.LittleEndian.PutUint64(tweak[:], sectorNum)
+	c.Decrypt(dst, src, tweak[:])
+}
+
+func (c *Cipher) crypt(dst, src, tweak []byte, encrypt bool) {
+	textlen := len(src)
+	if textlen < blockSize {
+		panic(fmt.Errorf("hctr2: data size too small; must be larger than one block"))
+	}
+	if len(dst) < textlen {
+		panic(fmt.Errorf("hctr2: output smaller than input"))
+	}
+
+	// the first block goes through the block cipher, the rest through XCTR
+	var m, mm, uu, s [blockSize]byte
+	copy(m[:], src[:blockSize])
+	n, v := src[blockSize:], dst[blockSize:textlen]
+
+	// MM = M xor H(T, N)
+	c.hash(&mm, tweak, n)
+	xor(mm[:], mm[:], m[:])
+
+	// UU = E(MM)
+	if encrypt {
+		c.block.Encrypt(uu[:], mm[:])
+	} else {
+		c.block.Decrypt(uu[:], mm[:])
+	}
+
+	// S = MM xor UU xor L, and V = N xor XCTR(S)
+	xor(s[:], mm[:], uu[:])
+	xor(s[:], s[:], c.l[:])
+	c.xctr(v, n, &s)
+
+	// U = UU xor H(T, V)
+	var u [blockSize]byte
+	c.hash(&u, tweak, v)
+	xor(dst[:blockSize], u[:], uu[:])
+}
+
+// the hash H(T, M) = POLYVAL(h, len block || pad(T) || pad(M))
+func (c *Cipher) hash(out *[blockSize]byte, tweak, msg []byte) {
+	p := newPolyval(c.h[:])
+
+	var blk [blockSize]byte
+	lenbits := uint64(len(tweak))*8*2 + 2
+	if len(msg)%blockSize != 0 {
+		lenbits++
+	}
+	binary.LittleEndian.PutUint64(blk[:8], lenbits)
+	p.update(blk[:])
+
+	// the tweak is padded with zeros
+	full := len(tweak) / blockSize * blockSize
+	p.update(tweak[:full])
+	if full < len(tweak) {
+		blk = [blockSize]byte{}
+		copy(blk[:], tweak[full:])
+		p.update(blk[:])
+	}
+
+	// the message is padded with a one and zeros
+	full = len(msg) / blockSize * blockSize
+	p.update(msg[:full])
+	if full < len(msg) {
+		blk = [blockSize]byte{}
+		k := copy(blk[:], msg[full:])
+		blk[k] = 1
+		p.update(blk[:])
+	}
+	p.s.store(out[:])
+}
+
+// XCTR mode: the i-th keystream block is E(S xor i), with i starting from 1 as a little-endian integer.
+// dst and src may overlap entirely.
+func (c *Cipher) xctr(dst, src []byte, s *[blockSize]byte) {
+	var ks [blockSize]byte
+	for i := 0; len(src) > 0; i++ {
+		ks = *s
+		var ctr [8]byte
+		binary.LittleEndian.PutUint64(ctr[:], uint64(i+1))
+		xor(ks[:8], ks[:8], ctr[:])
+		c.block.Encrypt(ks[:], ks[:])
+		k := len(src)
+		if k > blockSize {
+			k = blockSize
+		}
+		xor(dst[:k], src[:k], ks[:k])
+		dst, src = dst[k:], src[k:]
+	}
+}
+
+func xor(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
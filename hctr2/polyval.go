@@ -0,0 +1,84 @@
+/*
+	polyval.go
+	2026-10, github.com/mixcode
+*/
+
+package hctr2
+
+import (
+	"encoding/binary"
+)
+
+// fieldElement is an element of GF(2^128) in the POLYVAL representation (RFC 8452):
+// the bits of a little-endian 128-bit integer are the coefficients, lowest first.
+type fieldElement struct {
+	lo, hi uint64
+}
+
+func loadElement(b []byte) fieldElement {
+	return fieldElement{binary.LittleEndian.Uint64(b[:8]), binary.LittleEndian.Uint64(b[8:16])}
+}
+
+func (e fieldElement) store(b []byte) {
+	binary.LittleEndian.PutUint64(b[:8], e.lo)
+	binary.LittleEndian.PutUint64(b[8:16], e.hi)
+}
+
+// mul returns a*b mod x^128 + x^127 + x^126 + x^121 + 1
+func mul(a, b fieldElement) fieldElement {
+	var r fieldElement
+	for i := 127; i >= 0; i-- {
+		// r = r*x
+		carry := r.hi >> 63
+		r.hi = r.hi<<1 | r.lo>>63
+		r.lo <<= 1
+		mask := -carry
+		r.hi ^= mask & (1<<63 | 1<<62 | 1<<57)
+		r.lo ^= mask & 1
+
+		// r += a if bit i of b is set
+		var bit uint64
+		if i >= 64 {
+			bit = b.hi >> uint(i-64) & 1
+		} else {
+			bit = b.lo >> uint(i) & 1
+		}
+		mask = -bit
+		r.lo ^= mask & a.lo
+		r.hi ^= mask & a.hi
+	}
+	return r
+}
+
+// divX returns a*x^-1
+func divX(a fieldElement) fieldElement {
+	mask := -(a.lo & 1)
+	a.lo = a.lo>>1 | a.hi<<63
+	a.hi >>= 1
+	a.hi ^= mask & (1<<63 | 1<<62 | 1<<61 | 1<<56)
+	return a
+}
+
+// polyval is the POLYVAL universal hash of RFC 8452
+type polyval struct {
+	h fieldElement // the hash key, pre-multiplied by x^-128
+	s fieldElement
+}
+
+func newPolyval(key []byte) *polyval {
+	h := loadElement(key)
+	for i := 0; i < 128; i++ {
+		h = divX(h)
+	}
+	return &polyval{h: h}
+}
+
+// update hashes whole blocks
+func (p *polyval) update(b []byte) {
+	for ; len(b) >= 16; b = b[16:] {
+		x := loadElement(b)
+		p.s.lo ^= x.lo
+		p.s.hi ^= x.hi
+		p.s = mul(p.s, p.h)
+	}
+}
@@ -0,0 +1,25 @@
+package hctr2
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestPolyval(t *testing.T) {
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// RFC 8452 appendix A
+	p := newPolyval(unhex("25629347589242761d31f826ba4b757b"))
+	p.update(unhex("4f4f95668c83dfb6401762bb2d01a262" + "d1a24ddd2721d006bbe45f20d3c9f362"))
+	got := make([]byte, 16)
+	p.s.store(got)
+	if expect := "f7a3b47b846119fae5b7866cf5e5b77e"; hex.EncodeToString(got) != expect {
+		t.Errorf("expected %s, got %x", expect, got)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+	image.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// bytes of an image processed by a worker at once, in whole sectors
+const imageSegmentSize = 4 << 20
+
+// EncryptImage encrypts a raw disk image sector by sector, writing the ciphertext to dst at the same offsets,
+// with segments of whole sectors encrypted concurrently on up to workers goroutines; 0 means runtime.GOMAXPROCS(0).
+// Each sector is encrypted with the IV of its number, from 0 at the start of the image, so that any sector can be
+// decrypted on its own, as with SectorFile, whose encryption of a final sector shorter than a block is also used here.
+// dst may be src, to encrypt the image in place; dst is truncated to the size of src.
+func (s *SectorCipher) EncryptImage(dst, src *os.File, sectorSize, workers int) error {
+	return s.cryptImage(dst, src, sectorSize, workers, true)
+}
+
+// DecryptImage decrypts a disk image encrypted by EncryptImage, or written through a SectorFile with the same sector size.
+func (s *SectorCipher) DecryptImage(dst, src *os.File, sectorSize, workers int) error {
+	return s.cryptImage(dst, src, sectorSize, workers, false)
+}
+
+func (s *SectorCipher) cryptImage(dst, src *os.File, sectorSize, workers int, encrypt bool) error {
+	if sectorSize < s.block.BlockSize() {
+		return fmt.Errorf("sector size too small; must be at least one block")
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	size := fi.Size()
+	per := int64(imageSegmentSize / sectorSize * sectorSize)
+	if per == 0 {
+		per = int64(sectorSize)
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	segs := make(chan int64)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, per)
+			defer zero(buf)
+			for off := range segs {
+				b := buf
+				if size-off < per {
+					b = b[:size-off]
+				}
+				if n, err := src.ReadAt(b, off); n < len(b) {
+					if err == nil || err == io.EOF {
+						err = io.ErrUnexpectedEOF
+					}
+					fail(err)
+					continue
+				}
+				for p := 0; p < len(b); p += sectorSize {
+					end := p + sectorSize
+					if end > len(b) {
+						end = len(b)
+					}
+					s.crypt(b[p:end], uint64((off+int64(p))/int64(sectorSize)), encrypt)
+				}
+				if _, err := dst.WriteAt(b, off); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+	for off := int64(0); off < size && !failed(); off += per {
+		segs <- off
+	}
+	close(segs)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return dst.Truncate(size)
+}
@@ -0,0 +1,91 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestImage(t *testing.T) {
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	ac, _ := aes.NewCipher(key)
+	essiv, _ := cbccts.NewESSIV(aes.NewCipher, key, sha256.New)
+	sc := cbccts.NewSectorCipher(ac, essiv, cbccts.CS3)
+	dir := t.TempDir()
+
+	for _, c := range []struct{ size, sectorSize int }{
+		{9<<20 + 512*3 + 10, 512}, // a final sector shorter than a block
+		{1 << 20, 4096},
+		{100000, 1000},
+	} {
+		img := make([]byte, c.size)
+		rand.Read(img)
+		src, dst, out := filepath.Join(dir, "src"), filepath.Join(dir, "dst"), filepath.Join(dir, "out")
+		os.WriteFile(src, img, 0600)
+		os.WriteFile(dst, make([]byte, c.size+5000), 0600) // truncated to the image
+		os.WriteFile(out, nil, 0600)
+
+		fs, _ := os.Open(src)
+		fd, _ := os.OpenFile(dst, os.O_RDWR, 0)
+		if err := sc.EncryptImage(fd, fs, c.sectorSize, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		// the sectors are those of the SectorCipher, and of a SectorFile
+		ct, _ := os.ReadFile(dst)
+		if len(ct) != c.size {
+			t.Fatalf("%d bytes of ciphertext for %d", len(ct), c.size)
+		}
+		for _, num := range []int{0, 1, c.size/c.sectorSize - 1} {
+			expect := make([]byte, c.sectorSize)
+			sc.EncryptSector(expect, img[num*c.sectorSize:(num+1)*c.sectorSize], uint64(num))
+			if !bytes.Equal(expect, ct[num*c.sectorSize:(num+1)*c.sectorSize]) {
+				t.Errorf("%d bytes, sector %d differs", c.size, num)
+			}
+		}
+		if tail := c.size % c.sectorSize; tail > 0 && tail < aes.BlockSize {
+			// the short tail is not revealed by the first block of its sector zero-filled
+			num := c.size / c.sectorSize
+			zeros := make([]byte, c.sectorSize)
+			sc.EncryptSector(zeros, zeros, uint64(num))
+			leak := make([]byte, tail)
+			for i := range leak {
+				leak[i] = ct[num*c.sectorSize+i] ^ zeros[i]
+			}
+			if bytes.Equal(leak, img[num*c.sectorSize:]) {
+				t.Errorf("%d bytes: the short tail is revealed by a zero-filled sector", c.size)
+			}
+		}
+		if b, err := io.ReadAll(cbccts.NewSectorFile(fd, int64(c.size), sc, c.sectorSize)); err != nil || !bytes.Equal(b, img) {
+			t.Errorf("%d bytes: not readable by a SectorFile: %v", c.size, err)
+		}
+
+		fo, _ := os.OpenFile(out, os.O_RDWR, 0)
+		if err := sc.DecryptImage(fo, fd, c.sectorSize, 0); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(out); !bytes.Equal(b, img) {
+			t.Errorf("%d bytes: decryption failed", c.size)
+		}
+
+		// in place
+		if err := sc.DecryptImage(fd, fd, c.sectorSize, 2); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(dst); !bytes.Equal(b, img) {
+			t.Errorf("%d bytes: in-place decryption failed", c.size)
+		}
+		fs.Close()
+		fd.Close()
+		fo.Close()
+	}
+}
@@ -0,0 +1,51 @@
+/*
+	indicator.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"reflect"
+	"strings"
+)
+
+// ApprovedBlock is an optional interface of a cipher.Block that declares whether it is an approved algorithm,
+// such as a cipher of a validated HSM. It overrides the detection of the AES of the standard library.
+type ApprovedBlock interface {
+	Approved() bool
+}
+
+// WithServiceIndicator makes a codec made by this package call indicator after each CryptBlocks, and returns the codec.
+// approved reports whether the operation used an approved algorithm and parameter set: AES with a 128, 192 or 256-bit key,
+// in any of the CS1, CS2 and CS3 formats of the Addendum to NIST SP 800-38A.
+// Other block ciphers, such as DES and triple DES, are not approved. Modules under FIPS validation may implement their
+// service indicator with this; the indicator is called on the goroutine of the operation.
+func WithServiceIndicator(m cipher.BlockMode, indicator func(approved bool)) cipher.BlockMode {
+	cd := codecOf(m)
+	cd.indicator = indicator
+	cd.approved = approvedBlock(cd.block)
+	return m
+}
+
+// Approved returns whether the codec uses an approved algorithm and parameter set, as reported by the service indicator.
+func (cd *cbccts) Approved() bool {
+	return approvedBlock(cd.block)
+}
+
+// AES of the standard library; its implementation lives in crypto/aes, or crypto/internal/fips140/aes since Go 1.24
+func approvedBlock(b cipher.Block) bool {
+	if a, ok := b.(ApprovedBlock); ok {
+		return a.Approved()
+	}
+	if b == nil || b.BlockSize() != 16 {
+		return false
+	}
+	t := reflect.TypeOf(b)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pkg := t.PkgPath()
+	return pkg == "crypto/aes" || strings.HasPrefix(pkg, "crypto/internal/") && strings.HasSuffix(pkg, "/aes")
+}
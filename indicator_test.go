@@ -0,0 +1,49 @@
+package cbccts_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// a cipher declaring itself approved, as an HSM adapter would
+type approvedBlock struct {
+	cipher.Block
+}
+
+func (approvedBlock) Approved() bool { return true }
+
+func TestServiceIndicator(t *testing.T) {
+
+	aesCipher, _ := aes.NewCipher(make([]byte, 24))
+	desCipher, _ := des.NewTripleDESCipher(make([]byte, 24))
+	for _, c := range []struct {
+		b        cipher.Block
+		approved bool
+	}{
+		{aesCipher, true},
+		{desCipher, false},
+		{&wideBlock{}, false},
+		{approvedBlock{desCipher}, true},
+	} {
+		bs := c.b.BlockSize()
+		calls := 0
+		var got bool
+		m := cbccts.WithServiceIndicator(cbccts.NewCBCCTSEncrypter(c.b, make([]byte, bs), cbccts.CS3), func(approved bool) {
+			calls++
+			got = approved
+		})
+		data := make([]byte, bs+3)
+		m.CryptBlocks(data, data)
+		m.CryptBlocks(data, data)
+		if calls != 2 || got != c.approved {
+			t.Errorf("%T: %d calls, approved %v", c.b, calls, got)
+		}
+		if m.(*cbccts.Encrypter).Approved() != c.approved {
+			t.Errorf("%T: Approved() is not %v", c.b, c.approved)
+		}
+	}
+}
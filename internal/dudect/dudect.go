@@ -0,0 +1,84 @@
+/*
+	dudect.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package dudect is a timing leak detector after dudect (Reparaz, Balasch and Verbauwhede, "Dude, is my code constant time?", 2017).
+
+	An operation is timed many times on inputs of two classes, chosen at random for each measurement, e.g. a fixed input against random inputs.
+	The two timing distributions are compared with Welch's t-test, after cropping the slow outliers caused by interrupts and scheduling.
+	A |t| above Threshold is a statistically significant difference: a timing leak of what distinguishes the classes.
+*/
+package dudect
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Threshold is the |t| above which a leak is reported, as dudect does.
+const Threshold = 4.5
+
+// Result is the outcome of a test.
+type Result struct {
+	T     float64 // Welch's t statistic of the class timings
+	N     [2]int  // number of measurements of each class, after cropping
+	Means [2]time.Duration
+}
+
+// Leaks returns whether the result shows a significant timing difference between the classes.
+func (r Result) Leaks() bool {
+	return math.Abs(r.T) > Threshold
+}
+
+// Run times op n times, each time on a class chosen at random; prepare is called untimed before each measurement
+// to set up the input of the class. Each measurement times op repeated reps times, to exceed the timer resolution.
+// Measurements slower than the crop percentile of all (e.g. 0.9) are dropped.
+func Run(n, reps int, crop float64, prepare func(class int), op func()) Result {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	classes := make([]int, n)
+	times := make([]float64, n)
+	for i := range times {
+		c := rnd.Intn(2)
+		prepare(c)
+		start := time.Now()
+		for j := 0; j < reps; j++ {
+			op()
+		}
+		classes[i], times[i] = c, float64(time.Since(start))/float64(reps)
+	}
+
+	sorted := append([]float64(nil), times...)
+	sort.Float64s(sorted)
+	limit := sorted[int(crop*float64(n-1))]
+
+	var count [2]float64
+	var mean, m2 [2]float64 // Welford's running mean and sum of squared deviations
+	for i, x := range times {
+		if x > limit {
+			continue
+		}
+		c := classes[i]
+		count[c]++
+		d := x - mean[c]
+		mean[c] += d / count[c]
+		m2[c] += d * (x - mean[c])
+	}
+
+	r := Result{
+		N:     [2]int{int(count[0]), int(count[1])},
+		Means: [2]time.Duration{time.Duration(mean[0]), time.Duration(mean[1])},
+	}
+	if count[0] < 2 || count[1] < 2 {
+		return r
+	}
+	v0, v1 := m2[0]/(count[0]-1)/count[0], m2[1]/(count[1]-1)/count[1]
+	if v0+v1 == 0 {
+		return r
+	}
+	r.T = (mean[0] - mean[1]) / math.Sqrt(v0+v1)
+	return r
+}
@@ -0,0 +1,38 @@
+package dudect_test
+
+import (
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/internal/dudect"
+)
+
+func TestRun(t *testing.T) {
+
+	// an operation that takes longer for class 1 is detected
+	class := 0
+	sink := 0
+	leaky := func() {
+		n := 200
+		if class == 1 {
+			n = 2000
+		}
+		for i := 0; i < n; i++ {
+			sink += i
+		}
+	}
+	r := dudect.Run(2000, 10, 0.9, func(c int) { class = c }, leaky)
+	if !r.Leaks() {
+		t.Errorf("a leak not detected: %+v", r)
+	}
+
+	// a constant operation is not
+	constant := func() {
+		for i := 0; i < 1000; i++ {
+			sink += i
+		}
+	}
+	r = dudect.Run(2000, 10, 0.9, func(c int) { class = c }, constant)
+	if r.Leaks() {
+		t.Errorf("a leak falsely detected: %+v", r)
+	}
+}
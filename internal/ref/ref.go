@@ -0,0 +1,122 @@
+/*
+	ref.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package ref is a reference implementation of CBC-CTS, written literally after the Addendum to NIST SP 800-38A.
+
+	It is deliberately simple and allocates freely; it exists to check the optimized codec against on random inputs.
+*/
+package ref
+
+import (
+	"crypto/cipher"
+	"fmt"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// Encrypt encrypts src of at least one block in a CBC-CTS format, and returns the ciphertext.
+func Encrypt(b cipher.Block, iv []byte, mode cbccts.Format, src []byte) []byte {
+	bs := b.BlockSize()
+	n, d := split(bs, len(src))
+
+	// CBC-encrypt P_1, ..., P_{n-1}, P*_n || 0^(b-d)
+	p := append(append([]byte{}, src...), make([]byte, bs-d)...)
+	c := make([][]byte, n+1)
+	c[0] = iv
+	for i := 1; i <= n; i++ {
+		c[i] = make([]byte, bs)
+		xor(c[i], p[(i-1)*bs:i*bs], c[i-1])
+		b.Encrypt(c[i], c[i])
+	}
+	if n == 1 {
+		return c[1]
+	}
+
+	// C*_{n-1} = MSB_d(C_{n-1})
+	cstar := c[n-1][:d]
+
+	var out []byte
+	for i := 1; i <= n-2; i++ {
+		out = append(out, c[i]...)
+	}
+	switch {
+	case mode == cbccts.CS1 || (mode == cbccts.CS2 && d == bs):
+		out = append(append(out, cstar...), c[n]...)
+	case mode == cbccts.CS2 || mode == cbccts.CS3:
+		out = append(append(out, c[n]...), cstar...)
+	default:
+		panic(fmt.Errorf("ref: invalid mode"))
+	}
+	return out
+}
+
+// Decrypt decrypts src of at least one block in a CBC-CTS format, and returns the plaintext.
+func Decrypt(b cipher.Block, iv []byte, mode cbccts.Format, src []byte) []byte {
+	bs := b.BlockSize()
+	n, d := split(bs, len(src))
+
+	// rearrange the ciphertext into the CS1 order: C_1, ..., C_{n-2}, C*_{n-1}, C_n
+	c1 := append([]byte{}, src...)
+	if n > 1 {
+		switch {
+		case mode == cbccts.CS1 || (mode == cbccts.CS2 && d == bs):
+		case mode == cbccts.CS2 || mode == cbccts.CS3:
+			head := src[:(n-2)*bs]
+			cn := src[(n-2)*bs : (n-1)*bs]
+			cstar := src[(n-1)*bs:]
+			c1 = append(append(append([]byte{}, head...), cstar...), cn...)
+		default:
+			panic(fmt.Errorf("ref: invalid mode"))
+		}
+	}
+
+	c := make([][]byte, n+1)
+	c[0] = iv
+	for i := 1; i <= n-2; i++ {
+		c[i] = c1[(i-1)*bs : i*bs]
+	}
+	if n == 1 {
+		c[1] = c1
+	} else {
+		cstar := c1[(n-2)*bs : (n-2)*bs+d]
+		c[n] = c1[(n-2)*bs+d:]
+
+		// Z = CIPH^-1(C_n); C_{n-1} = C*_{n-1} || LSB_(b-d)(Z)
+		z := make([]byte, bs)
+		b.Decrypt(z, c[n])
+		c[n-1] = append(append([]byte{}, cstar...), z[d:]...)
+	}
+
+	// P_i = CIPH^-1(C_i) xor C_{i-1}, for i < n
+	var out []byte
+	for i := 1; i <= n-1; i++ {
+		p := make([]byte, bs)
+		b.Decrypt(p, c[i])
+		xor(p, p, c[i-1])
+		out = append(out, p...)
+	}
+
+	// P*_n = MSB_d(CIPH^-1(C_n) xor C_{n-1})
+	p := make([]byte, bs)
+	b.Decrypt(p, c[n])
+	xor(p, p, c[n-1])
+	return append(out, p[:d]...)
+}
+
+// the number of blocks n, and the length d of the last block P*_n, 0 < d <= b
+func split(bs, length int) (n, d int) {
+	if length < bs {
+		panic(fmt.Errorf("ref: data size too small"))
+	}
+	n = (length + bs - 1) / bs
+	return n, length - (n-1)*bs
+}
+
+func xor(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
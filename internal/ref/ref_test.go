@@ -0,0 +1,25 @@
+package ref_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/internal/ref"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+// the reference implementation itself is checked with the known-answer vectors
+func TestVectors(t *testing.T) {
+	for _, set := range [][]vectors.Vector{vectors.RFC3962, vectors.NIST} {
+		for _, v := range set {
+			b, _ := aes.NewCipher(v.Key)
+			if ct := ref.Encrypt(b, v.IV, v.Format, v.Plaintext); !bytes.Equal(ct, v.Ciphertext) {
+				t.Errorf("%s: encryption mismatch %x", v.Name, ct)
+			}
+			if pt := ref.Decrypt(b, v.IV, v.Format, v.Ciphertext); !bytes.Equal(pt, v.Plaintext) {
+				t.Errorf("%s: decryption mismatch %x", v.Name, pt)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+//go:build amd64 || arm64
+// +build amd64 arm64
+
+package interop
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// AF_ALG constants of linux/if_alg.h
+const (
+	afALG          = 38
+	solALG         = 279
+	algSetKey      = 1
+	algSetIV       = 2
+	algSetOp       = 3
+	algOpDecrypt   = 0
+	algOpEncrypt   = 1
+	afALGTransform = "cts(cbc(aes))"
+)
+
+// struct sockaddr_alg
+type sockaddrALG struct {
+	family uint16
+	typ    [14]byte
+	feat   uint32
+	mask   uint32
+	name   [64]byte
+}
+
+// run the kernel's cts(cbc(aes)) skcipher through an AF_ALG socket; the kernel has a single CTS format, CS3
+func afalgCrypt(f cbccts.Format, encrypt bool, key, iv, data []byte) ([]byte, error) {
+	if f != cbccts.CS3 {
+		return nil, fmt.Errorf("af_alg: unsupported format CS%d", f)
+	}
+	fd, err := syscall.Socket(afALG, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	sa := sockaddrALG{family: afALG}
+	copy(sa.typ[:], "skcipher")
+	copy(sa.name[:], afALGTransform)
+	if _, _, e := syscall.Syscall(syscall.SYS_BIND, uintptr(fd), uintptr(unsafe.Pointer(&sa)), unsafe.Sizeof(sa)); e != 0 {
+		return nil, e
+	}
+	if err := syscall.SetsockoptString(fd, solALG, algSetKey, string(key)); err != nil {
+		return nil, err
+	}
+	op, _, e := syscall.Syscall6(syscall.SYS_ACCEPT4, uintptr(fd), 0, 0, syscall.SOCK_CLOEXEC, 0, 0)
+	if e != 0 {
+		return nil, e
+	}
+	defer syscall.Close(int(op))
+
+	// control messages: the operation, and the IV as struct af_alg_iv
+	opdata := make([]byte, 4)
+	if encrypt {
+		binary.LittleEndian.PutUint32(opdata, algOpEncrypt)
+	} else {
+		binary.LittleEndian.PutUint32(opdata, algOpDecrypt)
+	}
+	ivdata := make([]byte, 4+len(iv))
+	binary.LittleEndian.PutUint32(ivdata, uint32(len(iv)))
+	copy(ivdata[4:], iv)
+	oob := append(cmsg(algSetOp, opdata), cmsg(algSetIV, ivdata)...)
+
+	if err := syscall.Sendmsg(int(op), data, oob, nil, 0); err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	if n, err := syscall.Read(int(op), out); err != nil {
+		return nil, err
+	} else if n != len(out) {
+		return nil, fmt.Errorf("af_alg: short read %d", n)
+	}
+	return out, nil
+}
+
+// a SOL_ALG control message
+func cmsg(typ int32, data []byte) []byte {
+	b := make([]byte, syscall.CmsgSpace(len(data)))
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&b[0]))
+	h.Level = solALG
+	h.Type = typ
+	h.SetLen(syscall.CmsgLen(len(data)))
+	copy(b[syscall.CmsgLen(0):], data)
+	return b
+}
+
+// the kernel's cts(cbc(aes)) swaps the last two blocks even if the input is aligned, i.e. CS3
+func TestAFALG(t *testing.T) {
+	key := make([]byte, 16)
+	if _, err := afalgCrypt(cbccts.CS3, true, key, make([]byte, 16), make([]byte, 17)); err != nil {
+		t.Skipf("%s through AF_ALG is not available: %v", afALGTransform, err)
+	}
+	liveTest(t, "Ciphertexts of the cts(cbc(aes)) skcipher of the Linux kernel.",
+		[]cbccts.Format{cbccts.CS3}, []int{16, 24, 32}, afalgCrypt)
+}
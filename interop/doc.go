@@ -0,0 +1,19 @@
+/*
+	doc.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package interop holds interoperability tests of the CBC-CTS codec against other implementations.
+
+	Golden files produced by other implementations are in testdata and are checked by a plain `go test`.
+	Live tests against a library installed on the machine are opt-in by build tags, with cgo:
+
+		go test -tags openssl ./interop    // OpenSSL 3 libcrypto
+		go test -tags gcrypt ./interop     // libgcrypt
+
+	On Linux, the kernel's cts(cbc(aes)) transform is tested through an AF_ALG socket whenever the kernel allows it.
+
+	The live tests regenerate the golden files with the -update flag.
+*/
+package interop
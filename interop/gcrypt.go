@@ -0,0 +1,62 @@
+//go:build gcrypt && cgo
+// +build gcrypt,cgo
+
+/*
+	gcrypt.go
+	2026-10, github.com/mixcode
+*/
+
+package interop
+
+/*
+#cgo LDFLAGS: -lgcrypt
+#include <gcrypt.h>
+
+// run AES in the CBC mode of libgcrypt with the GCRY_CIPHER_CBC_CTS flag, in place
+static int gcrypt_cts(int enc, const unsigned char *key, size_t keylen, const unsigned char *iv,
+		unsigned char *buf, size_t len) {
+	gcry_cipher_hd_t h;
+	int algo = keylen == 16 ? GCRY_CIPHER_AES128 : keylen == 24 ? GCRY_CIPHER_AES192 : GCRY_CIPHER_AES256;
+	gcry_error_t err;
+
+	gcry_check_version(NULL);
+	if (gcry_cipher_open(&h, algo, GCRY_CIPHER_MODE_CBC, GCRY_CIPHER_CBC_CTS)) {
+		return 0;
+	}
+	err = gcry_cipher_setkey(h, key, keylen);
+	if (!err) {
+		err = gcry_cipher_setiv(h, iv, 16);
+	}
+	if (!err) {
+		err = enc ? gcry_cipher_encrypt(h, buf, len, NULL, 0) : gcry_cipher_decrypt(h, buf, len, NULL, 0);
+	}
+	gcry_cipher_close(h);
+	return !err;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// run libgcrypt's CBC-CTS on data; libgcrypt has a single CTS format, so f must be CS3
+func gcryptCrypt(f cbccts.Format, encrypt bool, key, iv, data []byte) ([]byte, error) {
+	if f != cbccts.CS3 {
+		return nil, fmt.Errorf("libgcrypt: unsupported format CS%d", f)
+	}
+	enc := C.int(0)
+	if encrypt {
+		enc = 1
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	if C.gcrypt_cts(enc, (*C.uchar)(unsafe.Pointer(&key[0])), C.size_t(len(key)), (*C.uchar)(unsafe.Pointer(&iv[0])),
+		(*C.uchar)(unsafe.Pointer(&out[0])), C.size_t(len(out))) == 0 {
+		return nil, fmt.Errorf("libgcrypt: CBC-CTS failed")
+	}
+	return out, nil
+}
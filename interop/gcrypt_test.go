@@ -0,0 +1,20 @@
+//go:build gcrypt && cgo
+// +build gcrypt,cgo
+
+package interop
+
+import (
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// libgcrypt's GCRY_CIPHER_CBC_CTS swaps the last two blocks even if the input is aligned, i.e. CS3
+func TestGcrypt(t *testing.T) {
+	golden := liveTest(t,
+		"Ciphertexts of AES in the GCRY_CIPHER_MODE_CBC mode of libgcrypt with the GCRY_CIPHER_CBC_CTS flag.",
+		[]cbccts.Format{cbccts.CS3}, []int{16, 24, 32}, gcryptCrypt)
+	if *update {
+		writeGolden(t, "libgcrypt.json", golden)
+	}
+}
@@ -0,0 +1,41 @@
+package interop
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+// the vectors of a golden file must match the format they are declared with, and no other format
+func TestFormatMapping(t *testing.T) {
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		f, err := vectors.LoadJSONFile(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, g := range f.TestGroups {
+			for _, other := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+				if formatName(other) == g.Format {
+					continue
+				}
+				distinct := false
+				for i := range g.Tests {
+					if g.Tests[i].Result == vectors.ResultValid && g.Tests[i].Run(other) != nil {
+						distinct = true
+						break
+					}
+				}
+				if !distinct {
+					t.Errorf("%s: vectors of %s also match %s", name, g.Format, formatName(other))
+				}
+			}
+		}
+	}
+}
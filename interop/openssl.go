@@ -0,0 +1,67 @@
+//go:build openssl && cgo
+// +build openssl,cgo
+
+/*
+	openssl.go
+	2026-10, github.com/mixcode
+*/
+
+package interop
+
+/*
+#cgo LDFLAGS: -lcrypto
+#include <stdlib.h>
+#include <openssl/evp.h>
+#include <openssl/core_names.h>
+#include <openssl/params.h>
+
+// run the AES-n-CBC-CTS cipher of OpenSSL 3 in a CTS mode of "CS1", "CS2" or "CS3"
+static int cts_crypt(const char *name, char *mode, int enc,
+		const unsigned char *key, const unsigned char *iv,
+		const unsigned char *in, int inlen, unsigned char *out) {
+	int ok = 0, outlen = 0, finlen = 0;
+	EVP_CIPHER *c = EVP_CIPHER_fetch(NULL, name, NULL);
+	EVP_CIPHER_CTX *ctx = EVP_CIPHER_CTX_new();
+	OSSL_PARAM params[2] = {
+		OSSL_PARAM_construct_utf8_string(OSSL_CIPHER_PARAM_CTS_MODE, mode, 0),
+		OSSL_PARAM_construct_end(),
+	};
+	if (c != NULL && ctx != NULL &&
+			EVP_CipherInit_ex2(ctx, c, key, iv, enc, params) &&
+			EVP_CipherUpdate(ctx, out, &outlen, in, inlen) &&
+			EVP_CipherFinal_ex(ctx, out+outlen, &finlen)) {
+		ok = outlen+finlen == inlen;
+	}
+	EVP_CIPHER_CTX_free(ctx);
+	EVP_CIPHER_free(c);
+	return ok;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// run OpenSSL's AES-CBC-CTS cipher on data
+func opensslCrypt(f cbccts.Format, encrypt bool, key, iv, data []byte) ([]byte, error) {
+	name := C.CString(fmt.Sprintf("AES-%d-CBC-CTS", len(key)*8))
+	defer C.free(unsafe.Pointer(name))
+	mode := C.CString(fmt.Sprintf("CS%d", f))
+	defer C.free(unsafe.Pointer(mode))
+
+	enc := C.int(0)
+	if encrypt {
+		enc = 1
+	}
+	out := make([]byte, len(data))
+	if C.cts_crypt(name, mode, enc,
+		(*C.uchar)(unsafe.Pointer(&key[0])), (*C.uchar)(unsafe.Pointer(&iv[0])),
+		(*C.uchar)(unsafe.Pointer(&data[0])), C.int(len(data)), (*C.uchar)(unsafe.Pointer(&out[0]))) == 0 {
+		return nil, fmt.Errorf("openssl: %s %s failed", C.GoString(name), C.GoString(mode))
+	}
+	return out, nil
+}
@@ -0,0 +1,19 @@
+//go:build openssl && cgo
+// +build openssl,cgo
+
+package interop
+
+import (
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestOpenSSL(t *testing.T) {
+	golden := liveTest(t,
+		"Ciphertexts of the AES-128-CBC-CTS and AES-256-CBC-CTS ciphers of OpenSSL 3, in the CTS modes CS1, CS2 and CS3.",
+		[]cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3}, []int{16, 32}, opensslCrypt)
+	if *update {
+		writeGolden(t, "openssl.json", golden)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+	ivguard.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"errors"
+	"sync"
+)
+
+// ErrIVReused is returned when an IV is used twice for encryption under the same key.
+var ErrIVReused = errors.New("cbccts: IV reused")
+
+// IVTracker records the IVs used for encryption under a key, and returns ErrIVReused for an IV seen before.
+// Implementations must be safe for concurrent use. Use one tracker per key.
+type IVTracker interface {
+	UseIV(iv []byte) error
+}
+
+// a tracker of the most recent IVs
+type recentIVs struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+	ring []string
+	next int
+}
+
+// NewIVTracker returns an IVTracker that remembers the last n IVs; older IVs are forgotten, so a reuse is detected within n messages.
+func NewIVTracker(n int) IVTracker {
+	if n <= 0 {
+		n = 1
+	}
+	return &recentIVs{seen: make(map[string]struct{}, n), ring: make([]string, 0, n)}
+}
+
+func (r *recentIVs) UseIV(iv []byte) error {
+	key := string(iv)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.seen[key]; ok {
+		return ErrIVReused
+	}
+	if len(r.ring) < cap(r.ring) {
+		r.ring = append(r.ring, key)
+	} else {
+		delete(r.seen, r.ring[r.next])
+		r.ring[r.next] = key
+		r.next = (r.next + 1) % len(r.ring)
+	}
+	r.seen[key] = struct{}{}
+	return nil
+}
+
+// NewGuardedEncrypter creates a CBC-CTS encrypter as NewCBCCTSEncrypter does, after recording iv with the tracker of the key.
+// It returns ErrIVReused, and no encrypter, if the tracker has seen iv.
+func NewGuardedEncrypter(b cipher.Block, iv []byte, mode Format, tracker IVTracker) (cipher.BlockMode, error) {
+	if err := tracker.UseIV(iv); err != nil {
+		return nil, err
+	}
+	return NewCBCCTSEncrypter(b, iv, mode), nil
+}
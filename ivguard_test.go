@@ -0,0 +1,39 @@
+package cbccts_test
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestIVTracker(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	tracker := cbccts.NewIVTracker(3)
+	iv := func(b byte) []byte {
+		v := make([]byte, aes.BlockSize)
+		v[0] = b
+		return v
+	}
+
+	for b := byte(1); b <= 3; b++ {
+		if _, err := cbccts.NewGuardedEncrypter(ac, iv(b), cbccts.CS3, tracker); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cbccts.NewGuardedEncrypter(ac, iv(2), cbccts.CS3, tracker); err != cbccts.ErrIVReused {
+		t.Errorf("IV reuse not detected: %v", err)
+	}
+
+	// the oldest IV is forgotten after n more
+	if err := tracker.UseIV(iv(4)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.UseIV(iv(1)); err != nil {
+		t.Errorf("a forgotten IV is still tracked: %v", err)
+	}
+	if err := tracker.UseIV(iv(4)); err != cbccts.ErrIVReused {
+		t.Errorf("IV reuse not detected: %v", err)
+	}
+}
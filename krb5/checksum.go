@@ -0,0 +1,65 @@
+/*
+	checksum.go
+	2026-10, github.com/mixcode
+*/
+
+package krb5
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+)
+
+// ChecksumType is a Kerberos checksum type number.
+type ChecksumType int32
+
+const (
+	HMACSHA196AES128    ChecksumType = 15 // hmac-sha1-96-aes128, RFC 3962
+	HMACSHA196AES256    ChecksumType = 16 // hmac-sha1-96-aes256, RFC 3962
+	HMACSHA256128AES128 ChecksumType = 19 // hmac-sha256-128-aes128, RFC 8009
+	HMACSHA384192AES256 ChecksumType = 20 // hmac-sha384-192-aes256, RFC 8009
+)
+
+// ChecksumType returns the keyed checksum type associated with the encryption type, or 0 if the type is not supported.
+func (e EType) ChecksumType() ChecksumType {
+	switch e {
+	case AES128CTSHMACSHA196:
+		return HMACSHA196AES128
+	case AES256CTSHMACSHA196:
+		return HMACSHA196AES256
+	case AES128CTSHMACSHA256128:
+		return HMACSHA256128AES128
+	case AES256CTSHMACSHA384192:
+		return HMACSHA384192AES256
+	}
+	return 0
+}
+
+// Checksum computes the keyed checksum of data with a base key for a key usage number:
+// the truncated HMAC of data with the checksum key Kc derived from the base key.
+func (e EType) Checksum(key []byte, usage uint32, data []byte) ([]byte, error) {
+	kc, err := e.DeriveKey(key, usageConstant(usage, usageKc))
+	if err != nil {
+		return nil, err
+	}
+	if e.isSHA2() {
+		mac := hmac.New(e.hash(), kc)
+		mac.Write(data)
+		return mac.Sum(nil)[:e.macSize()], nil
+	}
+	mac := hmac.New(sha1.New, kc)
+	mac.Write(data)
+	return mac.Sum(nil)[:hmacSize], nil
+}
+
+// VerifyChecksum checks a keyed checksum made by Checksum.
+func (e EType) VerifyChecksum(key []byte, usage uint32, data, checksum []byte) error {
+	expect, err := e.Checksum(key, usage, data)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expect, checksum) {
+		return ErrIntegrity
+	}
+	return nil
+}
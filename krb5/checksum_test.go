@@ -0,0 +1,45 @@
+package krb5_test
+
+import (
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/krb5"
+)
+
+func TestChecksum(t *testing.T) {
+
+	expect := map[krb5.EType]struct {
+		ctype krb5.ChecksumType
+		size  int
+	}{
+		krb5.AES128CTSHMACSHA196:    {krb5.HMACSHA196AES128, 12},
+		krb5.AES256CTSHMACSHA196:    {krb5.HMACSHA196AES256, 12},
+		krb5.AES128CTSHMACSHA256128: {krb5.HMACSHA256128AES128, 16},
+		krb5.AES256CTSHMACSHA384192: {krb5.HMACSHA384192AES256, 24},
+	}
+
+	data := []byte("data to be checksummed")
+	for etype, c := range expect {
+		if etype.ChecksumType() != c.ctype {
+			t.Errorf("%v: unexpected checksum type %d", etype, etype.ChecksumType())
+		}
+		key := make([]byte, etype.KeySize())
+		sum, err := etype.Checksum(key, 7, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sum) != c.size {
+			t.Errorf("%v: unexpected checksum size %d", etype, len(sum))
+		}
+		if err := etype.VerifyChecksum(key, 7, data, sum); err != nil {
+			t.Errorf("%v: %v", etype, err)
+		}
+		if err := etype.VerifyChecksum(key, 8, data, sum); err != krb5.ErrIntegrity {
+			t.Errorf("%v: wrong key usage not detected", etype)
+		}
+		sum[0] ^= 1
+		if err := etype.VerifyChecksum(key, 7, data, sum); err != krb5.ErrIntegrity {
+			t.Errorf("%v: altered checksum not detected", etype)
+		}
+	}
+}
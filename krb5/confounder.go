@@ -0,0 +1,38 @@
+/*
+	confounder.go
+	2026-10, github.com/mixcode
+*/
+
+package krb5
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// ConfounderSize is the size of the random confounder prepended to a plaintext by the AES encryption types.
+const ConfounderSize = aes.BlockSize
+
+// AddConfounder returns a new slice of a random confounder followed by plaintext.
+// The confounder is read from random, or from crypto/rand.Reader if random is nil; tests may inject a fixed reader.
+func AddConfounder(random io.Reader, plaintext []byte) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	out := make([]byte, ConfounderSize+len(plaintext))
+	if _, err := io.ReadFull(random, out[:ConfounderSize]); err != nil {
+		return nil, fmt.Errorf("krb5: cannot read the confounder: %w", err)
+	}
+	copy(out[ConfounderSize:], plaintext)
+	return out, nil
+}
+
+// RemoveConfounder returns the plaintext following the confounder of a decrypted message. The returned slice refers to b.
+func RemoveConfounder(b []byte) ([]byte, error) {
+	if len(b) < ConfounderSize {
+		return nil, fmt.Errorf("krb5: message shorter than the confounder")
+	}
+	return b[ConfounderSize:], nil
+}
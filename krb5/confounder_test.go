@@ -0,0 +1,53 @@
+package krb5_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/krb5"
+)
+
+func TestConfounder(t *testing.T) {
+
+	fixed := bytes.Repeat([]byte{0xcc}, krb5.ConfounderSize)
+	msg := []byte("message")
+
+	b, err := krb5.AddConfounder(bytes.NewReader(fixed), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b[:krb5.ConfounderSize], fixed) || !bytes.Equal(b[krb5.ConfounderSize:], msg) {
+		t.Errorf("unexpected confounded message %x", b)
+	}
+	m, err := krb5.RemoveConfounder(b)
+	if err != nil || !bytes.Equal(m, msg) {
+		t.Errorf("cannot remove the confounder: %v", err)
+	}
+
+	// short randomness and short messages are errors
+	if _, err := krb5.AddConfounder(bytes.NewReader(fixed[:3]), msg); err == nil {
+		t.Errorf("short confounder accepted")
+	}
+	if _, err := krb5.RemoveConfounder(fixed[:3]); err == nil {
+		t.Errorf("short message accepted")
+	}
+
+	// the default source is random
+	b1, _ := krb5.AddConfounder(nil, msg)
+	b2, _ := krb5.AddConfounder(nil, msg)
+	if bytes.Equal(b1, b2) {
+		t.Errorf("confounder repeated")
+	}
+
+	// a fixed confounder makes encryption deterministic
+	etype := krb5.AES256CTSHMACSHA196
+	key := make([]byte, etype.KeySize())
+	e1, err := etype.EncryptWithRand(bytes.NewReader(fixed), key, 1, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, _ := etype.EncryptWithRand(bytes.NewReader(fixed), key, 1, msg)
+	if !bytes.Equal(e1, e2) {
+		t.Errorf("encryption with a fixed confounder is not deterministic")
+	}
+}
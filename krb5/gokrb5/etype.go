@@ -0,0 +1,245 @@
+/*
+	etype.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package gokrb5 exposes the encryption types of package krb5 through the etype.EType interface of
+	github.com/jcmturner/gokrb5/v8/crypto/etype, so that they can replace the built-in AES types of gokrb5.
+
+	The package does not import gokrb5; the returned values satisfy the interface structurally.
+
+		et, err := gokrb5.GetEtype(18)
+		var _ etype.EType = et
+*/
+package gokrb5
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/krb5"
+)
+
+// EType is a Kerberos encryption type with the method set of the gokrb5 etype.EType interface.
+type EType struct {
+	e krb5.EType
+}
+
+// GetEtype returns the encryption type for an encryption type number.
+// Only the AES types 17, 18, 19 and 20 are supported.
+func GetEtype(id int32) (EType, error) {
+	e := krb5.EType(id)
+	if e.KeySize() == 0 {
+		return EType{}, fmt.Errorf("gokrb5: unsupported encryption type %d", id)
+	}
+	return EType{e}, nil
+}
+
+// GetETypeID returns the encryption type number.
+func (t EType) GetETypeID() int32 {
+	return int32(t.e)
+}
+
+// GetHashID returns the checksum type number associated with the encryption type.
+func (t EType) GetHashID() int32 {
+	return int32(t.e.ChecksumType())
+}
+
+// GetKeyByteSize returns the size of a key in bytes.
+// Note that gokrb5 itself reports 24 for aes256-cts-hmac-sha384-192, while the key is 32 bytes long.
+func (t EType) GetKeyByteSize() int {
+	return t.e.KeySize()
+}
+
+// GetKeySeedBitLength returns the size of the random input of random-to-key in bits.
+func (t EType) GetKeySeedBitLength() int {
+	return t.e.KeySize() * 8
+}
+
+// GetDefaultStringToKeyParams returns the default string-to-key parameter, the big-endian iteration count in hex.
+func (t EType) GetDefaultStringToKeyParams() string {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(t.iterations()))
+	return hex.EncodeToString(b[:])
+}
+
+// StringToKey derives a key from a password and a salt. An empty s2kparams selects the default iteration count.
+func (t EType) StringToKey(secret, salt, s2kparams string) ([]byte, error) {
+	iter := t.iterations()
+	if s2kparams != "" {
+		b, err := hex.DecodeString(s2kparams)
+		if err != nil || len(b) != 4 {
+			return nil, fmt.Errorf("gokrb5: invalid s2kparams %q", s2kparams)
+		}
+		iter = int(binary.BigEndian.Uint32(b))
+		if iter == 0 {
+			// a zero parameter means 2^32 iterations
+			return nil, fmt.Errorf("gokrb5: unsupported iteration count 2^32")
+		}
+	}
+	return t.e.StringToKey(secret, salt, iter)
+}
+
+// RandomToKey returns a key made from random bytes; for AES, it is the identity function.
+func (t EType) RandomToKey(b []byte) []byte {
+	return b
+}
+
+// GetHMACBitLength returns the size of the truncated integrity HMAC in bits.
+func (t EType) GetHMACBitLength() int {
+	switch t.e {
+	case krb5.AES128CTSHMACSHA256128:
+		return 128
+	case krb5.AES256CTSHMACSHA384192:
+		return 192
+	}
+	return 96
+}
+
+// GetMessageBlockByteSize returns the message block size; CBC-CTS needs no padding, so it is 1.
+func (t EType) GetMessageBlockByteSize() int {
+	return 1
+}
+
+// EncryptData encrypts data in CBC-CTS mode with a zero IV, without a confounder or an integrity hash.
+// It returns the IV for chaining the next encryption, which is the last ciphertext block in CBC order, and the ciphertext.
+// Data shorter than a block is zero-padded to a block.
+func (t EType) EncryptData(key, data []byte) ([]byte, []byte, error) {
+	if len(key) != t.e.KeySize() {
+		return nil, nil, fmt.Errorf("gokrb5: invalid key size %d for %v", len(key), t.e)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	if len(out) < aes.BlockSize {
+		out = append(out, make([]byte, aes.BlockSize-len(out))...)
+	}
+	cbccts.NewCBCCTSEncrypter(block, make([]byte, aes.BlockSize), cbccts.CS3).CryptBlocks(out, out)
+	return cbccts.CBCResidue(out, aes.BlockSize, cbccts.CS3), out, nil
+}
+
+// EncryptMessage encrypts a message for a key usage number: the confounded message is encrypted and the integrity hash is appended.
+// It returns the chaining IV of the encrypted part, and the ciphertext.
+func (t EType) EncryptMessage(key, message []byte, usage uint32) ([]byte, []byte, error) {
+	ct, err := t.e.Encrypt(key, usage, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := ct[:len(ct)-t.GetHMACBitLength()/8]
+	return cbccts.CBCResidue(body, aes.BlockSize, cbccts.CS3), ct, nil
+}
+
+// DecryptData decrypts data encrypted by EncryptData.
+func (t EType) DecryptData(key, data []byte) ([]byte, error) {
+	if len(key) != t.e.KeySize() {
+		return nil, fmt.Errorf("gokrb5: invalid key size %d for %v", len(key), t.e)
+	}
+	if len(data) < aes.BlockSize {
+		return nil, fmt.Errorf("gokrb5: ciphertext too short")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	cbccts.NewCBCCTSDecrypter(block, make([]byte, aes.BlockSize), cbccts.CS3).CryptBlocks(out, data)
+	return out, nil
+}
+
+// DecryptMessage decrypts a ciphertext made by EncryptMessage and verifies its integrity.
+func (t EType) DecryptMessage(key, ciphertext []byte, usage uint32) ([]byte, error) {
+	return t.e.Decrypt(key, usage, ciphertext)
+}
+
+// GetCypherBlockBitLength returns the cipher block size in bits.
+func (t EType) GetCypherBlockBitLength() int {
+	return aes.BlockSize * 8
+}
+
+// GetConfounderByteSize returns the size of the confounder in bytes.
+func (t EType) GetConfounderByteSize() int {
+	return krb5.ConfounderSize
+}
+
+// DeriveKey derives a key from a protocol key and a derivation constant. See krb5.EType.DeriveKey.
+func (t EType) DeriveKey(protocolKey, usage []byte) ([]byte, error) {
+	return t.e.DeriveKey(protocolKey, usage)
+}
+
+// DeriveRandom derives random bytes from a protocol key and a derivation constant.
+// As random-to-key is the identity function for AES, the result is the same as DeriveKey;
+// gokrb5 itself returns the RFC 8009 pseudo-random function for the RFC 8009 types instead.
+func (t EType) DeriveRandom(protocolKey, usage []byte) ([]byte, error) {
+	return t.e.DeriveKey(protocolKey, usage)
+}
+
+// VerifyIntegrity checks the integrity hash at the end of ct, where pt is the decrypted confounded plaintext.
+func (t EType) VerifyIntegrity(protocolKey, ct, pt []byte, usage uint32) bool {
+	hsz := t.GetHMACBitLength() / 8
+	if len(ct) < hsz {
+		return false
+	}
+	ki, err := t.e.DeriveKey(protocolKey, usageConstant(usage, 0x55))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(t.GetHashFunc(), ki)
+	if t.e == krb5.AES128CTSHMACSHA196 || t.e == krb5.AES256CTSHMACSHA196 {
+		// RFC 3962 authenticates the plaintext
+		mac.Write(pt)
+	} else {
+		// RFC 8009 authenticates the IV and the ciphertext
+		mac.Write(make([]byte, aes.BlockSize))
+		mac.Write(ct[:len(ct)-hsz])
+	}
+	return hmac.Equal(mac.Sum(nil)[:hsz], ct[len(ct)-hsz:])
+}
+
+// GetChecksumHash computes the keyed checksum of data for a key usage number.
+func (t EType) GetChecksumHash(protocolKey, data []byte, usage uint32) ([]byte, error) {
+	return t.e.Checksum(protocolKey, usage, data)
+}
+
+// VerifyChecksum checks a keyed checksum made by GetChecksumHash.
+func (t EType) VerifyChecksum(protocolKey, data, chksum []byte, usage uint32) bool {
+	return t.e.VerifyChecksum(protocolKey, usage, data, chksum) == nil
+}
+
+// GetHashFunc returns the hash function of the integrity HMAC.
+func (t EType) GetHashFunc() func() hash.Hash {
+	switch t.e {
+	case krb5.AES128CTSHMACSHA256128:
+		return sha256.New
+	case krb5.AES256CTSHMACSHA384192:
+		return sha512.New384
+	}
+	return sha1.New
+}
+
+// default iteration count of the string-to-key function
+func (t EType) iterations() int {
+	if t.e == krb5.AES128CTSHMACSHA256128 || t.e == krb5.AES256CTSHMACSHA384192 {
+		return krb5.DefaultIterationsSHA2
+	}
+	return krb5.DefaultIterations
+}
+
+// the derivation constant: the key usage number followed by a byte for the purpose of the key
+func usageConstant(usage uint32, purpose byte) []byte {
+	c := make([]byte, 5)
+	binary.BigEndian.PutUint32(c, usage)
+	c[4] = purpose
+	return c
+}
@@ -0,0 +1,131 @@
+package gokrb5_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/krb5/gokrb5"
+)
+
+// a copy of etype.EType of github.com/jcmturner/gokrb5/v8/crypto/etype
+type gokrb5EType interface {
+	GetETypeID() int32
+	GetHashID() int32
+	GetKeyByteSize() int
+	GetKeySeedBitLength() int
+	GetDefaultStringToKeyParams() string
+	StringToKey(string, salt, s2kparams string) ([]byte, error)
+	RandomToKey(b []byte) []byte
+	GetHMACBitLength() int
+	GetMessageBlockByteSize() int
+	EncryptData(key, data []byte) ([]byte, []byte, error)
+	EncryptMessage(key, message []byte, usage uint32) ([]byte, []byte, error)
+	DecryptData(key, data []byte) ([]byte, error)
+	DecryptMessage(key, ciphertext []byte, usage uint32) ([]byte, error)
+	GetCypherBlockBitLength() int
+	GetConfounderByteSize() int
+	DeriveKey(protocolKey, usage []byte) ([]byte, error)
+	DeriveRandom(protocolKey, usage []byte) ([]byte, error)
+	VerifyIntegrity(protocolKey, ct, pt []byte, usage uint32) bool
+	GetChecksumHash(protocolKey, data []byte, usage uint32) ([]byte, error)
+	VerifyChecksum(protocolKey, data, chksum []byte, usage uint32) bool
+	GetHashFunc() func() hash.Hash
+}
+
+var _ gokrb5EType = gokrb5.EType{}
+
+func TestEType(t *testing.T) {
+
+	for _, id := range []int32{17, 18, 19, 20} {
+		var et gokrb5EType
+		et, err := gokrb5.GetEtype(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if et.GetETypeID() != id {
+			t.Errorf("unexpected etype %d", et.GetETypeID())
+		}
+
+		key, err := et.StringToKey("password", "ATHENA.MIT.EDUraeburn", et.GetDefaultStringToKeyParams())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(key) != et.GetKeyByteSize() {
+			t.Errorf("etype %d: unexpected key size %d", id, len(key))
+		}
+
+		message := []byte("a message to be encrypted")
+		_, ct, err := et.EncryptMessage(key, message, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != et.GetConfounderByteSize()+len(message)+et.GetHMACBitLength()/8 {
+			t.Errorf("etype %d: unexpected ciphertext size %d", id, len(ct))
+		}
+		pt, err := et.DecryptMessage(key, ct, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pt, message) {
+			t.Errorf("etype %d: decryption failed", id)
+		}
+
+		// decrypt the message part by part, as gokrb5 does
+		ke, err := et.DeriveKey(key, []byte{0, 0, 0, 3, 0xaa})
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf, err := et.DecryptData(ke, ct[:len(ct)-et.GetHMACBitLength()/8])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(conf[et.GetConfounderByteSize():], message) {
+			t.Errorf("etype %d: DecryptData failed", id)
+		}
+		if !et.VerifyIntegrity(key, ct, conf, 3) {
+			t.Errorf("etype %d: integrity check failed", id)
+		}
+		if et.VerifyIntegrity(key, ct, conf, 4) {
+			t.Errorf("etype %d: wrong key usage not detected", id)
+		}
+
+		sum, err := et.GetChecksumHash(key, message, 9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sum) != et.GetHMACBitLength()/8 || !et.VerifyChecksum(key, message, sum, 9) {
+			t.Errorf("etype %d: checksum failed", id)
+		}
+	}
+
+	if _, err := gokrb5.GetEtype(23); err == nil {
+		t.Errorf("unsupported etype accepted")
+	}
+}
+
+func TestEncryptData(t *testing.T) {
+
+	// RFC 3962 Appendix B
+	key, _ := hex.DecodeString("636869636b656e207465726979616b69")
+	in, _ := hex.DecodeString("4920776f756c64206c696b652074686520")
+	out, _ := hex.DecodeString("c6353568f2bf8cb4d8a580362da7ff7f97")
+	nextIV, _ := hex.DecodeString("c6353568f2bf8cb4d8a580362da7ff7f")
+
+	et, _ := gokrb5.GetEtype(17)
+	iv, ct, err := et.EncryptData(key, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ct, out) || !bytes.Equal(iv, nextIV) {
+		t.Errorf("unexpected output %x, next IV %x", ct, iv)
+	}
+	pt, err := et.DecryptData(key, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pt, in) {
+		t.Errorf("decryption failed")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+	gss.go
+	2026-10, github.com/mixcode
+*/
+
+package krb5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Flags of a GSS-API per-message token (RFC 4121 section 4.2.2).
+const (
+	FlagSentByAcceptor = 0x01
+	FlagSealed         = 0x02
+	FlagAcceptorSubkey = 0x04
+)
+
+// Key usage numbers of GSS-API per-message tokens (RFC 4121 section 2).
+const (
+	UsageAcceptorSeal  = 22
+	UsageAcceptorSign  = 23
+	UsageInitiatorSeal = 24
+	UsageInitiatorSign = 25
+)
+
+// wrap token header
+const (
+	wrapHeaderSize = 16
+	wrapTokenID    = 0x0504
+	wrapFiller     = 0xff
+)
+
+// ErrInvalidToken is returned when a token is malformed.
+var ErrInvalidToken = errors.New("krb5: invalid GSS-API token")
+
+// WrapToken is a GSS-API wrap token of RFC 4121 section 4.2.6.2, with confidentiality.
+type WrapToken struct {
+	Flags   byte   // FlagSentByAcceptor and FlagAcceptorSubkey; FlagSealed is always set
+	EC      uint16 // number of filler bytes encrypted after the payload
+	RRC     uint16 // right rotation count of the encrypted part
+	SeqNum  uint64 // sender's sequence number
+	Payload []byte // plaintext message
+}
+
+// header returns the token header with the given RRC
+func (t *WrapToken) header(rrc uint16) []byte {
+	h := make([]byte, wrapHeaderSize)
+	binary.BigEndian.PutUint16(h[0:2], wrapTokenID)
+	h[2] = t.Flags | FlagSealed
+	h[3] = wrapFiller
+	binary.BigEndian.PutUint16(h[4:6], t.EC)
+	binary.BigEndian.PutUint16(h[6:8], rrc)
+	binary.BigEndian.PutUint64(h[8:16], t.SeqNum)
+	return h
+}
+
+// usage returns the key usage for sealing, by the direction of the token
+func wrapUsage(flags byte) uint32 {
+	if flags&FlagSentByAcceptor != 0 {
+		return UsageAcceptorSeal
+	}
+	return UsageInitiatorSeal
+}
+
+// Wrap encrypts a wrap token with the key of the security context.
+// The payload, the filler and a copy of the header are encrypted together,
+// and the encrypted part is rotated right by RRC bytes as required by some implementations (e.g. RRC 28 for Microsoft SSPI).
+func Wrap(e EType, key []byte, t *WrapToken) ([]byte, error) {
+	// the encrypted copy of the header has RRC 0
+	plain := make([]byte, 0, len(t.Payload)+int(t.EC)+wrapHeaderSize)
+	plain = append(plain, t.Payload...)
+	plain = append(plain, bytes.Repeat([]byte{wrapFiller}, int(t.EC))...)
+	plain = append(plain, t.header(0)...)
+
+	enc, err := e.Encrypt(key, wrapUsage(t.Flags), plain)
+	if err != nil {
+		return nil, err
+	}
+	return append(t.header(t.RRC), rotateRight(enc, int(t.RRC))...), nil
+}
+
+// Unwrap decrypts a wrap token, verifies its integrity and the encrypted copy of the header, and returns the token.
+func Unwrap(e EType, key []byte, token []byte) (*WrapToken, error) {
+	if len(token) < wrapHeaderSize || binary.BigEndian.Uint16(token[0:2]) != wrapTokenID || token[3] != wrapFiller {
+		return nil, ErrInvalidToken
+	}
+	t := &WrapToken{
+		Flags:  token[2],
+		EC:     binary.BigEndian.Uint16(token[4:6]),
+		RRC:    binary.BigEndian.Uint16(token[6:8]),
+		SeqNum: binary.BigEndian.Uint64(token[8:16]),
+	}
+	if t.Flags&FlagSealed == 0 {
+		return nil, fmt.Errorf("krb5: wrap token without confidentiality is not supported")
+	}
+
+	enc := rotateLeft(token[wrapHeaderSize:], int(t.RRC))
+	plain, err := e.Decrypt(key, wrapUsage(t.Flags), enc)
+	if err != nil {
+		return nil, err
+	}
+	if len(plain) < int(t.EC)+wrapHeaderSize {
+		return nil, ErrInvalidToken
+	}
+
+	// the encrypted header must match the clear one
+	n := len(plain) - wrapHeaderSize - int(t.EC)
+	if !bytes.Equal(plain[n+int(t.EC):], t.header(0)) {
+		return nil, ErrInvalidToken
+	}
+	t.Payload = plain[:n]
+	return t, nil
+}
+
+// rotate b right by n bytes into a new slice
+func rotateRight(b []byte, n int) []byte {
+	out := make([]byte, len(b))
+	if len(b) == 0 {
+		return out
+	}
+	n %= len(b)
+	copy(out, b[len(b)-n:])
+	copy(out[n:], b[:len(b)-n])
+	return out
+}
+
+// rotate b left by n bytes into a new slice
+func rotateLeft(b []byte, n int) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return rotateRight(b, len(b)-n%len(b))
+}
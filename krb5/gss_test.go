@@ -0,0 +1,72 @@
+package krb5_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/krb5"
+)
+
+func TestWrapToken(t *testing.T) {
+
+	etype := krb5.AES256CTSHMACSHA196
+	key := make([]byte, etype.KeySize())
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	for _, flags := range []byte{0, krb5.FlagSentByAcceptor | krb5.FlagAcceptorSubkey} {
+		for _, ec := range []uint16{0, 16} {
+			for _, rrc := range []uint16{0, 12, 28, 1000} {
+				wt := &krb5.WrapToken{
+					Flags:   flags,
+					EC:      ec,
+					RRC:     rrc,
+					SeqNum:  0x0102030405060708,
+					Payload: []byte("a GSS-API message"),
+				}
+				token, err := krb5.Wrap(etype, key, wt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if token[0] != 0x05 || token[1] != 0x04 || token[2] != flags|krb5.FlagSealed {
+					t.Errorf("unexpected token header %x", token[:16])
+				}
+
+				// the encrypted part, rotated back, is an ordinary Kerberos ciphertext
+				usage := uint32(krb5.UsageInitiatorSeal)
+				if flags&krb5.FlagSentByAcceptor != 0 {
+					usage = krb5.UsageAcceptorSeal
+				}
+				enc := token[16:]
+				r := int(rrc) % len(enc)
+				enc = append(append([]byte(nil), enc[r:]...), enc[:r]...)
+				plain, err := etype.Decrypt(key, usage, enc)
+				if err != nil {
+					t.Fatalf("flags %x, ec %d, rrc %d: %v", flags, ec, rrc, err)
+				}
+				if !bytes.HasPrefix(plain, wt.Payload) || len(plain) != len(wt.Payload)+int(ec)+16 {
+					t.Errorf("flags %x, ec %d, rrc %d: unexpected encrypted data", flags, ec, rrc)
+				}
+
+				got, err := krb5.Unwrap(etype, key, token)
+				if err != nil {
+					t.Fatalf("flags %x, ec %d, rrc %d: %v", flags, ec, rrc, err)
+				}
+				if !bytes.Equal(got.Payload, wt.Payload) || got.SeqNum != wt.SeqNum || got.RRC != rrc || got.EC != ec {
+					t.Errorf("flags %x, ec %d, rrc %d: unwrapped token differs", flags, ec, rrc)
+				}
+
+				// the clear header is protected by the encrypted copy
+				token[15] ^= 1
+				if _, err := krb5.Unwrap(etype, key, token); err != krb5.ErrInvalidToken {
+					t.Errorf("flags %x, ec %d, rrc %d: altered header not detected", flags, ec, rrc)
+				}
+			}
+		}
+	}
+
+	if _, err := krb5.Unwrap(etype, key, []byte{0x04, 0x04}); err != krb5.ErrInvalidToken {
+		t.Errorf("malformed token accepted")
+	}
+}
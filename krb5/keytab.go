@@ -0,0 +1,56 @@
+/*
+	keytab.go
+	2026-10, github.com/mixcode
+*/
+
+package krb5
+
+import (
+	"fmt"
+)
+
+// Key usage numbers of Kerberos messages (RFC 4120 section 7.5.1).
+const (
+	UsagePAEncTimestamp      = 1  // PA-ENC-TIMESTAMP, with the client key
+	UsageTicket              = 2  // the encrypted part of a ticket, with the service key
+	UsageASRepEncPart        = 3  // the encrypted part of an AS-REP, with the client key
+	UsageTGSRepEncPart       = 8  // the encrypted part of a TGS-REP, with the session key
+	UsageTGSRepEncPartSubkey = 9  // the encrypted part of a TGS-REP, with the authenticator subkey
+	UsageAPReqAuthenticator  = 11 // the authenticator of an AP-REQ, with the session key
+	UsageAPRepEncPart        = 12 // the encrypted part of an AP-REP, with the session key
+)
+
+// KeytabEntry is a key of a parsed keytab entry.
+type KeytabEntry struct {
+	KVNO  uint32 // key version number
+	EType EType  // encryption type of the key
+	Key   []byte // key contents
+}
+
+// Decrypt decrypts a Kerberos ciphertext, such as the cipher field of an EncryptedData, with the key of the entry.
+func (k *KeytabEntry) Decrypt(usage uint32, ciphertext []byte) ([]byte, error) {
+	return k.EType.Decrypt(k.Key, usage, ciphertext)
+}
+
+// DecryptWithKeytab decrypts a ciphertext encrypted with an encryption type and a key version number, with the matching key in entries.
+// A kvno of 0 matches the entry of the encryption type with the highest version, as the kvno field of an EncryptedData is optional.
+func DecryptWithKeytab(entries []KeytabEntry, etype EType, kvno uint32, usage uint32, ciphertext []byte) ([]byte, error) {
+	var found *KeytabEntry
+	for i := range entries {
+		k := &entries[i]
+		if k.EType != etype {
+			continue
+		}
+		if k.KVNO == kvno {
+			found = k
+			break
+		}
+		if kvno == 0 && (found == nil || k.KVNO > found.KVNO) {
+			found = k
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("krb5: no key for %v kvno %d", etype, kvno)
+	}
+	return found.Decrypt(usage, ciphertext)
+}
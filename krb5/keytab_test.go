@@ -0,0 +1,53 @@
+package krb5_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/krb5"
+)
+
+func TestDecryptWithKeytab(t *testing.T) {
+
+	key := func(e krb5.EType, b byte) []byte {
+		return bytes.Repeat([]byte{b}, e.KeySize())
+	}
+	entries := []krb5.KeytabEntry{
+		{KVNO: 1, EType: krb5.AES256CTSHMACSHA196, Key: key(krb5.AES256CTSHMACSHA196, 1)},
+		{KVNO: 2, EType: krb5.AES256CTSHMACSHA196, Key: key(krb5.AES256CTSHMACSHA196, 2)},
+		{KVNO: 2, EType: krb5.AES128CTSHMACSHA256128, Key: key(krb5.AES128CTSHMACSHA256128, 3)},
+	}
+
+	msg := []byte("encrypted ticket part")
+	for _, c := range []struct {
+		entry krb5.KeytabEntry
+		kvno  uint32
+	}{
+		{entries[0], 1},
+		{entries[1], 2},
+		{entries[1], 0}, // the highest version without a kvno
+		{entries[2], 2},
+	} {
+		ct, err := c.entry.EType.Encrypt(c.entry.Key, krb5.UsageTicket, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, err := krb5.DecryptWithKeytab(entries, c.entry.EType, c.kvno, krb5.UsageTicket, ct)
+		if err != nil {
+			t.Fatalf("%v kvno %d: %v", c.entry.EType, c.kvno, err)
+		}
+		if !bytes.Equal(pt, msg) {
+			t.Errorf("%v kvno %d: decryption failed", c.entry.EType, c.kvno)
+		}
+		if _, err := krb5.DecryptWithKeytab(entries, c.entry.EType, c.kvno, krb5.UsageASRepEncPart, ct); err != krb5.ErrIntegrity {
+			t.Errorf("%v kvno %d: wrong key usage not detected", c.entry.EType, c.kvno)
+		}
+	}
+
+	if _, err := krb5.DecryptWithKeytab(entries, krb5.AES128CTSHMACSHA196, 0, krb5.UsageTicket, nil); err == nil {
+		t.Errorf("missing key not detected")
+	}
+	if _, err := krb5.DecryptWithKeytab(entries, krb5.AES256CTSHMACSHA196, 3, krb5.UsageTicket, nil); err == nil {
+		t.Errorf("missing key version not detected")
+	}
+}
@@ -0,0 +1,54 @@
+/*
+	nfold.go
+	2026-10, github.com/mixcode
+*/
+
+package krb5
+
+// nfold stretches or folds the input to n bits, as defined in RFC 3961 section 5.1.
+// n must be a multiple of 8.
+func nfold(in []byte, n int) []byte {
+	inBits := len(in) * 8
+	l := lcm(n, inBits)
+
+	// concatenate copies of the input, each rotated right by 13 bits more than the previous one
+	buf := make([]byte, l/8)
+	for i := 0; i < l/inBits; i++ {
+		rot := 13 * i % inBits
+		for b := 0; b < inBits; b++ {
+			src := (b - rot + inBits) % inBits
+			if in[src/8]>>(7-uint(src%8))&1 != 0 {
+				pos := i*inBits + b
+				buf[pos/8] |= 1 << (7 - uint(pos%8))
+			}
+		}
+	}
+
+	// add the n-bit chunks with end-around carry (ones' complement addition)
+	out := make([]byte, n/8)
+	for off := 0; off < len(buf); off += n / 8 {
+		carry := 0
+		for i := n/8 - 1; i >= 0; i-- {
+			s := int(out[i]) + int(buf[off+i]) + carry
+			out[i] = byte(s)
+			carry = s >> 8
+		}
+		for i := n/8 - 1; carry != 0 && i >= 0; i-- {
+			s := int(out[i]) + carry
+			out[i] = byte(s)
+			carry = s >> 8
+		}
+	}
+	return out
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func lcm(a, b int) int {
+	return a / gcd(a, b) * b
+}
@@ -0,0 +1,160 @@
+/*
+	profile.go
+	2026-10, github.com/mixcode
+*/
+
+package krb5
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// ErrIntegrity is returned when the integrity check of a ciphertext fails.
+var ErrIntegrity = errors.New("krb5: integrity check failed")
+
+// key derivation constants of RFC 3961 section 5.3
+const (
+	usageKc = 0x99 // checksum key
+	usageKe = 0xaa // encryption key
+	usageKi = 0x55 // integrity key
+)
+
+// hmacSize is the size of the truncated HMAC-SHA1 of RFC 3962
+const hmacSize = 12
+
+// Encrypt encrypts plaintext with a base key for a key usage number.
+// A random confounder block is prepended to the plaintext, the result is encrypted in CBC-CTS mode with a zero IV, and a truncated HMAC is appended.
+// The RFC 3962 types authenticate the confounded plaintext (RFC 3961 section 5.3), and
+// the RFC 8009 types authenticate the IV and the ciphertext (RFC 8009 section 5).
+func (e EType) Encrypt(key []byte, usage uint32, plaintext []byte) ([]byte, error) {
+	return e.EncryptWithRand(nil, key, usage, plaintext)
+}
+
+// EncryptWithRand is Encrypt with the confounder read from random. See AddConfounder.
+func (e EType) EncryptWithRand(random io.Reader, key []byte, usage uint32, plaintext []byte) ([]byte, error) {
+	ke, ki, err := e.derivedKeys(key, usage)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := AddConfounder(random, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(ke)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(conf))
+	cbccts.NewCBCCTSEncrypter(block, make([]byte, aes.BlockSize), cbccts.CS3).CryptBlocks(out, conf)
+	return append(out, e.integrityHash(ki, conf, out)...), nil
+}
+
+// Decrypt decrypts a ciphertext made by Encrypt, checks its integrity, and returns the plaintext without the confounder.
+func (e EType) Decrypt(key []byte, usage uint32, ciphertext []byte) ([]byte, error) {
+	ke, ki, err := e.derivedKeys(key, usage)
+	if err != nil {
+		return nil, err
+	}
+	hsz := e.hashSize()
+	if len(ciphertext) < ConfounderSize+hsz {
+		return nil, fmt.Errorf("krb5: ciphertext too short")
+	}
+	body, h := ciphertext[:len(ciphertext)-hsz], ciphertext[len(ciphertext)-hsz:]
+
+	block, err := aes.NewCipher(ke)
+	if err != nil {
+		return nil, err
+	}
+	conf := make([]byte, len(body))
+	cbccts.NewCBCCTSDecrypter(block, make([]byte, aes.BlockSize), cbccts.CS3).CryptBlocks(conf, body)
+
+	if subtle.ConstantTimeCompare(h, e.integrityHash(ki, conf, body)) != 1 {
+		return nil, ErrIntegrity
+	}
+	return RemoveConfounder(conf)
+}
+
+// Verify checks the integrity of a ciphertext made by Encrypt, without returning the plaintext.
+func (e EType) Verify(key []byte, usage uint32, ciphertext []byte) error {
+	_, err := e.Decrypt(key, usage, ciphertext)
+	return err
+}
+
+// size of the integrity hash appended to a ciphertext
+func (e EType) hashSize() int {
+	if e.isSHA2() {
+		return e.macSize()
+	}
+	return hmacSize
+}
+
+// compute the integrity hash, over the confounded plaintext or the ciphertext depending on the type
+func (e EType) integrityHash(ki, plaintext, ciphertext []byte) []byte {
+	if e.isSHA2() {
+		mac := hmac.New(e.hash(), ki)
+		mac.Write(make([]byte, aes.BlockSize)) // the IV
+		mac.Write(ciphertext)
+		return mac.Sum(nil)[:e.macSize()]
+	}
+	mac := hmac.New(sha1.New, ki)
+	mac.Write(plaintext)
+	return mac.Sum(nil)[:hmacSize]
+}
+
+// derive the encryption key Ke and the integrity key Ki for a key usage
+func (e EType) derivedKeys(key []byte, usage uint32) (ke, ki []byte, err error) {
+	if err = e.checkKey(key); err != nil {
+		return
+	}
+	if ke, err = e.DeriveKey(key, usageConstant(usage, usageKe)); err != nil {
+		return
+	}
+	ki, err = e.DeriveKey(key, usageConstant(usage, usageKi))
+	return
+}
+
+// DeriveKey derives a key from a base key and a derivation constant, usually a key usage number followed by a purpose byte.
+// For the RFC 3962 types, the result is DK(key, constant) of RFC 3961.
+// For the RFC 8009 types, the result is KDF-HMAC-SHA2(key, constant, k), where k is the size of the truncated HMAC
+// if the constant ends with the integrity or checksum purpose byte, and the key size otherwise.
+func (e EType) DeriveKey(key, constant []byte) ([]byte, error) {
+	if err := e.checkKey(key); err != nil {
+		return nil, err
+	}
+	if !e.isSHA2() {
+		return deriveKey(key, constant)
+	}
+	size := len(key)
+	if len(constant) == 5 && (constant[4] == usageKi || constant[4] == usageKc) {
+		size = e.macSize()
+	}
+	return kdfHMACSHA2(e.hash(), key, constant, size), nil
+}
+
+// check the encryption type and the key size
+func (e EType) checkKey(key []byte) error {
+	if keysz := e.KeySize(); keysz == 0 {
+		return fmt.Errorf("krb5: unsupported encryption type %v", e)
+	} else if len(key) != keysz {
+		return fmt.Errorf("krb5: invalid key size %d for %v", len(key), e)
+	}
+	return nil
+}
+
+// the derivation constant: the key usage number followed by a byte for the purpose of the key
+func usageConstant(usage uint32, purpose byte) []byte {
+	c := make([]byte, 5)
+	binary.BigEndian.PutUint32(c, usage)
+	c[4] = purpose
+	return c
+}
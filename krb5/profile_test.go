@@ -0,0 +1,64 @@
+package krb5_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/krb5"
+)
+
+func TestProfile(t *testing.T) {
+
+	// confounder and truncated HMAC
+	overhead := map[krb5.EType]int{
+		krb5.AES128CTSHMACSHA196:    16 + 12,
+		krb5.AES256CTSHMACSHA196:    16 + 12,
+		krb5.AES128CTSHMACSHA256128: 16 + 16,
+		krb5.AES256CTSHMACSHA384192: 16 + 24,
+	}
+
+	for etype := range overhead {
+		key, err := etype.StringToKey("password", "EXAMPLE.COMuser", krb5.DefaultIterations)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, n := range []int{0, 1, 15, 16, 17, 31, 32, 100} {
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(i * 3)
+			}
+			enc, err := etype.Encrypt(key, 3, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(enc) != n+overhead[etype] {
+				t.Errorf("%v: unexpected ciphertext length %d for %d bytes", etype, len(enc), n)
+			}
+
+			// a random confounder makes every ciphertext different
+			enc2, _ := etype.Encrypt(key, 3, data)
+			if bytes.Equal(enc, enc2) {
+				t.Errorf("%v: ciphertext repeated", etype)
+			}
+
+			dec, err := etype.Decrypt(key, 3, enc)
+			if err != nil || !bytes.Equal(dec, data) {
+				t.Errorf("%v: decryption failed for %d bytes: %v", etype, n, err)
+			}
+
+			// the key usage is bound to the ciphertext
+			if err := etype.Verify(key, 4, enc); err != krb5.ErrIntegrity {
+				t.Errorf("%v: wrong key usage not detected", etype)
+			}
+			enc[0] ^= 1
+			if err := etype.Verify(key, 3, enc); err != krb5.ErrIntegrity {
+				t.Errorf("%v: altered ciphertext not detected", etype)
+			}
+		}
+
+		if _, err := etype.Encrypt(key[:8], 3, nil); err == nil {
+			t.Errorf("%v: invalid key accepted", etype)
+		}
+	}
+}
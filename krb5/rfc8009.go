@@ -0,0 +1,44 @@
+/*
+	rfc8009.go
+	2026-10, github.com/mixcode
+*/
+
+package krb5
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+)
+
+// the hash function of an RFC 8009 encryption type
+func (e EType) hash() func() hash.Hash {
+	if e == AES256CTSHMACSHA384192 {
+		return sha512.New384
+	}
+	return sha256.New
+}
+
+// sizes of the RFC 8009 derived keys Ki and Kc, which is also the size of the truncated HMAC
+func (e EType) macSize() int {
+	if e == AES256CTSHMACSHA384192 {
+		return 24
+	}
+	return 16
+}
+
+// KDF-HMAC-SHA2(key, label, k) of RFC 8009 section 3: the counter-mode KDF of SP 800-108 with a single iteration.
+// size is in bytes.
+func kdfHMACSHA2(h func() hash.Hash, key, label []byte, size int) []byte {
+	var buf [4]byte
+	mac := hmac.New(h, key)
+	binary.BigEndian.PutUint32(buf[:], 1)
+	mac.Write(buf[:])
+	mac.Write(label)
+	mac.Write([]byte{0})
+	binary.BigEndian.PutUint32(buf[:], uint32(size*8))
+	mac.Write(buf[:])
+	return mac.Sum(nil)[:size]
+}
@@ -0,0 +1,69 @@
+package krb5
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestRFC8009(t *testing.T) {
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// RFC 8009 appendix A, string-to-key
+	salt := string(unhex("10df9dd783e5bc8acea1730e74355f61")) + "ATHENA.MIT.EDUraeburn"
+	key, err := AES128CTSHMACSHA256128.StringToKey("password", salt, DefaultIterationsSHA2)
+	if err != nil || !bytes.Equal(key, unhex("089bca48b105ea6ea77ca5d2f39dc5e7")) {
+		t.Errorf("string-to-key 19: got %x, %v", key, err)
+	}
+	key, err = AES256CTSHMACSHA384192.StringToKey("password", salt, DefaultIterationsSHA2)
+	if err != nil || !bytes.Equal(key, unhex("45bd806dbf6a833a9cffc1c94589a222367a79bc21c413718906e9f578a78467")) {
+		t.Errorf("string-to-key 20: got %x, %v", key, err)
+	}
+
+	// RFC 8009 appendix A, key derivation with key usage 2
+	ke, ki, err := AES128CTSHMACSHA256128.derivedKeys(unhex("3705d96080c17728a0e800eab6e0d23c"), 2)
+	if err != nil || !bytes.Equal(ke, unhex("9b197dd1e8c5609d6e67c3e37c62c72e")) || !bytes.Equal(ki, unhex("9fda0e56ab2d85e1569a688696c26a6c")) {
+		t.Errorf("key derivation 19: got %x, %x, %v", ke, ki, err)
+	}
+}
+
+func TestRFC8009Encrypt(t *testing.T) {
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// RFC 8009 appendix A, encryption of an empty plaintext with key usage 2
+	confounder := bytes.NewReader(unhex("7e5895eaf2672435bad817f545a37148"))
+	enc, err := AES128CTSHMACSHA256128.EncryptWithRand(confounder, unhex("3705d96080c17728a0e800eab6e0d23c"), 2, nil)
+	expect := unhex("ef85fb890bb8472f4dab20394dca781dad877eda39d50c870c0d5a0a8e48c718")
+	if err != nil || !bytes.Equal(enc, expect) {
+		t.Errorf("encrypt 19: expected %x, got %x, %v", expect, enc, err)
+	}
+}
+
+func TestRFC8009Checksum(t *testing.T) {
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// RFC 8009 appendix A, checksums with key usage 2
+	data := unhex("000102030405060708090a0b0c0d0e0f1011121314")
+	sum, err := AES128CTSHMACSHA256128.Checksum(unhex("3705d96080c17728a0e800eab6e0d23c"), 2, data)
+	if expect := unhex("d78367186643d67b411cba9139fc1dee"); err != nil || !bytes.Equal(sum, expect) {
+		t.Errorf("checksum 19: expected %x, got %x, %v", expect, sum, err)
+	}
+}
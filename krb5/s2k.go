@@ -0,0 +1,107 @@
+/*
+	s2k.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package krb5 implements the Kerberos 5 encryption types based on AES in CBC-CTS mode.
+
+	See RFC 3961 (the simplified profile), RFC 3962 (AES encryption for Kerberos 5) and RFC 8009 (AES with HMAC-SHA2) for info.
+*/
+package krb5
+
+import (
+	"crypto/aes"
+	"crypto/sha1"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// EType is a Kerberos encryption type number.
+type EType int32
+
+const (
+	AES128CTSHMACSHA196    EType = 17 // aes128-cts-hmac-sha1-96, RFC 3962
+	AES256CTSHMACSHA196    EType = 18 // aes256-cts-hmac-sha1-96, RFC 3962
+	AES128CTSHMACSHA256128 EType = 19 // aes128-cts-hmac-sha256-128, RFC 8009
+	AES256CTSHMACSHA384192 EType = 20 // aes256-cts-hmac-sha384-192, RFC 8009
+)
+
+// Default PBKDF2 iteration counts of the string-to-key functions.
+const (
+	DefaultIterations     = 4096  // RFC 3962 encryption types
+	DefaultIterationsSHA2 = 32768 // RFC 8009 encryption types
+)
+
+func (e EType) String() string {
+	switch e {
+	case AES128CTSHMACSHA196:
+		return "aes128-cts-hmac-sha1-96"
+	case AES256CTSHMACSHA196:
+		return "aes256-cts-hmac-sha1-96"
+	case AES128CTSHMACSHA256128:
+		return "aes128-cts-hmac-sha256-128"
+	case AES256CTSHMACSHA384192:
+		return "aes256-cts-hmac-sha384-192"
+	}
+	return fmt.Sprintf("etype(%d)", int32(e))
+}
+
+// KeySize returns the size of a key of the encryption type in bytes, or 0 if the type is not supported.
+func (e EType) KeySize() int {
+	switch e {
+	case AES128CTSHMACSHA196, AES128CTSHMACSHA256128:
+		return 16
+	case AES256CTSHMACSHA196, AES256CTSHMACSHA384192:
+		return 32
+	}
+	return 0
+}
+
+// is the type one of the RFC 8009 encryption types
+func (e EType) isSHA2() bool {
+	return e == AES128CTSHMACSHA256128 || e == AES256CTSHMACSHA384192
+}
+
+// StringToKey derives a key from a password and a salt (usually the realm followed by the principal name components).
+// For the RFC 3962 types, the key is DK(PBKDF2-HMAC-SHA1(password, salt, iterations), "kerberos") as defined in RFC 3962 section 4.
+// For the RFC 8009 types, the key is KDF-HMAC-SHA2(PBKDF2-HMAC-SHA2(password, etype name || 0 || salt, iterations), "kerberos")
+// as defined in RFC 8009 section 4.
+func (e EType) StringToKey(password, salt string, iterations int) ([]byte, error) {
+	keysz := e.KeySize()
+	if keysz == 0 {
+		return nil, fmt.Errorf("krb5: unsupported encryption type %v", e)
+	}
+	if iterations <= 0 {
+		return nil, fmt.Errorf("krb5: invalid iteration count %d", iterations)
+	}
+	if e.isSHA2() {
+		saltp := append([]byte(e.String()+"\x00"), salt...)
+		tkey := pbkdf2.Key([]byte(password), saltp, iterations, keysz, e.hash())
+		return kdfHMACSHA2(e.hash(), tkey, []byte("kerberos"), keysz), nil
+	}
+	tkey := pbkdf2.Key([]byte(password), []byte(salt), iterations, keysz, sha1.New)
+	return deriveKey(tkey, []byte("kerberos"))
+}
+
+// DK(key, constant) of RFC 3961; for AES, random-to-key is the identity function.
+func deriveKey(key, constant []byte) ([]byte, error) {
+	return deriveRandom(key, constant, len(key))
+}
+
+// DR(key, constant) of RFC 3961: the n-folded constant is encrypted repeatedly, and the outputs are concatenated.
+func deriveRandom(key, constant []byte, size int) ([]byte, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	k := nfold(constant, aes.BlockSize*8)
+	out := make([]byte, 0, size+aes.BlockSize)
+	for len(out) < size {
+		// a single block in CBC-CTS with a zero IV is plain encryption
+		b.Encrypt(k, k)
+		out = append(out, k...)
+	}
+	return out[:size], nil
+}
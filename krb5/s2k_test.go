@@ -0,0 +1,49 @@
+package krb5_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/krb5"
+)
+
+func TestStringToKey(t *testing.T) {
+
+	// RFC 3962 appendix B
+	testCase := []struct {
+		iterations     int
+		password, salt string
+		aes128, aes256 string
+	}{
+		{1, "password", "ATHENA.MIT.EDUraeburn",
+			"42263c6e89f4fc28b8df68ee09799f15",
+			"fe697b52bc0d3ce14432ba036a92e65bbb52280990a2fa27883998d72af30161"},
+		{2, "password", "ATHENA.MIT.EDUraeburn",
+			"c651bf29e2300ac27fa469d693bdda13",
+			"a2e16d16b36069c135d5e9d2e25f896102685618b95914b467c67622225824ff"},
+		{1200, "password", "ATHENA.MIT.EDUraeburn",
+			"4c01cd46d632d01e6dbe230a01ed642a",
+			"55a6ac740ad17b4846941051e1e8b0a7548d93b0ab30a8bc3ff16280382b8c2a"},
+		{1200, "XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX", "pass phrase equals block size",
+			"59d1bb789a828b1aa54ef9c2883f69ed",
+			"89adee3608db8bc71f1bfbfe459486b05618b70cbae22092534e56c553ba4b34"},
+	}
+	for i, c := range testCase {
+		for _, e := range []struct {
+			etype  krb5.EType
+			expect string
+		}{{krb5.AES128CTSHMACSHA196, c.aes128}, {krb5.AES256CTSHMACSHA196, c.aes256}} {
+			key, err := e.etype.StringToKey(c.password, c.salt, c.iterations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(key); got != e.expect {
+				t.Errorf("case %d, %v: expected %s, got %s", i, e.etype, e.expect, got)
+			}
+		}
+	}
+
+	if _, err := krb5.EType(23).StringToKey("password", "salt", 1); err == nil {
+		t.Errorf("unsupported encryption type accepted")
+	}
+}
@@ -0,0 +1,88 @@
+/*
+	timestamp.go
+	2026-10, github.com/mixcode
+*/
+
+package krb5
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PADataEncTimestamp is the padata-type of PA-ENC-TIMESTAMP (RFC 4120 section 7.5.2).
+const PADataEncTimestamp = 2
+
+// ErrClockSkew is returned when an encrypted timestamp is out of the allowed clock skew.
+var ErrClockSkew = errors.New("krb5: clock skew too great")
+
+// EncryptedData is the EncryptedData structure of RFC 4120 section 5.2.9.
+type EncryptedData struct {
+	EType  int32  `asn1:"explicit,tag:0"`
+	KVNO   int64  `asn1:"optional,explicit,tag:1"` // 0 if absent
+	Cipher []byte `asn1:"explicit,tag:2"`
+}
+
+// PA-ENC-TS-ENC of RFC 4120 section 5.2.7.2
+type paEncTSEnc struct {
+	PATimestamp time.Time `asn1:"generalized,explicit,tag:0"`
+	PAUSec      int       `asn1:"optional,explicit,tag:1"`
+}
+
+// EncryptTimestamp builds the padata-value of a PA-ENC-TIMESTAMP: the DER encoding of an EncryptedData
+// that holds a PA-ENC-TS-ENC of t, encrypted with the client key for the key usage 1.
+// kvno may be 0 to omit the key version number.
+func (e EType) EncryptTimestamp(key []byte, kvno uint32, t time.Time) ([]byte, error) {
+	t = t.UTC()
+	ts, err := asn1.Marshal(paEncTSEnc{
+		PATimestamp: t.Truncate(time.Second),
+		PAUSec:      t.Nanosecond() / 1000,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ct, err := e.Encrypt(key, UsagePAEncTimestamp, ts)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(EncryptedData{EType: int32(e), KVNO: int64(kvno), Cipher: ct})
+}
+
+// DecryptTimestamp decrypts the padata-value of a PA-ENC-TIMESTAMP made by EncryptTimestamp, and returns the timestamp.
+func (e EType) DecryptTimestamp(key []byte, padata []byte) (time.Time, error) {
+	var ed EncryptedData
+	if rest, err := asn1.Unmarshal(padata, &ed); err != nil {
+		return time.Time{}, err
+	} else if len(rest) != 0 {
+		return time.Time{}, fmt.Errorf("krb5: trailing data after EncryptedData")
+	}
+	if EType(ed.EType) != e {
+		return time.Time{}, fmt.Errorf("krb5: encryption type mismatch: %v", EType(ed.EType))
+	}
+	pt, err := e.Decrypt(key, UsagePAEncTimestamp, ed.Cipher)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var ts paEncTSEnc
+	if _, err := asn1.Unmarshal(pt, &ts); err != nil {
+		return time.Time{}, err
+	}
+	if ts.PAUSec < 0 || ts.PAUSec > 999999 {
+		return time.Time{}, fmt.Errorf("krb5: invalid microseconds %d", ts.PAUSec)
+	}
+	return ts.PATimestamp.Add(time.Duration(ts.PAUSec) * time.Microsecond), nil
+}
+
+// VerifyTimestamp decrypts a PA-ENC-TIMESTAMP, as a KDC does, and checks that the timestamp is within skew of now.
+func (e EType) VerifyTimestamp(key []byte, padata []byte, now time.Time, skew time.Duration) error {
+	t, err := e.DecryptTimestamp(key, padata)
+	if err != nil {
+		return err
+	}
+	if d := now.Sub(t); d > skew || d < -skew {
+		return ErrClockSkew
+	}
+	return nil
+}
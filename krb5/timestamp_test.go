@@ -0,0 +1,51 @@
+package krb5_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mixcode/golib-cbccts/krb5"
+)
+
+func TestTimestamp(t *testing.T) {
+
+	now := time.Date(2026, 10, 15, 9, 30, 15, 123456789, time.UTC)
+	for _, etype := range []krb5.EType{krb5.AES128CTSHMACSHA196, krb5.AES256CTSHMACSHA384192} {
+		key, err := etype.StringToKey("password", "EXAMPLE.COMuser", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		padata, err := etype.EncryptTimestamp(key, 2, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ts, err := etype.DecryptTimestamp(key, padata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ts.Equal(now.Truncate(time.Microsecond)) {
+			t.Errorf("%v: unexpected timestamp %v", etype, ts)
+		}
+
+		if err := etype.VerifyTimestamp(key, padata, now.Add(4*time.Minute), 5*time.Minute); err != nil {
+			t.Errorf("%v: %v", etype, err)
+		}
+		if err := etype.VerifyTimestamp(key, padata, now.Add(-6*time.Minute), 5*time.Minute); err != krb5.ErrClockSkew {
+			t.Errorf("%v: clock skew not detected", etype)
+		}
+
+		wrong := bytes.Repeat([]byte{1}, etype.KeySize())
+		if err := etype.VerifyTimestamp(wrong, padata, now, 5*time.Minute); err != krb5.ErrIntegrity {
+			t.Errorf("%v: wrong key not detected", etype)
+		}
+	}
+
+	// the encryption type of the EncryptedData must match
+	key := make([]byte, 16)
+	padata, _ := krb5.AES128CTSHMACSHA196.EncryptTimestamp(key, 0, now)
+	if _, err := krb5.AES128CTSHMACSHA256128.DecryptTimestamp(key, padata); err == nil {
+		t.Errorf("encryption type mismatch not detected")
+	}
+}
@@ -0,0 +1,125 @@
+/*
+	kwp.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package kwp implements AES Key Wrap with Padding (RFC 5649, NIST SP 800-38F KWP).
+
+	KWP wraps keys and other short secrets of any length from one byte, with integrity.
+	It complements CBC-CTS, which has neither integrity nor a path for data shorter than a block.
+*/
+package kwp
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	blockSize = 16
+	semiBlock = 8
+)
+
+// ErrUnwrap is returned when a wrapped key fails the integrity check.
+var ErrUnwrap = errors.New("kwp: integrity check failed")
+
+// the alternative initial value of RFC 5649
+var aivPrefix = []byte{0xa6, 0x59, 0x59, 0xa6}
+
+// Wrap wraps plaintext with the key-encryption cipher b, which must have a 128-bit block (e.g. AES).
+// The result is the plaintext length padded up to a multiple of 8, plus 8 bytes.
+func Wrap(b cipher.Block, plaintext []byte) ([]byte, error) {
+	if b.BlockSize() != blockSize {
+		return nil, fmt.Errorf("kwp: cipher does not have a block size of %d", blockSize)
+	}
+	mli := len(plaintext)
+	if mli == 0 || uint64(mli) > math.MaxUint32 {
+		return nil, fmt.Errorf("kwp: invalid plaintext length %d", mli)
+	}
+	padded := (mli + semiBlock - 1) / semiBlock * semiBlock
+
+	out := make([]byte, semiBlock+padded)
+	copy(out, aivPrefix)
+	binary.BigEndian.PutUint32(out[4:8], uint32(mli))
+	copy(out[semiBlock:], plaintext)
+
+	if padded == semiBlock {
+		// a single semiblock is encrypted with AIV as a block
+		b.Encrypt(out, out)
+		return out, nil
+	}
+
+	// the wrapping process W of RFC 3394
+	n := padded / semiBlock
+	var buf [blockSize]byte
+	a := out[:semiBlock]
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			r := out[i*semiBlock : (i+1)*semiBlock]
+			copy(buf[:semiBlock], a)
+			copy(buf[semiBlock:], r)
+			b.Encrypt(buf[:], buf[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(buf[:semiBlock])^t)
+			copy(r, buf[semiBlock:])
+		}
+	}
+	return out, nil
+}
+
+// Unwrap unwraps a ciphertext made by Wrap, and checks its integrity.
+func Unwrap(b cipher.Block, ciphertext []byte) ([]byte, error) {
+	if b.BlockSize() != blockSize {
+		return nil, fmt.Errorf("kwp: cipher does not have a block size of %d", blockSize)
+	}
+	if len(ciphertext) < blockSize || len(ciphertext)%semiBlock != 0 {
+		return nil, ErrUnwrap
+	}
+	out := make([]byte, len(ciphertext))
+	copy(out, ciphertext)
+
+	n := len(out)/semiBlock - 1
+	if n == 1 {
+		b.Decrypt(out, out)
+	} else {
+		// the unwrapping process W^-1 of RFC 3394
+		var buf [blockSize]byte
+		a := out[:semiBlock]
+		for j := 5; j >= 0; j-- {
+			for i := n; i >= 1; i-- {
+				r := out[i*semiBlock : (i+1)*semiBlock]
+				t := uint64(n*j + i)
+				binary.BigEndian.PutUint64(buf[:semiBlock], binary.BigEndian.Uint64(a)^t)
+				copy(buf[semiBlock:], r)
+				b.Decrypt(buf[:], buf[:])
+				copy(a, buf[:semiBlock])
+				copy(r, buf[semiBlock:])
+			}
+		}
+	}
+
+	// check the AIV and the padding
+	mli := int(binary.BigEndian.Uint32(out[4:8]))
+	ok := subtle.ConstantTimeCompare(out[:4], aivPrefix)
+	if mli <= semiBlock*(n-1) || mli > semiBlock*n {
+		ok = 0
+	} else {
+		var pad byte
+		for _, v := range out[semiBlock+mli:] {
+			pad |= v
+		}
+		ok &= subtle.ConstantTimeByteEq(pad, 0)
+	}
+	if ok != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, ErrUnwrap
+	}
+	return out[semiBlock : semiBlock+mli], nil
+}
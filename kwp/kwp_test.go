@@ -0,0 +1,70 @@
+package kwp_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/kwp"
+)
+
+func TestKWP(t *testing.T) {
+
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// RFC 5649 section 6 examples
+	kek, err := aes.NewCipher(unhex("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCase := []struct {
+		key, wrapped string
+	}{
+		{"c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a"},
+		{"466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f"},
+	}
+	for i, c := range testCase {
+		wrapped, err := kwp.Wrap(kek, unhex(c.key))
+		if err != nil || !bytes.Equal(wrapped, unhex(c.wrapped)) {
+			t.Errorf("wrap failed: case %d, expected %s, got %x, %v", i, c.wrapped, wrapped, err)
+		}
+		key, err := kwp.Unwrap(kek, unhex(c.wrapped))
+		if err != nil || !bytes.Equal(key, unhex(c.key)) {
+			t.Errorf("unwrap failed: case %d, %v", i, err)
+		}
+	}
+
+	// round trip and tamper detection over lengths
+	for n := 1; n < 70; n++ {
+		key := make([]byte, n)
+		for i := range key {
+			key[i] = byte(i * 5)
+		}
+		wrapped, err := kwp.Wrap(kek, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(wrapped) != (n+7)/8*8+8 {
+			t.Errorf("unexpected wrapped length: length %d", n)
+		}
+		unwrapped, err := kwp.Unwrap(kek, wrapped)
+		if err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("round trip failed: length %d, %v", n, err)
+		}
+		wrapped[len(wrapped)-1] ^= 1
+		if _, err := kwp.Unwrap(kek, wrapped); err != kwp.ErrUnwrap {
+			t.Errorf("altered ciphertext not detected: length %d", n)
+		}
+	}
+
+	if _, err := kwp.Wrap(kek, nil); err == nil {
+		t.Errorf("empty plaintext accepted")
+	}
+}
@@ -0,0 +1,142 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/internal/ref"
+)
+
+// a toy large-block cipher in the place of Threefish: AES lanes chained within the block, so that
+// every byte of the output depends on the preceding ones
+type laneBlock struct {
+	b cipher.Block
+	n int
+}
+
+func newLaneBlock(key []byte, size int) (cipher.Block, error) {
+	if size%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("block size %d is not a multiple of the AES block", size)
+	}
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &laneBlock{b, size}, nil
+}
+
+func (l *laneBlock) BlockSize() int { return l.n }
+
+func (l *laneBlock) Encrypt(dst, src []byte) {
+	var prev [aes.BlockSize]byte
+	for i := 0; i < l.n; i += aes.BlockSize {
+		var x [aes.BlockSize]byte
+		for j := range x {
+			x[j] = src[i+j] ^ prev[j]
+		}
+		l.b.Encrypt(dst[i:i+aes.BlockSize], x[:])
+		copy(prev[:], dst[i:i+aes.BlockSize])
+	}
+}
+
+func (l *laneBlock) Decrypt(dst, src []byte) {
+	for i := l.n - aes.BlockSize; i >= 0; i -= aes.BlockSize {
+		var x [aes.BlockSize]byte
+		l.b.Decrypt(x[:], src[i:i+aes.BlockSize])
+		if i > 0 {
+			for j := range x {
+				x[j] ^= src[i-aes.BlockSize+j]
+			}
+		}
+		copy(dst[i:i+aes.BlockSize], x[:])
+	}
+}
+
+// block sizes of Threefish-256, -512 and -1024
+func TestLargeBlock(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(405))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rnd.Read(b)
+		return b
+	}
+
+	for _, bs := range []int{32, 64, 128} {
+		b, _ := newLaneBlock(random(16), bs)
+		iv := random(bs)
+
+		// every length up to 4 blocks and a block less, the tails close to the block size included
+		for n := bs; n < 5*bs; n++ {
+			pt := random(n)
+			for _, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+				ct := make([]byte, n)
+				cbccts.NewCBCCTSEncrypter(b, iv, f).CryptBlocks(ct, pt)
+				if !bytes.Equal(ct, ref.Encrypt(b, iv, f, pt)) {
+					t.Fatalf("block size %d, CS%d, %d bytes: encryption differs from the reference", bs, f, n)
+				}
+				if !bytes.Equal(cbccts.CBCResidue(ct, bs, f), ref.Encrypt(b, iv, cbccts.CS1, append(pt, make([]byte, (bs-n%bs)%bs)...))[(n+bs-1)/bs*bs-bs:]) {
+					t.Fatalf("block size %d, CS%d, %d bytes: unexpected residue", bs, f, n)
+				}
+				out := make([]byte, n)
+				cbccts.NewCBCCTSDecrypter(b, iv, f).CryptBlocks(out, ct)
+				if !bytes.Equal(out, pt) {
+					t.Fatalf("block size %d, CS%d, %d bytes: decryption failed", bs, f, n)
+				}
+				cbccts.WithScratch(cbccts.NewCBCCTSDecrypter(b, iv, f), make([]byte, cbccts.ScratchSize(bs))).CryptBlocks(ct, ct)
+				if !bytes.Equal(ct, pt) {
+					t.Fatalf("block size %d, CS%d, %d bytes: in-place decryption with a scratch buffer failed", bs, f, n)
+				}
+			}
+		}
+
+		// a stream and the parallel decrypter, over a tail of a block less a byte
+		pt := random(200*1024*bs/32 - 1)
+		var buf bytes.Buffer
+		w := cbccts.NewWriter(&buf, cbccts.NewCBCCTSEncrypter(b, iv, cbccts.CS3))
+		w.Write(pt)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		ct := append([]byte(nil), buf.Bytes()...)
+		if out, err := io.ReadAll(cbccts.NewReader(&buf, cbccts.NewCBCCTSParallelDecrypter(b, iv, cbccts.CS3, 4))); err != nil || !bytes.Equal(out, pt) {
+			t.Fatalf("block size %d: stream decryption failed: %v", bs, err)
+		}
+		cbccts.NewCBCCTSParallelDecrypter(b, iv, cbccts.CS3, 4).CryptBlocks(ct, ct)
+		if !bytes.Equal(ct, pt) {
+			t.Fatalf("block size %d: parallel decryption failed", bs)
+		}
+
+		// IVs from nonces longer than the HMAC: HKDF-Expand of the HMAC
+		ivKey, nonce := []byte("an IV derivation key"), []byte("nonce")
+		m := hmac.New(sha256.New, ivKey)
+		m.Write([]byte("cbccts IV"))
+		m.Write(nonce)
+		nonceIV := m.Sum(nil)
+		if bs > len(nonceIV) {
+			nonceIV = make([]byte, bs)
+			io.ReadFull(hkdf.Expand(sha256.New, m.Sum(nil), []byte("cbccts IV")), nonceIV)
+		}
+		enc, err := cbccts.NewEncrypterFromNonce(b, ivKey, nonce, cbccts.CS3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := random(bs + 1)
+		ct, expect := make([]byte, len(msg)), make([]byte, len(msg))
+		enc.CryptBlocks(ct, msg)
+		cbccts.NewCBCCTSEncrypter(b, nonceIV[:bs], cbccts.CS3).CryptBlocks(expect, msg)
+		if !bytes.Equal(ct, expect) {
+			t.Errorf("block size %d: unexpected IV derivation", bs)
+		}
+	}
+}
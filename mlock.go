@@ -0,0 +1,44 @@
+/*
+	mlock.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrNoLockedMemory is returned by WithLockedMemory when locked memory is not available:
+// the package is built without the mlock build tag, or the platform does not support it.
+var ErrNoLockedMemory = errors.New("cbccts: locked memory not available; build with -tags mlock on a unix platform")
+
+// WithLockedMemory moves the scratch buffers and the chaining value of a codec made by this package into memory locked
+// with mlock, so that the plaintext passing through them is never swapped to disk, and returns the codec.
+// The memory is released by Wipe or Close, which the caller must call.
+//
+// The key is not covered: the key schedule of the block cipher, and the copy of the chaining value inside the CBC mode
+// of the standard library, stay in ordinary memory. Keys that must not be swapped are better kept in a process that locks
+// all of its memory with mlockall, or has swap disabled.
+//
+// Locked memory is only available with the mlock build tag on unix platforms, and is subject to RLIMIT_MEMLOCK.
+func WithLockedMemory(m cipher.BlockMode) (cipher.BlockMode, error) {
+	cd := codecOf(m)
+	bs := cd.BlockSize()
+	buf, release, err := lockedAlloc(ScratchSize(bs) + bs)
+	if err != nil {
+		return nil, err
+	}
+	// the chaining value follows the scratch; it moves before the memory holding it may be released
+	state := buf[ScratchSize(bs):]
+	copy(state, cd.state)
+	zero(cd.state)
+	cd.setState(state)
+	if cd.release != nil {
+		cd.release()
+	}
+	cd.ext = buf[:ScratchSize(bs)]
+	cd.release = release
+	return m, nil
+}
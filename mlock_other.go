@@ -0,0 +1,13 @@
+//go:build !mlock || !(linux || darwin || freebsd || netbsd || openbsd)
+// +build !mlock !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+/*
+	mlock_other.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+func lockedAlloc(n int) ([]byte, func(), error) {
+	return nil, nil, ErrNoLockedMemory
+}
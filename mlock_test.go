@@ -0,0 +1,56 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestLockedMemory(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	msg := []byte("a message through locked scratch memory")
+
+	enc, err := cbccts.WithLockedMemory(cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3))
+	if err == cbccts.ErrNoLockedMemory {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Skip("mlock failed:", err) // e.g. RLIMIT_MEMLOCK
+	}
+	defer enc.(*cbccts.Encrypter).Close()
+	dec, err := cbccts.WithLockedMemory(cbccts.NewCBCCTSDecrypter(ac, iv, cbccts.CS3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.(*cbccts.Decrypter).Close()
+
+	out := make([]byte, len(msg))
+	enc.CryptBlocks(out, msg)
+	dec.CryptBlocks(out, out)
+	if !bytes.Equal(out, msg) {
+		t.Errorf("decryption failed")
+	}
+
+	// the chaining value moves into the locked memory, also when locked again, and carries on from call to call
+	plain := cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS1)
+	locked := cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS1)
+	aligned := msg[:2*aes.BlockSize]
+	want, got := make([]byte, len(aligned)), make([]byte, len(aligned))
+	plain.CryptBlocks(want, aligned)
+	locked.CryptBlocks(got, aligned)
+	for i := 0; i < 2; i++ {
+		if locked, err = cbccts.WithLockedMemory(locked); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer locked.(*cbccts.Encrypter).Close()
+	plain.CryptBlocks(want, aligned)
+	locked.CryptBlocks(got, aligned)
+	if !bytes.Equal(got, want) || !bytes.Equal(locked.(*cbccts.Encrypter).CipherState(), plain.(*cbccts.Encrypter).CipherState()) {
+		t.Errorf("the chaining value is lost in locked memory")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build mlock && (linux || darwin || freebsd || netbsd || openbsd)
+// +build mlock
+// +build linux darwin freebsd netbsd openbsd
+
+/*
+	mlock_unix.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"syscall"
+)
+
+// allocate n bytes of anonymous memory locked into RAM
+func lockedAlloc(n int) ([]byte, func(), error) {
+	b, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := syscall.Mlock(b); err != nil {
+		syscall.Munmap(b)
+		return nil, nil, err
+	}
+	release := func() {
+		zero(b)
+		syscall.Munlock(b)
+		syscall.Munmap(b)
+	}
+	return b, release, nil
+}
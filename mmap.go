@@ -0,0 +1,124 @@
+/*
+	mmap.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// ErrNoMmap is returned by EncryptFileInPlace and DecryptFileInPlace on platforms without memory-mapped files.
+var ErrNoMmap = errors.New("cbccts: memory-mapped files not available on this platform")
+
+// EncryptFileInPlace encrypts a whole file in place, mapping it into memory, so that a huge file needs neither a second file
+// nor twice the disk space. CBC encryption is serial, so this runs on one goroutine.
+// The file must be opened for reading and writing. An interrupted call leaves the file partly encrypted, with no way
+// to tell where it stopped; keep a copy of anything that cannot be lost.
+func EncryptFileInPlace(f *os.File, b cipher.Block, iv []byte, mode Format) error {
+	return cryptFileInPlace(f, b, iv, mode, func(data []byte) {
+		NewCBCCTSEncrypter(b, iv, mode).CryptBlocks(data, data)
+	})
+}
+
+// DecryptFileInPlace decrypts a whole file in place, as EncryptFileInPlace, with segments of the file decrypted concurrently
+// on up to workers goroutines; 0 means runtime.GOMAXPROCS(0).
+func DecryptFileInPlace(f *os.File, b cipher.Block, iv []byte, mode Format, workers int) error {
+	return cryptFileInPlace(f, b, iv, mode, func(data []byte) {
+		decryptInPlace(data, b, iv, mode, workers)
+	})
+}
+
+func cryptFileInPlace(f *os.File, b cipher.Block, iv []byte, mode Format, crypt func([]byte)) error {
+	if mode < CS1 || mode > CS3 {
+		return fmt.Errorf("invalid mode")
+	}
+	if len(iv) != b.BlockSize() {
+		return fmt.Errorf("IV length must equal block size")
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := fi.Size()
+	if size < int64(b.BlockSize()) {
+		return fmt.Errorf("data size too small; must be larger than one block")
+	}
+	if int64(int(size)) != size {
+		return fmt.Errorf("file too large to map")
+	}
+	data, unmap, err := mapFile(f, int(size))
+	if err != nil {
+		return err
+	}
+	crypt(data)
+	if err := unmap(); err != nil {
+		return err
+	}
+	// the pages of a shared mapping are in the page cache of the file
+	return f.Sync()
+}
+
+// decrypt CBC-CTS ciphertext in place, in segments on concurrent goroutines
+func decryptInPlace(data []byte, b cipher.Block, iv []byte, mode Format, workers int) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// the aligned prefix is plain CBC; the last two blocks, with the partial block if any, are decrypted as CTS
+	blocksz := b.BlockSize()
+	prefix := len(data) - len(data)%blocksz - 2*blocksz
+	if prefix < 0 {
+		prefix = 0
+	}
+
+	// a segment starts from the last ciphertext block of the preceding one, which is saved before it is decrypted over
+	type segment struct {
+		off, end int
+		iv       []byte
+	}
+	var segs []segment
+	add := func(off, end int) {
+		segiv := iv
+		if off > 0 {
+			segiv = append([]byte(nil), data[off-blocksz:off]...)
+		}
+		segs = append(segs, segment{off, end, segiv})
+	}
+	for off := 0; off < prefix; off += fileSegmentSize {
+		end := off + fileSegmentSize
+		if end > prefix {
+			end = prefix
+		}
+		add(off, end)
+	}
+	add(prefix, len(data))
+
+	ch := make(chan segment)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range ch {
+				seg := data[s.off:s.end]
+				if s.end == len(data) {
+					NewCBCCTSDecrypter(b, s.iv, mode).CryptBlocks(seg, seg)
+				} else {
+					newCBCDecrypter(b, s.iv).CryptBlocks(seg, seg)
+				}
+			}
+		}()
+	}
+	for _, s := range segs {
+		ch <- s
+	}
+	close(ch)
+	wg.Wait()
+}
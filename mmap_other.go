@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+/*
+	mmap_other.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"os"
+)
+
+func mapFile(f *os.File, size int) ([]byte, func() error, error) {
+	return nil, nil, ErrNoMmap
+}
@@ -0,0 +1,65 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestFileInPlace(t *testing.T) {
+
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(key)
+	rand.Read(iv)
+	ac, _ := aes.NewCipher(key)
+	name := filepath.Join(t.TempDir(), "data")
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		for _, n := range []int{16, 33, 64, 4 << 20, 9<<20 + 3, 9<<20 + 32} {
+			pt := make([]byte, n)
+			rand.Read(pt)
+			if err := os.WriteFile(name, pt, 0600); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.OpenFile(name, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = cbccts.EncryptFileInPlace(f, ac, iv, mode)
+			if err == cbccts.ErrNoMmap {
+				f.Close()
+				t.Skip(err)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			expect := make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(ac, iv, mode).CryptBlocks(expect, pt)
+			if ct, _ := os.ReadFile(name); !bytes.Equal(ct, expect) {
+				t.Errorf("CS%d, %d bytes: unexpected ciphertext", mode, n)
+			}
+
+			if err := cbccts.DecryptFileInPlace(f, ac, iv, mode, 3); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			if out, _ := os.ReadFile(name); !bytes.Equal(out, pt) {
+				t.Errorf("CS%d, %d bytes: decryption failed", mode, n)
+			}
+		}
+	}
+
+	// too short
+	os.WriteFile(name, make([]byte, 15), 0600)
+	f, _ := os.OpenFile(name, os.O_RDWR, 0)
+	defer f.Close()
+	if err := cbccts.EncryptFileInPlace(f, ac, iv, cbccts.CS3); err == nil {
+		t.Errorf("short file accepted")
+	}
+}
@@ -0,0 +1,58 @@
+/*
+	nonce.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// NewEncrypterFromNonce creates a CBC-CTS encrypter whose IV is derived from a nonce, so that a simple message counter
+// never becomes a predictable raw CBC IV. The IV is HKDF-Extract-SHA256 of label || nonce with the key as the salt,
+// which is HMAC-SHA256(key, label || nonce), truncated to the block size; for a block larger than 32 bytes,
+// it is HKDF-Expand of that with the label as the info.
+//
+// key is an IV derivation key, which should be independent of the key of block. A nonce must never be used twice with the same keys.
+func NewEncrypterFromNonce(block cipher.Block, key, nonce []byte, f Format) (cipher.BlockMode, error) {
+	iv, err := ivFromNonce(block, key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return NewCBCCTSEncrypter(block, iv, f), nil
+}
+
+// NewDecrypterFromNonce creates a CBC-CTS decrypter for the ciphertext of NewEncrypterFromNonce.
+func NewDecrypterFromNonce(block cipher.Block, key, nonce []byte, f Format) (cipher.BlockMode, error) {
+	iv, err := ivFromNonce(block, key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return NewCBCCTSDecrypter(block, iv, f), nil
+}
+
+// derive the CBC IV from a nonce
+func ivFromNonce(block cipher.Block, key, nonce []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("cbccts: empty key")
+	}
+	// HKDF-Extract with the key as the salt is HMAC(key, label || nonce)
+	prk := hkdf.Extract(sha256.New, append(append([]byte(nil), nonceLabel...), nonce...), key)
+	bs := block.BlockSize()
+	if bs <= len(prk) {
+		return prk[:bs], nil
+	}
+	iv := make([]byte, bs)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, nonceLabel), iv); err != nil {
+		return nil, errors.New("cbccts: block size too large for the IV derivation")
+	}
+	return iv, nil
+}
+
+var nonceLabel = []byte("cbccts IV")
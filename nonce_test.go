@@ -0,0 +1,59 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestEncrypterFromNonce(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	ivKey := []byte("an IV derivation key")
+	msg := []byte("a message encrypted under a counter nonce")
+
+	var prev []byte
+	for counter := uint64(0); counter < 3; counter++ {
+		nonce := make([]byte, 8)
+		binary.BigEndian.PutUint64(nonce, counter)
+
+		enc, err := cbccts.NewEncrypterFromNonce(ac, ivKey, nonce, cbccts.CS3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := make([]byte, len(msg))
+		enc.CryptBlocks(ct, msg)
+		if bytes.Equal(ct, prev) {
+			t.Errorf("the same ciphertext for different nonces")
+		}
+		prev = ct
+
+		// the IV is the truncated HMAC of the label and the nonce
+		m := hmac.New(sha256.New, ivKey)
+		m.Write([]byte("cbccts IV"))
+		m.Write(nonce)
+		want := make([]byte, len(msg))
+		cbccts.NewCBCCTSEncrypter(ac, m.Sum(nil)[:aes.BlockSize], cbccts.CS3).CryptBlocks(want, msg)
+		if !bytes.Equal(ct, want) {
+			t.Errorf("unexpected IV derivation")
+		}
+
+		dec, err := cbccts.NewDecrypterFromNonce(ac, ivKey, nonce, cbccts.CS3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec.CryptBlocks(ct, ct)
+		if !bytes.Equal(ct, msg) {
+			t.Errorf("decryption failed")
+		}
+	}
+
+	if _, err := cbccts.NewEncrypterFromNonce(ac, nil, []byte{1}, cbccts.CS3); err == nil {
+		t.Errorf("empty key accepted")
+	}
+}
@@ -0,0 +1,73 @@
+/*
+	parallel.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"runtime"
+	"sync"
+)
+
+// minimum size of a segment decrypted by a goroutine
+const parallelMinSegment = 64 * 1024
+
+// NewCBCCTSParallelDecrypter creates a CBC-CTS decrypter that splits large ciphertexts into segments decrypted concurrently.
+// CBC decryption of a block needs only the previous ciphertext block, so each segment starts from the last block of the preceding one.
+// The output and the chaining state are the same as those of NewCBCCTSDecrypter.
+// workers is the maximum number of goroutines; 0 means runtime.GOMAXPROCS(0).
+func NewCBCCTSParallelDecrypter(b cipher.Block, iv []byte, mode Format, workers int) cipher.BlockMode {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	d := NewCBCCTSDecrypter(b, iv, mode).(*Decrypter)
+	d.workers = workers
+	return d
+}
+
+// the CBC modes of the standard library can reset their IV
+type ivSetter interface {
+	SetIV([]byte)
+}
+
+// decrypt aligned blocks in CBC mode, in parallel if the decrypter is parallel and the data is large
+func (cd *cbccts) decryptBlocks(dst, src []byte) {
+	blocksz := cd.block.BlockSize()
+	nblocks := len(src) / blocksz
+	segments := len(src) / parallelMinSegment
+	if segments > cd.workers {
+		segments = cd.workers
+	}
+	if segments < 2 {
+		cd.chain(dst, src)
+		return
+	}
+	per := (nblocks + segments - 1) / segments * blocksz
+
+	// the chaining values are saved first, as dst and src may overlap
+	ivs := make([][]byte, 0, segments)
+	for off := per; off < len(src); off += per {
+		ivs = append(ivs, append([]byte(nil), src[off-blocksz:off]...))
+	}
+	last := append([]byte(nil), src[len(src)-blocksz:]...)
+
+	var wg sync.WaitGroup
+	for i, off := 0, per; off < len(src); i, off = i+1, off+per {
+		end := off + per
+		if end > len(src) {
+			end = len(src)
+		}
+		wg.Add(1)
+		go func(iv []byte, dst, src []byte) {
+			defer wg.Done()
+			newCBCDecrypter(cd.block, iv).CryptBlocks(dst, src)
+		}(ivs[i], dst[off:end], src[off:end])
+	}
+	// the first segment chains from the current state
+	cd.codec.CryptBlocks(dst[:per], src[:per])
+	wg.Wait()
+
+	cd.codec.(ivSetter).SetIV(last)
+}
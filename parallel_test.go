@@ -0,0 +1,54 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestParallelDecrypter(t *testing.T) {
+
+	key := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(key)
+	rand.Read(iv)
+	b, _ := aes.NewCipher(key)
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		for _, n := range []int{100, 1 << 20, 1<<20 + 5, 3<<20 + 17} {
+			pt := make([]byte, n)
+			rand.Read(pt)
+			ct := make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(b, iv, mode).CryptBlocks(ct, pt)
+
+			out := make([]byte, n)
+			cbccts.NewCBCCTSParallelDecrypter(b, iv, mode, 4).CryptBlocks(out, ct)
+			if !bytes.Equal(out, pt) {
+				t.Errorf("CS%d %d bytes: parallel decryption failed", mode, n)
+			}
+
+			// in place
+			copy(out, ct)
+			cbccts.NewCBCCTSParallelDecrypter(b, iv, mode, 0).CryptBlocks(out, out)
+			if !bytes.Equal(out, pt) {
+				t.Errorf("CS%d %d bytes: in-place parallel decryption failed", mode, n)
+			}
+		}
+	}
+
+	// aligned data chains across calls as the serial decrypter does
+	pt := make([]byte, 2<<20)
+	rand.Read(pt)
+	ct := make([]byte, len(pt))
+	cbccts.NewCBCCTSEncrypter(b, iv, cbccts.CS1).CryptBlocks(ct, pt)
+	out := make([]byte, len(pt))
+	d := cbccts.NewCBCCTSParallelDecrypter(b, iv, cbccts.CS1, 4)
+	d.CryptBlocks(out[:1<<20], ct[:1<<20])
+	d.CryptBlocks(out[1<<20:], ct[1<<20:])
+	if !bytes.Equal(out, pt) {
+		t.Errorf("chained parallel decryption failed")
+	}
+}
@@ -0,0 +1,124 @@
+/*
+	parallelfile.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// bytes of ciphertext decrypted by a worker at once; a multiple of every supported block size
+const fileSegmentSize = 4 << 20
+
+// DecryptFile decrypts a whole CBC-CTS encrypted file to dst, with segments of the file decrypted concurrently
+// on up to workers goroutines; 0 means runtime.GOMAXPROCS(0). The segments are written to dst in order.
+// This is meant for restoring large backups; memory use is about two segments of 4 MiB per worker.
+func DecryptFile(dst io.Writer, src *os.File, b cipher.Block, iv []byte, mode Format, workers int) error {
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	return DecryptReaderAt(dst, src, fi.Size(), b, iv, mode, workers)
+}
+
+// DecryptReaderAt decrypts size bytes of CBC-CTS ciphertext read from src, as DecryptFile does.
+func DecryptReaderAt(dst io.Writer, src io.ReaderAt, size int64, b cipher.Block, iv []byte, mode Format, workers int) error {
+	if mode < CS1 || mode > CS3 {
+		return fmt.Errorf("invalid mode")
+	}
+	blocksz := int64(b.BlockSize())
+	if len(iv) != int(blocksz) {
+		return fmt.Errorf("IV length must equal block size")
+	}
+	if size < blocksz {
+		return fmt.Errorf("data size too small; must be larger than one block")
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// the aligned prefix is plain CBC; the last two blocks, with the partial block if any, are decrypted as CTS
+	leftover := size % blocksz
+	prefix := size - leftover - 2*blocksz
+	if prefix < 0 {
+		prefix = 0
+	}
+
+	type segment struct {
+		off, end int64
+		out      []byte
+		err      error
+	}
+	var segs []*segment
+	for off := int64(0); off < prefix; off += fileSegmentSize {
+		end := off + fileSegmentSize
+		if end > prefix {
+			end = prefix
+		}
+		segs = append(segs, &segment{off: off, end: end})
+	}
+	segs = append(segs, &segment{off: prefix, end: size})
+
+	decrypt := func(s *segment) {
+		// a segment starts from the last ciphertext block of the preceding one
+		start := s.off - blocksz
+		if start < 0 {
+			start = 0
+		}
+		buf := make([]byte, s.end-start)
+		if n, err := src.ReadAt(buf, start); n < len(buf) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			s.err = err
+			return
+		}
+		segiv, ct := iv, buf
+		if s.off > 0 {
+			segiv, ct = buf[:blocksz], buf[blocksz:]
+		}
+		s.out = make([]byte, len(ct))
+		if s.end == size {
+			NewCBCCTSDecrypter(b, segiv, mode).CryptBlocks(s.out, ct)
+		} else {
+			newCBCDecrypter(b, segiv).CryptBlocks(s.out, ct)
+		}
+	}
+
+	// decrypt a round of segments concurrently, then write them in order
+	for len(segs) > 0 {
+		n := workers
+		if n > len(segs) {
+			n = len(segs)
+		}
+		round := segs[:n]
+		segs = segs[n:]
+
+		var wg sync.WaitGroup
+		for _, s := range round {
+			wg.Add(1)
+			go func(s *segment) {
+				defer wg.Done()
+				decrypt(s)
+			}(s)
+		}
+		wg.Wait()
+
+		for _, s := range round {
+			if s.err != nil {
+				return s.err
+			}
+			if _, err := dst.Write(s.out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
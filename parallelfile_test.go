@@ -0,0 +1,80 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestDecryptFile(t *testing.T) {
+
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(key)
+	rand.Read(iv)
+	ac, _ := aes.NewCipher(key)
+	dir := t.TempDir()
+
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		for _, n := range []int{16, 33, 64, 4 << 20, 9<<20 + 3, 9<<20 + 32} {
+			pt := make([]byte, n)
+			rand.Read(pt)
+			ct := make([]byte, n)
+			cbccts.NewCBCCTSEncrypter(ac, iv, mode).CryptBlocks(ct, pt)
+
+			name := filepath.Join(dir, "ct")
+			if err := os.WriteFile(name, ct, 0600); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			err = cbccts.DecryptFile(&out, f, ac, iv, mode, 2)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), pt) {
+				t.Errorf("CS%d %d bytes: decryption failed", mode, n)
+			}
+		}
+	}
+
+	if err := cbccts.DecryptReaderAt(&bytes.Buffer{}, bytes.NewReader(make([]byte, 15)), 15, ac, iv, cbccts.CS3, 0); err == nil {
+		t.Errorf("short data not detected")
+	}
+
+	// io.ReaderAt may return io.EOF with a full read at the end of the data
+	pt := make([]byte, 100)
+	rand.Read(pt)
+	ct := make([]byte, len(pt))
+	cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3).CryptBlocks(ct, pt)
+	var out bytes.Buffer
+	if err := cbccts.DecryptReaderAt(&out, eofReaderAt{bytes.NewReader(ct)}, int64(len(ct)), ac, iv, cbccts.CS3, 0); err != nil || !bytes.Equal(out.Bytes(), pt) {
+		t.Errorf("io.EOF with a full read: %v", err)
+	}
+	if err := cbccts.DecryptReaderAt(&bytes.Buffer{}, eofReaderAt{bytes.NewReader(ct)}, int64(len(ct))+1, ac, iv, cbccts.CS3, 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated data: unexpected error %v", err)
+	}
+}
+
+// eofReaderAt returns io.EOF along with the bytes that reach the end of the data
+type eofReaderAt struct {
+	r *bytes.Reader
+}
+
+func (e eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := e.r.ReadAt(p, off)
+	if err == nil && off+int64(n) == e.r.Size() {
+		err = io.EOF
+	}
+	return n, err
+}
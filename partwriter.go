@@ -0,0 +1,97 @@
+/*
+	partwriter.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// PartCipher encrypts a large object as parts of a fixed size, each a CBC-CTS message of its own, so that the parts
+// can be encrypted concurrently and out of order, as the parts of an S3 multipart upload.
+// Chaining every part to the ciphertext of the one before would make the encryption serial; instead the IV of a part
+// is derived from a nonce of the object and the part number, as NewEncrypterFromNonce does.
+// The ciphertext has the same length and the same offsets as the plaintext.
+//
+// A nonce must never be used for two objects with the same keys. A PartCipher has no mutable state and is safe for concurrent use.
+type PartCipher struct {
+	block    cipher.Block
+	key      []byte // the IV derivation key
+	nonce    []byte // the nonce of the object
+	mode     Format
+	partSize int
+}
+
+// NewPartCipher creates a PartCipher for an object with parts of partSize bytes; the last part may be shorter, but at least a block.
+// ivKey is the key of the IV derivation, independent of the key of b.
+func NewPartCipher(b cipher.Block, ivKey, nonce []byte, mode Format, partSize int) (*PartCipher, error) {
+	if mode < CS1 || mode > CS3 {
+		return nil, fmt.Errorf("invalid mode")
+	}
+	if partSize < b.BlockSize() {
+		return nil, fmt.Errorf("part size too small; must be at least one block")
+	}
+	p := &PartCipher{block: b, key: append([]byte(nil), ivKey...), nonce: append([]byte(nil), nonce...), mode: mode, partSize: partSize}
+	if _, err := p.iv(0); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// the IV of a part
+func (p *PartCipher) iv(part uint64) ([]byte, error) {
+	var num [8]byte
+	binary.BigEndian.PutUint64(num[:], part)
+	return ivFromNonce(p.block, p.key, append(append([]byte(nil), p.nonce...), num[:]...))
+}
+
+// check the length of a part
+func (p *PartCipher) check(n int) {
+	if n > p.partSize {
+		panic(fmt.Errorf("part larger than the part size"))
+	}
+}
+
+// EncryptPart encrypts a part. dst and src must overlap entirely or not at all.
+func (p *PartCipher) EncryptPart(dst, src []byte, part uint64) {
+	p.check(len(src))
+	iv, _ := p.iv(part) // the key was checked by NewPartCipher
+	NewCBCCTSEncrypter(p.block, iv, p.mode).CryptBlocks(dst, src)
+}
+
+// DecryptPart decrypts a part. dst and src must overlap entirely or not at all.
+func (p *PartCipher) DecryptPart(dst, src []byte, part uint64) {
+	p.check(len(src))
+	iv, _ := p.iv(part)
+	NewCBCCTSDecrypter(p.block, iv, p.mode).CryptBlocks(dst, src)
+}
+
+// NewWriterAt returns an io.WriterAt that encrypts the parts written to it into w, at the same offsets.
+// Every write must be a whole part, at an offset that is a multiple of the part size; the writes may come in any order
+// and concurrently, if w allows it.
+func (p *PartCipher) NewWriterAt(w io.WriterAt) io.WriterAt {
+	return &partWriterAt{p, w}
+}
+
+type partWriterAt struct {
+	*PartCipher
+	w io.WriterAt
+}
+
+func (pw *partWriterAt) WriteAt(b []byte, off int64) (int, error) {
+	if off < 0 || off%int64(pw.partSize) != 0 {
+		return 0, fmt.Errorf("cbccts: offset %d is not at a part boundary", off)
+	}
+	if len(b) > pw.partSize || len(b) < pw.block.BlockSize() {
+		return 0, fmt.Errorf("cbccts: a part of %d bytes; must be at most %d, and at least a block", len(b), pw.partSize)
+	}
+	// the plaintext of the caller is not modified, as io.WriterAt requires
+	ct := make([]byte, len(b))
+	pw.EncryptPart(ct, b, uint64(off/int64(pw.partSize)))
+	return pw.w.WriteAt(ct, off)
+}
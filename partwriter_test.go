@@ -0,0 +1,83 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestPartCipher(t *testing.T) {
+
+	key, ivKey := make([]byte, 32), make([]byte, 32)
+	rand.Read(key)
+	rand.Read(ivKey)
+	ac, _ := aes.NewCipher(key)
+	const partSize = 5000
+
+	for _, n := range []int{partSize, 7*partSize + 16, 10*partSize - 1} {
+		p, err := cbccts.NewPartCipher(ac, ivKey, []byte("object 1"), cbccts.CS3, partSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt := make([]byte, n)
+		rand.Read(pt)
+
+		// the parts are written concurrently, in reverse order
+		store := &memStorage{b: make([]byte, n)}
+		w := p.NewWriterAt(store)
+		var wg sync.WaitGroup
+		for off := (n - 1) / partSize * partSize; off >= 0; off -= partSize {
+			end := off + partSize
+			if end > n {
+				end = n
+			}
+			wg.Add(1)
+			go func(off, end int) {
+				defer wg.Done()
+				if _, err := w.WriteAt(pt[off:end], int64(off)); err != nil {
+					t.Error(err)
+				}
+			}(off, end)
+		}
+		wg.Wait()
+
+		// each part decrypts on its own
+		out := make([]byte, n)
+		for off := 0; off < n; off += partSize {
+			end := off + partSize
+			if end > n {
+				end = n
+			}
+			p.DecryptPart(out[off:end], store.b[off:end], uint64(off/partSize))
+		}
+		if !bytes.Equal(out, pt) {
+			t.Errorf("%d bytes: round trip failed", n)
+		}
+
+		// another nonce encrypts differently
+		q, _ := cbccts.NewPartCipher(ac, ivKey, []byte("object 2"), cbccts.CS3, partSize)
+		ct := make([]byte, partSize)
+		q.EncryptPart(ct, pt[:partSize], 0)
+		if bytes.Equal(ct, store.b[:partSize]) {
+			t.Errorf("the nonce is not used")
+		}
+	}
+
+	p, _ := cbccts.NewPartCipher(ac, ivKey, nil, cbccts.CS1, partSize)
+	w := p.NewWriterAt(&memStorage{})
+	for _, c := range []struct {
+		n   int
+		off int64
+	}{{partSize, 1}, {partSize + 1, 0}, {15, 0}, {partSize, -partSize}} {
+		if _, err := w.WriteAt(make([]byte, c.n), c.off); err == nil {
+			t.Errorf("%d bytes at %d accepted", c.n, c.off)
+		}
+	}
+	if _, err := cbccts.NewPartCipher(ac, nil, nil, cbccts.CS1, partSize); err == nil {
+		t.Errorf("empty IV key accepted")
+	}
+}
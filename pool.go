@@ -0,0 +1,113 @@
+/*
+	pool.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"sync"
+)
+
+// Codec is a CBC-CTS encrypter or decrypter that can be restarted with a new IV.
+type Codec interface {
+	cipher.BlockMode
+	Reset(iv []byte) // restart with a new IV
+}
+
+// Pool hands out reusable encrypters and decrypters keyed by (key, format), for servers that would otherwise construct a codec per request.
+// A Pool is safe for concurrent use; the codecs it hands out are not.
+// Note that the pool holds a copy of every key it has seen until the pool itself is released.
+type Pool struct {
+	newBlock func(key []byte) (cipher.Block, error)
+
+	mu    sync.Mutex
+	pools map[poolKey]*sync.Pool
+}
+
+type poolKey struct {
+	key     string
+	mode    Format
+	encoder bool
+}
+
+type pooledCodec struct {
+	Codec
+	pk poolKey
+}
+
+// NewPool creates a Pool that makes block ciphers with newBlock, such as aes.NewCipher.
+func NewPool(newBlock func(key []byte) (cipher.Block, error)) *Pool {
+	return &Pool{newBlock: newBlock, pools: make(map[poolKey]*sync.Pool)}
+}
+
+// GetEncrypter returns an encrypter of the key and the format, started with iv.
+func (p *Pool) GetEncrypter(key, iv []byte, mode Format) (Codec, error) {
+	return p.get(poolKey{string(key), mode, true}, iv)
+}
+
+// GetDecrypter returns a decrypter of the key and the format, started with iv.
+func (p *Pool) GetDecrypter(key, iv []byte, mode Format) (Codec, error) {
+	return p.get(poolKey{string(key), mode, false}, iv)
+}
+
+// Put returns a codec obtained from the pool for reuse. The codec must not be used after Put.
+func (p *Pool) Put(c Codec) {
+	pc, ok := c.(*pooledCodec)
+	if !ok {
+		panic(fmt.Errorf("the codec is not from a pool"))
+	}
+	p.mu.Lock()
+	sp := p.pools[pc.pk]
+	p.mu.Unlock()
+	if sp != nil {
+		sp.Put(pc)
+	}
+}
+
+func (p *Pool) get(pk poolKey, iv []byte) (Codec, error) {
+	if pk.mode < CS1 || pk.mode > CS3 {
+		return nil, fmt.Errorf("invalid mode")
+	}
+	p.mu.Lock()
+	sp := p.pools[pk]
+	if sp == nil {
+		sp = &sync.Pool{}
+		p.pools[pk] = sp
+	}
+	p.mu.Unlock()
+
+	// the IV is checked before a codec is reused or made, as Reset would panic
+	if pc, ok := sp.Get().(*pooledCodec); ok {
+		if err := checkIV(iv, pc.BlockSize()); err != nil {
+			sp.Put(pc)
+			return nil, err
+		}
+		pc.Reset(iv)
+		return pc, nil
+	}
+
+	b, err := p.newBlock([]byte(pk.key))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIV(iv, b.BlockSize()); err != nil {
+		return nil, err
+	}
+	var c Codec
+	if pk.encoder {
+		c = NewCBCCTSEncrypter(b, iv, pk.mode).(*Encrypter)
+	} else {
+		c = NewCBCCTSDecrypter(b, iv, pk.mode).(*Decrypter)
+	}
+	return &pooledCodec{Codec: c, pk: pk}, nil
+}
+
+func checkIV(iv []byte, blocksz int) error {
+	if len(iv) != blocksz {
+		return fmt.Errorf("IV length must equal block size")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestPool(t *testing.T) {
+
+	pool := cbccts.NewPool(aes.NewCipher)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			key := bytes.Repeat([]byte{byte(g % 2)}, 16) // two keys shared by the goroutines
+			b, _ := aes.NewCipher(key)
+			for i := 0; i < 100; i++ {
+				iv := make([]byte, aes.BlockSize)
+				msg := make([]byte, aes.BlockSize+i)
+				rand.Read(iv)
+				rand.Read(msg)
+
+				enc, err := pool.GetEncrypter(key, iv, cbccts.CS3)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ct := make([]byte, len(msg))
+				enc.CryptBlocks(ct, msg)
+				pool.Put(enc)
+
+				want := make([]byte, len(msg))
+				cbccts.NewCBCCTSEncrypter(b, iv, cbccts.CS3).CryptBlocks(want, msg)
+				if !bytes.Equal(ct, want) {
+					t.Errorf("unexpected ciphertext")
+					return
+				}
+
+				dec, err := pool.GetDecrypter(key, iv, cbccts.CS3)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				pt := make([]byte, len(ct))
+				dec.CryptBlocks(pt, ct)
+				pool.Put(dec)
+				if !bytes.Equal(pt, msg) {
+					t.Errorf("decryption failed")
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if _, err := pool.GetEncrypter(make([]byte, 5), make([]byte, 16), cbccts.CS1); err == nil {
+		t.Errorf("invalid key accepted")
+	}
+	if _, err := pool.GetEncrypter(make([]byte, 16), make([]byte, 16), 4); err == nil {
+		t.Errorf("invalid mode accepted")
+	}
+
+	// a wrong IV is an error both for a new codec and for a reused one
+	key := make([]byte, 16)
+	for i := 0; i < 2; i++ {
+		if _, err := pool.GetDecrypter(key, make([]byte, 8), cbccts.CS3); err == nil {
+			t.Errorf("round %d: invalid IV accepted", i)
+		}
+		dec, err := pool.GetDecrypter(key, make([]byte, 16), cbccts.CS3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool.Put(dec)
+	}
+}
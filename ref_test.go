@@ -0,0 +1,61 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"math/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/internal/ref"
+)
+
+// the codec must agree with the reference implementation on random inputs
+func TestReference(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rnd.Read(b)
+		return b
+	}
+
+	aesCipher, _ := aes.NewCipher(random(16))
+	desCipher, _ := des.NewCipher(random(8))
+	for _, b := range []cipher.Block{aesCipher, desCipher} {
+		bs := b.BlockSize()
+		for _, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+			for i := 0; i < 500; i++ {
+				n := bs + rnd.Intn(8*bs)
+				iv, pt := random(bs), random(n)
+
+				expect := ref.Encrypt(b, iv, f, pt)
+				ct := make([]byte, n)
+				cbccts.NewCBCCTSEncrypter(b, iv, f).CryptBlocks(ct, pt)
+				if !bytes.Equal(ct, expect) {
+					t.Fatalf("block size %d, CS%d, %d bytes: encryption differs", bs, f, n)
+				}
+				if !bytes.Equal(ref.Decrypt(b, iv, f, ct), pt) {
+					t.Fatalf("block size %d, CS%d, %d bytes: reference decryption differs", bs, f, n)
+				}
+
+				// out of place and in place
+				out := make([]byte, n)
+				cbccts.NewCBCCTSDecrypter(b, iv, f).CryptBlocks(out, ct)
+				if !bytes.Equal(out, pt) {
+					t.Fatalf("block size %d, CS%d, %d bytes: decryption differs", bs, f, n)
+				}
+				cbccts.NewCBCCTSDecrypter(b, iv, f).CryptBlocks(ct, ct)
+				if !bytes.Equal(ct, pt) {
+					t.Fatalf("block size %d, CS%d, %d bytes: in-place decryption differs", bs, f, n)
+				}
+				cbccts.NewCBCCTSEncrypter(b, iv, f).CryptBlocks(out, out)
+				if !bytes.Equal(out, expect) {
+					t.Fatalf("block size %d, CS%d, %d bytes: in-place encryption differs", bs, f, n)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+/*
+	residue.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"fmt"
+)
+
+// CBCResidue returns the final CBC chaining value of a CBC-CTS ciphertext, i.e. the last full ciphertext block in CBC order.
+// The ciphertext blocks are reordered by the CTS formats, so the block is located according to the format.
+//
+// The residue is the CBC-MAC of the zero-padded plaintext under the same IV, and is also the IV to continue a CBC chain,
+// like the output cipher state of RFC 3962.
+// The returned slice refers to the ciphertext.
+func CBCResidue(ciphertext []byte, blockSize int, mode Format) []byte {
+	textlen := len(ciphertext)
+	if textlen < blockSize {
+		panic(fmt.Errorf("data size too small; must be larger than one block"))
+	}
+	leftover := textlen % blockSize
+	last := ciphertext[textlen-blockSize:] // the last full block in the stream
+
+	if textlen == blockSize {
+		return last
+	}
+	switch mode {
+	case CS1:
+		// the block order is retained
+		return last
+	case CS2:
+		if leftover == 0 {
+			return last
+		}
+		fallthrough
+	case CS3:
+		// the last full block precedes the final (partial) block
+		py := textlen - leftover - blockSize
+		if leftover == 0 {
+			py -= blockSize
+		}
+		return ciphertext[py : py+blockSize]
+	default:
+		panic(fmt.Errorf("invalid mode"))
+	}
+}
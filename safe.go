@@ -0,0 +1,34 @@
+/*
+	safe.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"sync"
+)
+
+// a cipher.BlockMode serialized by a mutex
+type safeBlockMode struct {
+	mu sync.Mutex
+	bm cipher.BlockMode
+}
+
+// NewSafeBlockMode wraps a cipher.BlockMode so that CryptBlocks may be called from many goroutines.
+// The calls are serialized, so the chaining state passes from one call to the next in an unspecified order;
+// the wrapper prevents data races, not interleaving. Prefer a codec per goroutine, or a Pool, for independent messages.
+func NewSafeBlockMode(bm cipher.BlockMode) cipher.BlockMode {
+	return &safeBlockMode{bm: bm}
+}
+
+func (s *safeBlockMode) BlockSize() int {
+	return s.bm.BlockSize()
+}
+
+func (s *safeBlockMode) CryptBlocks(dst, src []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bm.CryptBlocks(dst, src)
+}
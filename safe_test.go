@@ -0,0 +1,49 @@
+package cbccts_test
+
+import (
+	"crypto/aes"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestSafeBlockMode(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	msg := []byte("the same message from every goroutine")
+	const n = 50
+
+	// the calls with the same input give the outputs of serial calls, in some order
+	serial := cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3)
+	want := make([]string, n)
+	for i := range want {
+		out := make([]byte, len(msg))
+		serial.CryptBlocks(out, msg)
+		want[i] = string(out)
+	}
+
+	safe := cbccts.NewSafeBlockMode(cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3))
+	got := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out := make([]byte, len(msg))
+			safe.CryptBlocks(out, msg)
+			got[i] = string(out)
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Strings(want)
+	sort.Strings(got)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected output of concurrent calls")
+		}
+	}
+}
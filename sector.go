@@ -0,0 +1,58 @@
+/*
+	sector.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// IVGenerator generates the IV of a sector from its sector number.
+// ESSIV and Plain64 are IVGenerators.
+type IVGenerator interface {
+	// BlockSize returns the size of the IV.
+	BlockSize() int
+	// PutIV writes the IV for a sector into iv.
+	PutIV(iv []byte, sectorNum uint64)
+}
+
+// SectorCipher encrypts and decrypts sectors in CBC-CTS mode, each sector with its own IV derived from the sector number.
+// Sectors need not be aligned to the block size, but must be at least one block long.
+// A SectorCipher has no mutable state and is safe for concurrent use.
+type SectorCipher struct {
+	block cipher.Block
+	ivgen IVGenerator
+	mode  Format
+}
+
+// NewSectorCipher creates a new sector cipher.
+func NewSectorCipher(b cipher.Block, ivgen IVGenerator, mode Format) *SectorCipher {
+	if mode < CS1 || mode > CS3 {
+		panic(fmt.Errorf("invalid mode"))
+	}
+	if ivgen.BlockSize() != b.BlockSize() {
+		panic(fmt.Errorf("IV size does not match the block size"))
+	}
+	return &SectorCipher{
+		block: b,
+		ivgen: ivgen,
+		mode:  mode,
+	}
+}
+
+// EncryptSector encrypts a sector. dst and src must overlap entirely or not at all.
+func (s *SectorCipher) EncryptSector(dst, src []byte, sectorNum uint64) {
+	iv := make([]byte, s.block.BlockSize())
+	s.ivgen.PutIV(iv, sectorNum)
+	NewCBCCTSEncrypter(s.block, iv, s.mode).CryptBlocks(dst, src)
+}
+
+// DecryptSector decrypts a sector. dst and src must overlap entirely or not at all.
+func (s *SectorCipher) DecryptSector(dst, src []byte, sectorNum uint64) {
+	iv := make([]byte, s.block.BlockSize())
+	s.ivgen.PutIV(iv, sectorNum)
+	NewCBCCTSDecrypter(s.block, iv, s.mode).CryptBlocks(dst, src)
+}
@@ -0,0 +1,59 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestSectorCipher(t *testing.T) {
+
+	key := make([]byte, 0x20)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	ac, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	essiv, err := cbccts.NewESSIV(aes.NewCipher, key, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := cbccts.NewSectorCipher(ac, essiv, cbccts.CS3)
+
+	// odd-sized sectors
+	const sectorSize = 500
+	data := make([]byte, 4*sectorSize)
+	for i := range data {
+		data[i] = byte(i % sectorSize * 7) // all sectors have the same content
+	}
+	enc := make([]byte, len(data))
+	for i := 0; i < len(data); i += sectorSize {
+		sector := uint64(i / sectorSize)
+		sc.EncryptSector(enc[i:i+sectorSize], data[i:i+sectorSize], sector)
+
+		// each sector is a CBC-CTS message with the sector IV
+		expect := make([]byte, sectorSize)
+		cbccts.NewCBCCTSEncrypter(ac, essiv.IV(sector), cbccts.CS3).CryptBlocks(expect, data[i:i+sectorSize])
+		if !bytes.Equal(enc[i:i+sectorSize], expect) {
+			t.Errorf("unexpected ciphertext: sector %d", sector)
+		}
+	}
+
+	// identical sectors encrypt differently at different positions
+	if bytes.Equal(enc[:sectorSize], enc[sectorSize:2*sectorSize]) {
+		t.Errorf("sectors encrypted with the same IV")
+	}
+
+	// in-place decryption
+	for i := 0; i < len(enc); i += sectorSize {
+		sc.DecryptSector(enc[i:i+sectorSize], enc[i:i+sectorSize], uint64(i/sectorSize))
+	}
+	if !bytes.Equal(enc, data) {
+		t.Errorf("decryption failed")
+	}
+}
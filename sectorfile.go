@@ -0,0 +1,233 @@
+/*
+	sectorfile.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SectorStorage is the storage of the ciphertext of a SectorFile, such as an *os.File opened for reading and writing.
+type SectorStorage interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// SectorFile is an encrypted file, readable and writable at any offset like a block device.
+// The storage is divided into sectors of a fixed size, each encrypted by a SectorCipher with the IV of its sector number,
+// so that a write re-encrypts only the sectors it touches. The ciphertext has the same length as the plaintext.
+//
+// A final sector shorter than a block cannot be CBC-CTS encrypted; it is XORed with a keystream E(IV xor shortSectorLabel) instead.
+// The label keeps the keystream apart from E(IV), the first ciphertext block of a sector beginning with a zero block,
+// so that a short tail is not revealed when the file later grows over it with zeros; the keystream only equals
+// the first ciphertext block of a sector beginning with the label itself.
+//
+// As with any sector encryption, rewriting a sector under the same IV reveals whether, and from which block on, its content changed.
+// A short tail is worse off: its keystream is fixed, so two versions of it reveal the XOR of their plaintexts.
+// A SectorFile gives confidentiality, not integrity.
+//
+// A SectorFile keeps an offset and a buffer, and is not safe for concurrent use.
+type SectorFile struct {
+	f      SectorStorage
+	sc     *SectorCipher
+	sector int64
+	size   int64  // bytes in the file
+	off    int64  // offset of Read, Write and Seek
+	buf    []byte // a sector
+}
+
+// NewSectorFile creates a SectorFile over the ciphertext in f, of size bytes, with sectors of sectorSize bytes.
+// sectorSize must be at least the block size of the cipher, and need not be a multiple of it.
+func NewSectorFile(f SectorStorage, size int64, sc *SectorCipher, sectorSize int) *SectorFile {
+	if sectorSize < sc.block.BlockSize() {
+		panic(fmt.Errorf("sector size too small; must be at least one block"))
+	}
+	if size < 0 {
+		panic(fmt.Errorf("negative size"))
+	}
+	return &SectorFile{f: f, sc: sc, sector: int64(sectorSize), size: size, buf: make([]byte, sectorSize)}
+}
+
+// Size returns the length of the file.
+func (s *SectorFile) Size() int64 {
+	return s.size
+}
+
+// ReadAt reads len(p) bytes of plaintext from the offset off.
+func (s *SectorFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("cbccts: negative offset")
+	}
+	defer zero(s.buf)
+	n := 0
+	for n < len(p) && off < s.size {
+		num := off / s.sector
+		pt, err := s.readSector(num)
+		if err != nil {
+			return n, err
+		}
+		c := copy(p[n:], pt[off-num*s.sector:])
+		n += c
+		off += int64(c)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt writes len(p) bytes of plaintext at the offset off. A write past the end of the file extends it,
+// filling any gap with zeros.
+func (s *SectorFile) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("cbccts: negative offset")
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	end := off + int64(len(p))
+	size := s.size
+	if end > size {
+		size = end
+	}
+	from := off
+	if s.size < from {
+		from = s.size // the gap is written too
+	}
+	n := 0
+	for num := from / s.sector; num*s.sector < end; num++ {
+		start := num * s.sector
+		pt, err := s.readSector(num)
+		if err != nil {
+			return n, err
+		}
+		// the sector grows up to the new end of the file, with zeros
+		l := size - start
+		if l > s.sector {
+			l = s.sector
+		}
+		old := len(pt)
+		pt = s.buf[:l]
+		for i := old; i < len(pt); i++ {
+			pt[i] = 0
+		}
+		if start < end && off < start+l {
+			lo := off - start
+			if lo < 0 {
+				lo = 0
+			}
+			c := copy(pt[lo:], p[start+lo-off:])
+			n += c
+		}
+		if err := s.writeSector(num, pt); err != nil {
+			return n, err
+		}
+		if start+l > s.size {
+			s.size = start + l
+		}
+	}
+	return n, nil
+}
+
+// Read reads from the current offset.
+func (s *SectorFile) Read(p []byte) (int, error) {
+	if len(p) > 0 && s.off >= s.size {
+		return 0, io.EOF
+	}
+	n, err := s.ReadAt(p, s.off)
+	s.off += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Write writes at the current offset.
+func (s *SectorFile) Write(p []byte) (int, error) {
+	n, err := s.WriteAt(p, s.off)
+	s.off += int64(n)
+	return n, err
+}
+
+// Seek sets the offset of the next Read or Write, as io.Seeker. Seeking past the end is allowed; a Write there extends the file.
+func (s *SectorFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.off
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return s.off, errors.New("cbccts: invalid whence")
+	}
+	if offset < 0 {
+		return s.off, errors.New("cbccts: negative offset")
+	}
+	s.off = offset
+	return offset, nil
+}
+
+// read and decrypt a sector into the buffer, as long as it is in the file
+func (s *SectorFile) readSector(num int64) ([]byte, error) {
+	start := num * s.sector
+	l := s.size - start
+	if l <= 0 {
+		return s.buf[:0], nil
+	}
+	if l > s.sector {
+		l = s.sector
+	}
+	b := s.buf[:l]
+	if n, err := s.f.ReadAt(b, start); n < len(b) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	s.cryptSector(b, num, false)
+	return b, nil
+}
+
+// encrypt the sector in the buffer in place, and write it
+func (s *SectorFile) writeSector(num int64, b []byte) error {
+	s.cryptSector(b, num, true)
+	_, err := s.f.WriteAt(b, num*s.sector)
+	return err
+}
+
+func (s *SectorFile) cryptSector(b []byte, num int64, encrypt bool) {
+	s.sc.crypt(b, uint64(num), encrypt)
+}
+
+// encrypt or decrypt a sector in place; a sector shorter than a block is XORed with a keystream, see cryptShort
+func (s *SectorCipher) crypt(b []byte, sectorNum uint64, encrypt bool) {
+	switch {
+	case len(b) < s.block.BlockSize():
+		s.cryptShort(b, sectorNum)
+	case encrypt:
+		s.EncryptSector(b, b, sectorNum)
+	default:
+		s.DecryptSector(b, b, sectorNum)
+	}
+}
+
+// the tweak of the keystream of a sector shorter than a block, repeated to the block size
+const shortSectorLabel = "cbccts short sec"
+
+// XOR a sector shorter than a block with E(IV xor shortSectorLabel), in place
+func (s *SectorCipher) cryptShort(b []byte, sectorNum uint64) {
+	ks := make([]byte, s.block.BlockSize())
+	s.ivgen.PutIV(ks, sectorNum)
+	for i := range ks {
+		ks[i] ^= shortSectorLabel[i%len(shortSectorLabel)]
+	}
+	s.block.Encrypt(ks, ks)
+	for i := range b {
+		b[i] ^= ks[i]
+	}
+	zero(ks)
+}
@@ -0,0 +1,133 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// a growable in-memory storage
+type memStorage struct {
+	b []byte
+}
+
+func (m *memStorage) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(m.b)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m *memStorage) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.b) {
+		m.b = append(m.b, make([]byte, end-len(m.b))...)
+	}
+	return copy(m.b[off:], p), nil
+}
+
+func TestSectorFile(t *testing.T) {
+
+	key := make([]byte, 32)
+	ac, _ := aes.NewCipher(key)
+	essiv, err := cbccts.NewESSIV(aes.NewCipher, key, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := cbccts.NewSectorCipher(ac, essiv, cbccts.CS3)
+
+	// random writes, reads and seeks agree with a plain buffer
+	const sectorSize = 100
+	rnd := rand.New(rand.NewSource(1))
+	var store memStorage
+	f := cbccts.NewSectorFile(&store, 0, sc, sectorSize)
+	var model []byte
+	for i := 0; i < 2000; i++ {
+		off := int64(rnd.Intn(1500))
+		switch rnd.Intn(3) {
+		case 0, 1:
+			p := make([]byte, rnd.Intn(300))
+			rnd.Read(p)
+			if n, err := f.WriteAt(p, off); err != nil || n != len(p) {
+				t.Fatalf("WriteAt: %d, %v", n, err)
+			}
+			if end := int(off) + len(p); len(p) > 0 && end > len(model) {
+				model = append(model, make([]byte, end-len(model))...)
+			}
+			copy(model[off:], p)
+		case 2:
+			p := make([]byte, rnd.Intn(300))
+			n, err := f.ReadAt(p, off)
+			expect := 0
+			if int(off) < len(model) {
+				expect = len(model) - int(off)
+				if expect > len(p) {
+					expect = len(p)
+				}
+			}
+			if n != expect || (n < len(p) && err != io.EOF) || !bytes.Equal(p[:n], model[int(off):int(off)+n]) {
+				t.Fatalf("ReadAt %d bytes at %d: %d, %v", len(p), off, n, err)
+			}
+		}
+		if f.Size() != int64(len(model)) || len(store.b) != len(model) {
+			t.Fatalf("size %d, storage %d, expected %d", f.Size(), len(store.b), len(model))
+		}
+	}
+
+	// the full sectors are those of the SectorCipher
+	for num := 0; (num+1)*sectorSize <= len(model); num++ {
+		ct := make([]byte, sectorSize)
+		sc.EncryptSector(ct, model[num*sectorSize:(num+1)*sectorSize], uint64(num))
+		if !bytes.Equal(ct, store.b[num*sectorSize:(num+1)*sectorSize]) {
+			t.Fatalf("sector %d differs", num)
+		}
+	}
+
+	// a fresh SectorFile over the storage reads it back, sequentially
+	g := cbccts.NewSectorFile(&store, int64(len(store.b)), sc, sectorSize)
+	if b, err := io.ReadAll(g); err != nil || !bytes.Equal(b, model) {
+		t.Errorf("ReadAll: %v", err)
+	}
+	if pos, err := g.Seek(-5, io.SeekEnd); err != nil || pos != int64(len(model)-5) {
+		t.Errorf("Seek: %d, %v", pos, err)
+	}
+	g.Write([]byte("0123456789"))
+	g.Seek(-10, io.SeekCurrent)
+	if b, _ := io.ReadAll(g); string(b) != "0123456789" {
+		t.Errorf("read back %q", b)
+	}
+	if _, err := g.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("negative offset accepted")
+	}
+
+	// a file shorter than a block
+	var short memStorage
+	h := cbccts.NewSectorFile(&short, 0, sc, sectorSize)
+	h.Write([]byte("tiny"))
+	if bytes.Equal(short.b, []byte("tiny")) {
+		t.Errorf("short sector not encrypted")
+	}
+	h.Seek(0, io.SeekStart)
+	if b, _ := io.ReadAll(h); string(b) != "tiny" {
+		t.Errorf("short sector read %q", b)
+	}
+
+	// the keystream of the short sector is not E(IV), the first block of the same sector later zero-filled
+	zeros := make([]byte, sectorSize)
+	sc.EncryptSector(zeros, zeros, 0)
+	leak := make([]byte, len(short.b))
+	for i := range leak {
+		leak[i] = short.b[i] ^ zeros[i]
+	}
+	if string(leak) == "tiny" {
+		t.Errorf("short sector revealed by a zero-filled sector")
+	}
+}
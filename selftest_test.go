@@ -0,0 +1,26 @@
+package cbccts_test
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := cbccts.SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// the codecs of many goroutines, as those of a SectorCipher, are made without contention on the self test
+func BenchmarkNewEncrypterParallel(b *testing.B) {
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3)
+		}
+	})
+}
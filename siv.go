@@ -0,0 +1,111 @@
+/*
+	siv.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// ErrAuthentication is returned when a ciphertext fails to authenticate.
+var ErrAuthentication = errors.New("cbccts: message authentication failed")
+
+// SIV is a deterministic, misuse-resistant authenticated encryption in the style of SIV (RFC 5297).
+// The IV is a MAC of the associated data and the plaintext, and the plaintext is encrypted in CBC-CTS mode with that IV.
+// The ciphertext is the IV followed by the CBC-CTS encrypted plaintext, so it is exactly one block longer than the plaintext.
+//
+// Encrypting the same plaintext with the same associated data always yields the same ciphertext,
+// which is useful for deduplicating stores, but it also reveals whether two plaintexts are equal.
+type SIV struct {
+	block cipher.Block
+	mac   func() hash.Hash
+	mode  Format
+}
+
+// NewSIV creates a new SIV construction.
+// mac must return a keyed MAC (e.g. HMAC or CMAC) whose key is independent of the block cipher key,
+// and whose output is at least one block long.
+func NewSIV(b cipher.Block, mac func() hash.Hash, mode Format) *SIV {
+	if mode < CS1 || mode > CS3 {
+		panic(fmt.Errorf("invalid mode"))
+	}
+	if mac().Size() < b.BlockSize() {
+		panic(fmt.Errorf("MAC size too small; must be at least one block"))
+	}
+	return &SIV{
+		block: b,
+		mac:   mac,
+		mode:  mode,
+	}
+}
+
+// Overhead returns the difference between the lengths of a ciphertext and its plaintext.
+func (s *SIV) Overhead() int {
+	return s.block.BlockSize()
+}
+
+// Seal encrypts and authenticates plaintext, authenticates additionalData, and appends the result to dst.
+// The plaintext must be at least one block long.
+func (s *SIV) Seal(dst, plaintext, additionalData []byte) []byte {
+	blocksz := s.block.BlockSize()
+	if len(plaintext) < blocksz {
+		panic(fmt.Errorf("data size too small; must be larger than one block"))
+	}
+	iv := s.syntheticIV(plaintext, additionalData)
+
+	ret, out := sliceForAppend(dst, blocksz+len(plaintext))
+	copy(out, iv)
+	NewCBCCTSEncrypter(s.block, iv, s.mode).CryptBlocks(out[blocksz:], plaintext)
+	return ret
+}
+
+// Open decrypts and authenticates ciphertext, authenticates additionalData, and appends the plaintext to dst.
+// dst and ciphertext must not overlap.
+func (s *SIV) Open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	blocksz := s.block.BlockSize()
+	if len(ciphertext) < 2*blocksz {
+		return nil, ErrAuthentication
+	}
+	iv, body := ciphertext[:blocksz], ciphertext[blocksz:]
+
+	ret, out := sliceForAppend(dst, len(body))
+	NewCBCCTSDecrypter(s.block, iv, s.mode).CryptBlocks(out, body)
+
+	if subtle.ConstantTimeCompare(iv, s.syntheticIV(out, additionalData)) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, ErrAuthentication
+	}
+	return ret, nil
+}
+
+// compute the synthetic IV; the associated data is length-prefixed to separate it from the plaintext
+func (s *SIV) syntheticIV(plaintext, additionalData []byte) []byte {
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(additionalData)))
+	m := s.mac()
+	m.Write(l[:])
+	m.Write(additionalData)
+	m.Write(plaintext)
+	return m.Sum(nil)[:s.block.BlockSize()]
+}
+
+// sliceForAppend extends in by n bytes, and returns the whole slice and the extended part.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
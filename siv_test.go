@@ -0,0 +1,65 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestSIV(t *testing.T) {
+
+	key := make([]byte, 0x20)
+	macKey := make([]byte, 0x20)
+	for i := range key {
+		key[i] = byte(i)
+		macKey[i] = byte(0x80 + i)
+	}
+	ac, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := func() hash.Hash { return hmac.New(sha256.New, macKey) }
+
+	ad := []byte("associated data")
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		s := cbccts.NewSIV(ac, mac, mode)
+		for n := aes.BlockSize; n < 4*aes.BlockSize; n++ {
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(i * 3)
+			}
+
+			sealed := s.Seal(nil, data, ad)
+			if len(sealed) != n+s.Overhead() {
+				t.Fatalf("invalid ciphertext length: mode %d, length %d", mode, n)
+			}
+
+			// deterministic
+			if !bytes.Equal(sealed, s.Seal(nil, data, ad)) {
+				t.Errorf("ciphertext not deterministic: mode %d, length %d", mode, n)
+			}
+
+			opened, err := s.Open(nil, sealed, ad)
+			if err != nil || !bytes.Equal(opened, data) {
+				t.Errorf("open failed: mode %d, length %d, %v", mode, n, err)
+			}
+
+			// tampering must be detected
+			if _, err := s.Open(nil, sealed, []byte("other data")); err != cbccts.ErrAuthentication {
+				t.Errorf("altered associated data not detected: mode %d, length %d", mode, n)
+			}
+			for _, pos := range []int{0, aes.BlockSize, len(sealed) - 1} {
+				sealed[pos] ^= 1
+				if _, err := s.Open(nil, sealed, ad); err != cbccts.ErrAuthentication {
+					t.Errorf("altered ciphertext not detected: mode %d, length %d, pos %d", mode, n, pos)
+				}
+				sealed[pos] ^= 1
+			}
+		}
+	}
+}
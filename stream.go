@@ -0,0 +1,142 @@
+/*
+	stream.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"io"
+)
+
+// bytes buffered by a Reader or a Writer
+const streamBufferSize = 64 * 1024
+
+// Writer is an io.WriteCloser that runs everything written to it through a CBC-CTS codec as a single message,
+// and writes the result to an underlying writer. The last two blocks are held back until Close,
+// as ciphertext stealing works on the end of the message; memory use is bounded regardless of the message size.
+type Writer struct {
+	w      io.Writer
+	m      cipher.BlockMode
+	blocks func(dst, src []byte)
+	buf    []byte
+	err    error
+}
+
+// NewWriter returns a Writer which encrypts or decrypts, by the codec m made by this package, the data written to w.
+// Close must be called to process the final blocks; it does not close w.
+func NewWriter(w io.Writer, m cipher.BlockMode) *Writer {
+	return &Writer{w: w, m: m, blocks: streamBlocks(m), buf: make([]byte, 0, streamBufferLen(m.BlockSize()))}
+}
+
+func (w *Writer) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	for len(p) > 0 {
+		k := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+k]
+		n, p = n+k, p[k:]
+		if len(w.buf) == cap(w.buf) {
+			m := streamable(len(w.buf), w.m.BlockSize())
+			w.blocks(w.buf[:m], w.buf[:m])
+			if _, w.err = w.w.Write(w.buf[:m]); w.err != nil {
+				return n, w.err
+			}
+			w.buf = w.buf[:copy(w.buf, w.buf[m:])]
+		}
+	}
+	return n, nil
+}
+
+// Close processes the final blocks and writes them to the underlying writer.
+// The message must be empty or at least one block long.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = fmt.Errorf("write to a closed Writer")
+	defer zero(w.buf[:cap(w.buf)])
+	if n := len(w.buf); n > 0 && n < w.m.BlockSize() {
+		return fmt.Errorf("data size too small; must be larger than one block")
+	}
+	w.m.CryptBlocks(w.buf, w.buf)
+	_, err := w.w.Write(w.buf)
+	return err
+}
+
+// Reader is an io.Reader that runs everything read from an underlying reader through a CBC-CTS codec as a single message.
+// As with Writer, the last two blocks are processed when the underlying reader reaches io.EOF.
+type Reader struct {
+	r      io.Reader
+	m      cipher.BlockMode
+	blocks func(dst, src []byte)
+	buf    []byte
+	off    int   // start of the processed data not read yet
+	ready  int   // end of the processed data, and the start of the held back input
+	end    int   // end of the held back input
+	err    error // io.EOF after the final blocks
+}
+
+// NewReader returns a Reader which encrypts or decrypts, by the codec m made by this package, the data read from r.
+func NewReader(r io.Reader, m cipher.BlockMode) *Reader {
+	return &Reader{r: r, m: m, blocks: streamBlocks(m), buf: make([]byte, streamBufferLen(m.BlockSize()))}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for r.off == r.ready {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.buf[r.off:r.ready])
+	r.off += n
+	return n, nil
+}
+
+// read more input, and process all but the held back blocks, or everything at the end of the input
+func (r *Reader) fill() {
+	r.end = copy(r.buf, r.buf[r.ready:r.end])
+	r.off, r.ready = 0, 0
+	n, err := io.ReadFull(r.r, r.buf[r.end:])
+	r.end += n
+	switch err {
+	case nil:
+		r.ready = streamable(r.end, r.m.BlockSize())
+		r.blocks(r.buf[:r.ready], r.buf[:r.ready])
+	case io.EOF, io.ErrUnexpectedEOF:
+		if r.end > 0 && r.end < r.m.BlockSize() {
+			r.err = fmt.Errorf("data size too small; must be larger than one block")
+			return
+		}
+		r.m.CryptBlocks(r.buf[:r.end], r.buf[:r.end])
+		r.ready, r.err = r.end, io.EOF
+	default:
+		r.err = err
+	}
+}
+
+// the aligned blocks of a stream are passed through the CBC codec without the ciphertext stealing of the final blocks
+func streamBlocks(m cipher.BlockMode) func(dst, src []byte) {
+	cd := codecOf(m)
+	if _, ok := cd.codec.(*decChain); ok {
+		return cd.decryptBlocks
+	}
+	return cd.chain
+}
+
+// the buffer holds at least three blocks, so that a full buffer always has a block to pass
+func streamBufferLen(blocksz int) int {
+	if n := streamBufferSize - streamBufferSize%blocksz; n >= 3*blocksz {
+		return n
+	}
+	return 3 * blocksz
+}
+
+// bytes of aligned blocks that may be processed out of n, holding back more than one and up to two blocks
+func streamable(n, blocksz int) int {
+	return (n - blocksz - 1) / blocksz * blocksz
+}
@@ -0,0 +1,71 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestStream(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	dc, _ := des.NewCipher(make([]byte, 8))
+	for _, b := range []cipher.Block{ac, dc} {
+		iv := make([]byte, b.BlockSize())
+		rand.Read(iv)
+		for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+			for _, n := range []int{0, b.BlockSize(), 2 * b.BlockSize(), 100, 64 * 1024, 64*1024 + 17, 200000} {
+				pt := make([]byte, n)
+				rand.Read(pt)
+				want := make([]byte, n)
+				cbccts.NewCBCCTSEncrypter(b, iv, mode).CryptBlocks(want, pt)
+
+				// the writer, with writes of odd sizes
+				var ct bytes.Buffer
+				w := cbccts.NewWriter(&ct, cbccts.NewCBCCTSEncrypter(b, iv, mode))
+				for p := pt; len(p) > 0; {
+					k := 777
+					if k > len(p) {
+						k = len(p)
+					}
+					w.Write(p[:k])
+					p = p[k:]
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(ct.Bytes(), want) {
+					t.Errorf("block size %d, CS%d, %d bytes: unexpected ciphertext", b.BlockSize(), mode, n)
+				}
+
+				// the reader, over an input read a byte at a time
+				r := cbccts.NewReader(iotest.OneByteReader(bytes.NewReader(want)), cbccts.NewCBCCTSDecrypter(b, iv, mode))
+				out, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(out, pt) {
+					t.Errorf("block size %d, CS%d, %d bytes: decryption failed", b.BlockSize(), mode, n)
+				}
+			}
+		}
+	}
+
+	// a message shorter than a block
+	w := cbccts.NewWriter(io.Discard, cbccts.NewCBCCTSEncrypter(ac, make([]byte, 16), cbccts.CS3))
+	w.Write(make([]byte, 15))
+	if err := w.Close(); err == nil {
+		t.Errorf("short message accepted by the writer")
+	}
+	r := cbccts.NewReader(bytes.NewReader(make([]byte, 15)), cbccts.NewCBCCTSDecrypter(ac, make([]byte, 16), cbccts.CS3))
+	if _, err := io.ReadAll(r); err == nil {
+		t.Errorf("short message accepted by the reader")
+	}
+}
@@ -0,0 +1,195 @@
+package cbccts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// run f on many goroutines, many times each; the failures of f are reported. Meant to run under -race.
+func stress(t *testing.T, f func(g, i int) error) {
+	goroutines, rounds := 32, 200
+	if testing.Short() {
+		goroutines, rounds = 8, 20
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				if err := f(g, i); err != nil {
+					t.Errorf("goroutine %d, round %d: %v", g, i, err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// a message and an IV unique to a goroutine and a round
+func stressInput(g, i int) (iv, msg []byte) {
+	iv = make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint32(iv, uint32(g))
+	binary.BigEndian.PutUint32(iv[4:], uint32(i))
+	msg = make([]byte, aes.BlockSize+(g*7+i)%200)
+	for j := range msg {
+		msg[j] = byte(g + i + j)
+	}
+	return iv, msg
+}
+
+func TestStress(t *testing.T) {
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	ac, _ := aes.NewCipher(key)
+
+	t.Run("Pool", func(t *testing.T) {
+		pool := cbccts.NewPool(aes.NewCipher)
+		keys := [][]byte{key[:16], key[:24], key}
+		stress(t, func(g, i int) error {
+			iv, msg := stressInput(g, i)
+			k, mode := keys[(g+i)%len(keys)], cbccts.Format(i%3+1)
+			enc, err := pool.GetEncrypter(k, iv, mode)
+			if err != nil {
+				return err
+			}
+			ct := make([]byte, len(msg))
+			enc.CryptBlocks(ct, msg)
+			pool.Put(enc)
+			dec, err := pool.GetDecrypter(k, iv, mode)
+			if err != nil {
+				return err
+			}
+			dec.CryptBlocks(ct, ct)
+			pool.Put(dec)
+			if !bytes.Equal(ct, msg) {
+				return fmt.Errorf("round trip failed")
+			}
+			return nil
+		})
+	})
+
+	t.Run("SafeBlockMode", func(t *testing.T) {
+		// the chaining order is unspecified; only the absence of races is checked
+		safe := cbccts.NewSafeBlockMode(cbccts.NewCBCCTSEncrypter(ac, make([]byte, aes.BlockSize), cbccts.CS1))
+		stress(t, func(g, i int) error {
+			_, msg := stressInput(g, i)
+			msg = msg[:len(msg)/aes.BlockSize*aes.BlockSize]
+			safe.CryptBlocks(msg, msg)
+			return nil
+		})
+	})
+
+	t.Run("AEAD", func(t *testing.T) {
+		aead, err := cbccts.NewAEAD(ac, cbccts.CS3, sha256.New, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stress(t, func(g, i int) error {
+			nonce, msg := stressInput(g, i)
+			ct := aead.Seal(nil, nonce, msg, nonce)
+			pt, err := aead.Open(nil, nonce, ct, nonce)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(pt, msg) {
+				return fmt.Errorf("round trip failed")
+			}
+			return nil
+		})
+	})
+
+	t.Run("IVTracker", func(t *testing.T) {
+		// every goroutine tries the IVs of all rounds; each IV is accepted once
+		tracker := cbccts.NewIVTracker(1 << 16)
+		var accepted int64
+		stress(t, func(g, i int) error {
+			iv, _ := stressInput(0, i)
+			_, err := cbccts.NewGuardedEncrypter(ac, iv, cbccts.CS3, tracker)
+			switch err {
+			case nil:
+				atomic.AddInt64(&accepted, 1)
+			case cbccts.ErrIVReused:
+			default:
+				return err
+			}
+			return nil
+		})
+		rounds := int64(200)
+		if testing.Short() {
+			rounds = 20
+		}
+		if accepted != rounds {
+			t.Errorf("%d IVs accepted out of %d", accepted, rounds)
+		}
+	})
+
+	t.Run("SectorCipher", func(t *testing.T) {
+		sc, err := cbccts.NewDMCryptSectorCipher(key, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stress(t, func(g, i int) error {
+			_, msg := stressInput(g, i)
+			ct := make([]byte, len(msg))
+			sc.EncryptSector(ct, msg, uint64(g*1000+i))
+			sc.DecryptSector(ct, ct, uint64(g*1000+i))
+			if !bytes.Equal(ct, msg) {
+				return fmt.Errorf("round trip failed")
+			}
+			return nil
+		})
+	})
+
+	t.Run("PartCipher", func(t *testing.T) {
+		const partSize = 256
+		pc, err := cbccts.NewPartCipher(ac, key, []byte("stress"), cbccts.CS3, partSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stress(t, func(g, i int) error {
+			_, msg := stressInput(g, i)
+			ct := make([]byte, len(msg))
+			pc.EncryptPart(ct, msg, uint64(g*1000+i))
+			pc.DecryptPart(ct, ct, uint64(g*1000+i))
+			if !bytes.Equal(ct, msg) {
+				return fmt.Errorf("round trip failed")
+			}
+			return nil
+		})
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		// a Writer and a Reader per goroutine, sharing the block cipher and a parallel decrypter per message
+		stress(t, func(g, i int) error {
+			iv, msg := stressInput(g, i)
+			msg = bytes.Repeat(msg, 1+i%50)
+			var ct bytes.Buffer
+			w := cbccts.NewWriter(&ct, cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3))
+			w.Write(msg)
+			if err := w.Close(); err != nil {
+				return err
+			}
+			pt, err := io.ReadAll(cbccts.NewReader(bytes.NewReader(ct.Bytes()), cbccts.NewCBCCTSParallelDecrypter(ac, iv, cbccts.CS3, 4)))
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(pt, msg) {
+				return fmt.Errorf("round trip failed")
+			}
+			return nil
+		})
+	})
+}
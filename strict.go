@@ -0,0 +1,58 @@
+/*
+	strict.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrUnauthenticated is returned by NewEncrypter and NewDecrypter without AllowUnauthenticated.
+var ErrUnauthenticated = errors.New("cbccts: unauthenticated encryption not allowed; use NewAEAD or pass AllowUnauthenticated()")
+
+// Option is an option of NewEncrypter and NewDecrypter.
+type Option func(*options)
+
+type options struct {
+	allowUnauthenticated bool
+}
+
+// AllowUnauthenticated acknowledges that the codec provides confidentiality only:
+// a CBC-CTS ciphertext may be modified without detection, unless the caller authenticates it.
+func AllowUnauthenticated() Option {
+	return func(o *options) {
+		o.allowUnauthenticated = true
+	}
+}
+
+// NewEncrypter creates a CBC-CTS encrypter as NewCBCCTSEncrypter does, but refuses with ErrUnauthenticated
+// unless AllowUnauthenticated is passed, to nudge the callers toward the authenticated encryption of NewAEAD.
+// This strict construction is planned to be the default of a future v2.
+func NewEncrypter(b cipher.Block, iv []byte, mode Format, opts ...Option) (cipher.BlockMode, error) {
+	if err := checkOptions(opts); err != nil {
+		return nil, err
+	}
+	return NewCBCCTSEncrypter(b, iv, mode), nil
+}
+
+// NewDecrypter creates a CBC-CTS decrypter with the strict construction of NewEncrypter.
+func NewDecrypter(b cipher.Block, iv []byte, mode Format, opts ...Option) (cipher.BlockMode, error) {
+	if err := checkOptions(opts); err != nil {
+		return nil, err
+	}
+	return NewCBCCTSDecrypter(b, iv, mode), nil
+}
+
+func checkOptions(opts []Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !o.allowUnauthenticated {
+		return ErrUnauthenticated
+	}
+	return nil
+}
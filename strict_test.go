@@ -0,0 +1,27 @@
+package cbccts_test
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestStrict(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := make([]byte, aes.BlockSize)
+
+	if _, err := cbccts.NewEncrypter(ac, iv, cbccts.CS3); err != cbccts.ErrUnauthenticated {
+		t.Errorf("unauthenticated encrypter built: %v", err)
+	}
+	if _, err := cbccts.NewDecrypter(ac, iv, cbccts.CS3); err != cbccts.ErrUnauthenticated {
+		t.Errorf("unauthenticated decrypter built: %v", err)
+	}
+	if _, err := cbccts.NewEncrypter(ac, iv, cbccts.CS3, cbccts.AllowUnauthenticated()); err != nil {
+		t.Error(err)
+	}
+	if _, err := cbccts.NewDecrypter(ac, iv, cbccts.CS3, cbccts.AllowUnauthenticated()); err != nil {
+		t.Error(err)
+	}
+}
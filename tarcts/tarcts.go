@@ -0,0 +1,197 @@
+/*
+	tarcts.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package tarcts encrypts the content of each entry of a tar archive in CBC-CTS mode, leaving the tar structure readable.
+
+	Every entry with content has its own random IV, stored in hex in the PAX record CBCCTS.iv of its header.
+	As CBC-CTS preserves the length, the names, sizes, modes and times of the entries stay as they are, and tar tools list
+	the archive as usual; a single entry is decrypted without the others, which the reader skips over.
+	Content shorter than a block cannot be CBC-CTS encrypted; it is XORed with the encryption of the IV instead, as one block of CFB.
+
+	The headers are not encrypted, and nothing is authenticated: a modified entry decrypts to garbage without an error.
+	Where integrity matters, the archive should be wrapped, or the entries checked, by other means.
+*/
+package tarcts
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// PAXRecordIV is the key of the PAX record holding the IV of an entry, in hex.
+const PAXRecordIV = "CBCCTS.iv"
+
+// Writer writes a tar archive, encrypting the content of the entries, as tar.Writer.
+type Writer struct {
+	tw     *tar.Writer
+	block  cipher.Block
+	format cbccts.Format
+	iv     []byte         // of the current entry
+	left   int64          // bytes of the current entry not written yet
+	cw     *cbccts.Writer // of the current entry, at least a block long
+	short  []byte         // the content of a current entry shorter than a block
+}
+
+// NewWriter creates a Writer writing to w, encrypting with b in the format f.
+func NewWriter(w io.Writer, b cipher.Block, f cbccts.Format) *Writer {
+	return &Writer{tw: tar.NewWriter(w), block: b, format: f}
+}
+
+// WriteHeader finishes the current entry and writes hdr. If hdr.Size is not zero, a new IV is generated and
+// stored in hdr.PAXRecords, and the content written up to hdr.Size is encrypted.
+func (w *Writer) WriteHeader(hdr *tar.Header) error {
+	if err := w.finish(); err != nil {
+		return err
+	}
+	h := *hdr
+	if h.Size > 0 {
+		w.iv = make([]byte, w.block.BlockSize())
+		if _, err := rand.Read(w.iv); err != nil {
+			return err
+		}
+		h.PAXRecords = make(map[string]string, len(hdr.PAXRecords)+1)
+		for k, v := range hdr.PAXRecords {
+			h.PAXRecords[k] = v
+		}
+		h.PAXRecords[PAXRecordIV] = hex.EncodeToString(w.iv)
+		h.Format = tar.FormatPAX
+	}
+	if err := w.tw.WriteHeader(&h); err != nil {
+		return err
+	}
+	w.left = h.Size
+	if h.Size >= int64(w.block.BlockSize()) {
+		w.cw = cbccts.NewWriter(w.tw, cbccts.NewCBCCTSEncrypter(w.block, w.iv, w.format))
+	} else if h.Size > 0 {
+		w.short = make([]byte, 0, h.Size)
+	}
+	return nil
+}
+
+// Write writes to the content of the current entry, as tar.Writer.Write.
+func (w *Writer) Write(p []byte) (int, error) {
+	n := len(p)
+	if int64(n) > w.left {
+		n = int(w.left)
+	}
+	var err error
+	switch {
+	case w.cw != nil:
+		n, err = w.cw.Write(p[:n])
+	case w.short != nil:
+		w.short = append(w.short, p[:n]...)
+	}
+	w.left -= int64(n)
+	if err == nil && n < len(p) {
+		err = tar.ErrWriteTooLong
+	}
+	return n, err
+}
+
+// Flush finishes the current entry, as tar.Writer.Flush.
+func (w *Writer) Flush() error {
+	if err := w.finish(); err != nil {
+		return err
+	}
+	return w.tw.Flush()
+}
+
+// Close finishes the current entry and closes the archive, as tar.Writer.Close. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.finish(); err != nil {
+		return err
+	}
+	return w.tw.Close()
+}
+
+// encrypt and write the rest of the current entry
+func (w *Writer) finish() error {
+	if w.left > 0 {
+		return fmt.Errorf("tarcts: missed writing %d bytes", w.left)
+	}
+	cw, short := w.cw, w.short
+	w.cw, w.short = nil, nil
+	switch {
+	case cw != nil:
+		return cw.Close()
+	case short != nil:
+		xorShort(w.block, w.iv, short)
+		_, err := w.tw.Write(short)
+		return err
+	}
+	return nil
+}
+
+// Reader reads a tar archive written by Writer, decrypting the content of the entries, as tar.Reader.
+type Reader struct {
+	tr     *tar.Reader
+	block  cipher.Block
+	format cbccts.Format
+	r      io.Reader // of the current entry
+}
+
+// NewReader creates a Reader reading from r, decrypting with b in the format f.
+func NewReader(r io.Reader, b cipher.Block, f cbccts.Format) *Reader {
+	return &Reader{tr: tar.NewReader(r), block: b, format: f}
+}
+
+// Next advances to the next entry, as tar.Reader.Next, skipping the rest of the current one without decrypting it.
+// The header returned has no CBCCTS.iv record. An entry with content but without an IV is an error.
+func (r *Reader) Next() (*tar.Header, error) {
+	r.r = nil
+	hdr, err := r.tr.Next()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := hdr.PAXRecords[PAXRecordIV]
+	if !ok {
+		if hdr.Size > 0 {
+			return nil, fmt.Errorf("tarcts: entry %q is not encrypted", hdr.Name)
+		}
+		r.r = r.tr
+		return hdr, nil
+	}
+	delete(hdr.PAXRecords, PAXRecordIV)
+	iv, err := hex.DecodeString(s)
+	if err != nil || len(iv) != r.block.BlockSize() {
+		return nil, fmt.Errorf("tarcts: invalid IV of entry %q", hdr.Name)
+	}
+	if hdr.Size >= int64(r.block.BlockSize()) {
+		r.r = cbccts.NewReader(r.tr, cbccts.NewCBCCTSDecrypter(r.block, iv, r.format))
+		return hdr, nil
+	}
+	short, err := io.ReadAll(r.tr)
+	if err != nil {
+		return nil, err
+	}
+	xorShort(r.block, iv, short)
+	r.r = bytes.NewReader(short)
+	return hdr, nil
+}
+
+// Read reads the decrypted content of the current entry, as tar.Reader.Read.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.r == nil {
+		return 0, io.EOF
+	}
+	return r.r.Read(p)
+}
+
+// XOR content shorter than a block with the encryption of the IV
+func xorShort(b cipher.Block, iv, p []byte) {
+	ks := make([]byte, b.BlockSize())
+	b.Encrypt(ks, iv)
+	for i := range p {
+		p[i] ^= ks[i]
+	}
+}
@@ -0,0 +1,133 @@
+package tarcts_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/tarcts"
+)
+
+func TestTar(t *testing.T) {
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	b, _ := aes.NewCipher(key)
+
+	entries := []struct {
+		name string
+		size int
+	}{
+		{"dir/", 0},
+		{"dir/empty", 0},
+		{"dir/short", 5},
+		{"dir/block", 16},
+		{"dir/odd", 1000},
+		{"dir/large", 200000 + 3},
+	}
+	content := make([][]byte, len(entries))
+
+	var buf bytes.Buffer
+	w := tarcts.NewWriter(&buf, b, cbccts.CS3)
+	for i, e := range entries {
+		content[i] = make([]byte, e.size)
+		rand.Read(content[i])
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Size: int64(e.size), Typeflag: tar.TypeReg}
+		if e.size == 0 && e.name[len(e.name)-1] == '/' {
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		}
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(content[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := w.Write([]byte{0}); err != tar.ErrWriteTooLong {
+		t.Errorf("expected ErrWriteTooLong, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	// a plain tar reader sees the same entries, with encrypted content
+	tr := tar.NewReader(bytes.NewReader(archive))
+	ivs := map[string]bool{}
+	for i, e := range entries {
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name != e.name || hdr.Size != int64(e.size) {
+			t.Errorf("entry %d: %q of %d bytes", i, hdr.Name, hdr.Size)
+		}
+		iv, ok := hdr.PAXRecords[tarcts.PAXRecordIV]
+		if ok != (e.size > 0) || ok && ivs[iv] {
+			t.Errorf("entry %d: IV %q", i, iv)
+		}
+		ivs[iv] = ok
+		if ct, _ := io.ReadAll(tr); e.size > 0 && bytes.Equal(ct, content[i]) {
+			t.Errorf("entry %d: not encrypted", i)
+		}
+	}
+
+	// all entries
+	r := tarcts.NewReader(bytes.NewReader(archive), b, cbccts.CS3)
+	for i, e := range entries {
+		hdr, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := hdr.PAXRecords[tarcts.PAXRecordIV]; ok || hdr.Name != e.name {
+			t.Errorf("entry %d: unexpected header %v", i, hdr)
+		}
+		if pt, err := io.ReadAll(r); err != nil || !bytes.Equal(pt, content[i]) {
+			t.Errorf("entry %d: decryption failed: %v", i, err)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	// a single entry, skipping the others partly read or unread
+	r = tarcts.NewReader(bytes.NewReader(archive), b, cbccts.CS3)
+	for {
+		hdr, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == "dir/odd" {
+			io.ReadFull(r, make([]byte, 10))
+			continue
+		}
+		if hdr.Name == "dir/large" {
+			if pt, _ := io.ReadAll(r); !bytes.Equal(pt, content[len(content)-1]) {
+				t.Errorf("selective extraction failed")
+			}
+			break
+		}
+	}
+
+	// an entry written in the clear
+	buf.Reset()
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "plain", Mode: 0644, Size: 3})
+	tw.Write([]byte("abc"))
+	tw.Close()
+	if _, err := tarcts.NewReader(&buf, b, cbccts.CS3).Next(); err == nil {
+		t.Errorf("unencrypted entry accepted")
+	}
+
+	// a short write
+	w = tarcts.NewWriter(io.Discard, b, cbccts.CS3)
+	w.WriteHeader(&tar.Header{Name: "f", Mode: 0644, Size: 100})
+	w.Write(make([]byte, 50))
+	if err := w.Close(); err == nil {
+		t.Errorf("missing content accepted")
+	}
+}
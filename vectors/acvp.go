@@ -0,0 +1,229 @@
+/*
+	acvp.go
+	2026-10, github.com/mixcode
+*/
+
+package vectors
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// ACVPVersion is the ACVP protocol version written by WriteACVP.
+const ACVPVersion = "1.0"
+
+// ACVPVectorSet is an ACVP vector set of the ACVP-AES-CBC-CS1, ACVP-AES-CBC-CS2 or ACVP-AES-CBC-CS3 algorithm.
+// The same layout holds the prompts of a server, the expected results of a sample set, and the responses of a client.
+type ACVPVectorSet struct {
+	VsID       int             `json:"vsId"`
+	Algorithm  string          `json:"algorithm"`
+	Revision   string          `json:"revision"`
+	IsSample   bool            `json:"isSample,omitempty"`
+	TestGroups []ACVPTestGroup `json:"testGroups"`
+}
+
+// ACVPTestGroup is a group of test cases of the same direction and key length.
+type ACVPTestGroup struct {
+	TgID      int            `json:"tgId"`
+	TestType  string         `json:"testType,omitempty"`  // "AFT"
+	Direction string         `json:"direction,omitempty"` // "encrypt" or "decrypt"
+	KeyLen    int            `json:"keyLen,omitempty"`    // in bits
+	Tests     []ACVPTestCase `json:"tests"`
+}
+
+// ACVPTestCase is a test case.
+type ACVPTestCase struct {
+	TcID       int      `json:"tcId"`
+	PayloadLen int      `json:"payloadLen,omitempty"` // in bits
+	Key        HexBytes `json:"key,omitempty"`
+	IV         HexBytes `json:"iv,omitempty"`
+	PT         HexBytes `json:"pt,omitempty"`
+	CT         HexBytes `json:"ct,omitempty"`
+}
+
+// ACVPAlgorithm returns the ACVP algorithm name of a format.
+func ACVPAlgorithm(f cbccts.Format) string {
+	return fmt.Sprintf("ACVP-AES-CBC-CS%d", f)
+}
+
+// NewACVPVectorSet makes a sample vector set with expected results from known-answer vectors of a format.
+// Each vector makes an encryption and a decryption test case, grouped by direction and key length.
+func NewACVPVectorSet(vsID int, f cbccts.Format, vectors []Vector) *ACVPVectorSet {
+	vs := &ACVPVectorSet{VsID: vsID, Algorithm: ACVPAlgorithm(f), Revision: "1.0", IsSample: true}
+
+	// group by key length
+	byKeyLen := make(map[int][]Vector)
+	for _, v := range vectors {
+		if v.Format == f {
+			byKeyLen[len(v.Key)*8] = append(byKeyLen[len(v.Key)*8], v)
+		}
+	}
+	keyLens := make([]int, 0, len(byKeyLen))
+	for k := range byKeyLen {
+		keyLens = append(keyLens, k)
+	}
+	sort.Ints(keyLens)
+
+	tgID, tcID := 1, 1
+	for _, dir := range []string{"encrypt", "decrypt"} {
+		for _, keyLen := range keyLens {
+			g := ACVPTestGroup{TgID: tgID, TestType: "AFT", Direction: dir, KeyLen: keyLen}
+			for _, v := range byKeyLen[keyLen] {
+				g.Tests = append(g.Tests, ACVPTestCase{TcID: tcID, PayloadLen: len(v.Plaintext) * 8, Key: v.Key, IV: v.IV, PT: v.Plaintext, CT: v.Ciphertext})
+				tcID++
+			}
+			vs.TestGroups = append(vs.TestGroups, g)
+			tgID++
+		}
+	}
+	return vs
+}
+
+// Response computes the responses of a client to the prompts of a vector set:
+// the ciphertexts of the encryption test cases and the plaintexts of the decryption test cases.
+func (vs *ACVPVectorSet) Response() (*ACVPVectorSet, error) {
+	f, err := vs.format()
+	if err != nil {
+		return nil, err
+	}
+	resp := &ACVPVectorSet{VsID: vs.VsID, Algorithm: vs.Algorithm, Revision: vs.Revision}
+	for _, g := range vs.TestGroups {
+		rg := ACVPTestGroup{TgID: g.TgID}
+		for _, tc := range g.Tests {
+			out, err := tc.crypt(f, g.Direction)
+			if err != nil {
+				return nil, fmt.Errorf("tgId %d tcId %d: %v", g.TgID, tc.TcID, err)
+			}
+			r := ACVPTestCase{TcID: tc.TcID}
+			if g.Direction == "encrypt" {
+				r.CT = out
+			} else {
+				r.PT = out
+			}
+			rg.Tests = append(rg.Tests, r)
+		}
+		resp.TestGroups = append(resp.TestGroups, rg)
+	}
+	return resp, nil
+}
+
+// Verify checks the codec against the expected results of a sample vector set, and returns an error listing the failed cases.
+func (vs *ACVPVectorSet) Verify() error {
+	f, err := vs.format()
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, g := range vs.TestGroups {
+		for _, tc := range g.Tests {
+			expect := tc.PT
+			if g.Direction == "encrypt" {
+				expect = tc.CT
+			}
+			out, err := tc.crypt(f, g.Direction)
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("tgId %d tcId %d: %v", g.TgID, tc.TcID, err))
+			} else if !bytes.Equal(out, expect) {
+				failed = append(failed, fmt.Sprintf("tgId %d tcId %d: mismatch", g.TgID, tc.TcID))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d test cases failed:\n%s", len(failed), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// the format of the algorithm of the vector set
+func (vs *ACVPVectorSet) format() (cbccts.Format, error) {
+	for _, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		if vs.Algorithm == ACVPAlgorithm(f) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("vectors: unsupported ACVP algorithm %q", vs.Algorithm)
+}
+
+// run a test case in a direction
+func (tc *ACVPTestCase) crypt(f cbccts.Format, direction string) ([]byte, error) {
+	block, err := aes.NewCipher(tc.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(tc.IV) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV length %d", len(tc.IV))
+	}
+	in := tc.PT
+	if direction == "decrypt" {
+		in = tc.CT
+	} else if direction != "encrypt" {
+		return nil, fmt.Errorf("unknown direction %q", direction)
+	}
+	if tc.PayloadLen != 0 && tc.PayloadLen != len(in)*8 {
+		return nil, fmt.Errorf("payload length %d does not match the input", tc.PayloadLen)
+	}
+	if len(in) < aes.BlockSize {
+		return nil, fmt.Errorf("payload shorter than a block")
+	}
+	out := make([]byte, len(in))
+	if direction == "encrypt" {
+		cbccts.NewCBCCTSEncrypter(block, tc.IV, f).CryptBlocks(out, in)
+	} else {
+		cbccts.NewCBCCTSDecrypter(block, tc.IV, f).CryptBlocks(out, in)
+	}
+	return out, nil
+}
+
+// ReadACVP reads ACVP vector sets, either as an ACVP message (an array of a version object and vector sets) or as a single vector set.
+func ReadACVP(r io.Reader) ([]*ACVPVectorSet, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		vs := new(ACVPVectorSet)
+		if err := json.Unmarshal(raw, vs); err != nil {
+			return nil, err
+		}
+		return []*ACVPVectorSet{vs}, nil
+	}
+
+	var msg []json.RawMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, err
+	}
+	var sets []*ACVPVectorSet
+	for _, m := range msg {
+		vs := new(ACVPVectorSet)
+		if err := json.Unmarshal(m, vs); err != nil {
+			return nil, err
+		}
+		if vs.Algorithm == "" {
+			continue // the version object
+		}
+		sets = append(sets, vs)
+	}
+	return sets, nil
+}
+
+// WriteACVP writes vector sets as an ACVP message.
+func WriteACVP(w io.Writer, sets []*ACVPVectorSet) error {
+	msg := []interface{}{struct {
+		ACVVersion string `json:"acvVersion"`
+	}{ACVPVersion}}
+	for _, vs := range sets {
+		msg = append(msg, vs)
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(msg)
+}
@@ -0,0 +1,90 @@
+package vectors_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+func TestACVP(t *testing.T) {
+
+	var sets []*vectors.ACVPVectorSet
+	for i, f := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		vs := vectors.NewACVPVectorSet(i+1, f, append(append([]vectors.Vector{}, vectors.NIST...), vectors.RFC3962...))
+		if err := vs.Verify(); err != nil {
+			t.Fatalf("%s: %v", vs.Algorithm, err)
+		}
+		sets = append(sets, vs)
+	}
+
+	var b bytes.Buffer
+	if err := vectors.WriteACVP(&b, sets); err != nil {
+		t.Fatal(err)
+	}
+	read, err := vectors.ReadACVP(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(sets) {
+		t.Fatalf("unexpected number of vector sets %d", len(read))
+	}
+
+	for i, vs := range read {
+		if err := vs.Verify(); err != nil {
+			t.Errorf("%s: %v", vs.Algorithm, err)
+		}
+
+		// the response answers the prompts
+		resp, err := vs.Response()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, g := range resp.TestGroups {
+			for k, tc := range g.Tests {
+				expect := sets[i].TestGroups[j].Tests[k]
+				if sets[i].TestGroups[j].Direction == "encrypt" {
+					if !bytes.Equal(tc.CT, expect.CT) || tc.PT != nil {
+						t.Errorf("%s tcId %d: unexpected response", vs.Algorithm, tc.TcID)
+					}
+				} else if !bytes.Equal(tc.PT, expect.PT) || tc.CT != nil {
+					t.Errorf("%s tcId %d: unexpected response", vs.Algorithm, tc.TcID)
+				}
+			}
+		}
+	}
+
+	// a wrong expected result fails
+	read[2].TestGroups[0].Tests[0].CT[0] ^= 1
+	if err := read[2].Verify(); err == nil {
+		t.Errorf("wrong result accepted")
+	}
+}
+
+func TestReadACVP(t *testing.T) {
+
+	// a single prompt vector set
+	prompt := `{"vsId": 7, "algorithm": "ACVP-AES-CBC-CS3", "revision": "1.0", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "tests": [
+			{"tcId": 1, "payloadLen": 136, "key": "636869636B656E207465726979616B69", "iv": "00000000000000000000000000000000",
+			 "pt": "4920776F756C64206C696B652074686520"}]}]}`
+	sets, err := vectors.ReadACVP(strings.NewReader(prompt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := sets[0].Response()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.TestGroups[0].Tests[0].CT; !bytes.Equal(ct, vectors.RFC3962[0].Ciphertext) {
+		t.Errorf("unexpected response %x", ct)
+	}
+
+	// an unknown algorithm is rejected
+	sets[0].Algorithm = "ACVP-AES-CBC"
+	if _, err := sets[0].Response(); err == nil {
+		t.Errorf("unknown algorithm accepted")
+	}
+}
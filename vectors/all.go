@@ -0,0 +1,86 @@
+/*
+	all.go
+	2026-10, github.com/mixcode
+*/
+
+package vectors
+
+import (
+	"fmt"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// LengthClass is the class of the length of a message, where CTS implementations differ the most.
+type LengthClass int
+
+const (
+	AnyLength   LengthClass = iota // matches every length, in a Filter
+	SingleBlock                    // exactly one block, where all formats are plain CBC
+	Aligned                        // a multiple of the block size, more than one block
+	Unaligned                      // more than one block, not a multiple of the block size
+)
+
+// Filter selects vectors by their cipher, format and length class; a zero field matches all vectors.
+type Filter struct {
+	Cipher string        // "AES-128", "AES-192" or "AES-256"
+	Format cbccts.Format // CS1, CS2 or CS3
+	Length LengthClass
+}
+
+// Match reports whether the vector is selected by the filter.
+func (f Filter) Match(v *Vector) bool {
+	return (f.Cipher == "" || f.Cipher == v.Cipher()) &&
+		(f.Format == 0 || f.Format == v.Format) &&
+		(f.Length == AnyLength || f.Length == v.LengthClass())
+}
+
+// All returns copies of all the vectors of the package, those of RFC3962 and NIST, for the tests of other implementations.
+func All() []Vector {
+	var all []Vector
+	for _, set := range [][]Vector{RFC3962, NIST} {
+		for _, v := range set {
+			all = append(all, v.clone())
+		}
+	}
+	return all
+}
+
+// Select returns the vectors of All matched by the filter.
+func Select(f Filter) []Vector {
+	var sel []Vector
+	for _, v := range All() {
+		if f.Match(&v) {
+			sel = append(sel, v)
+		}
+	}
+	return sel
+}
+
+// Cipher returns the name of the cipher of the vector, by the key size: "AES-128", "AES-192" or "AES-256".
+func (v *Vector) Cipher() string {
+	return fmt.Sprintf("AES-%d", len(v.Key)*8)
+}
+
+// LengthClass returns the class of the length of the plaintext.
+func (v *Vector) LengthClass() LengthClass {
+	const bs = 16
+	switch n := len(v.Plaintext); {
+	case n == bs:
+		return SingleBlock
+	case n%bs == 0:
+		return Aligned
+	}
+	return Unaligned
+}
+
+func (v Vector) clone() Vector {
+	dup := func(b []byte) []byte {
+		if b == nil {
+			return nil
+		}
+		return append([]byte{}, b...)
+	}
+	v.Key, v.IV, v.Plaintext, v.Ciphertext, v.NextIV = dup(v.Key), dup(v.IV), dup(v.Plaintext), dup(v.Ciphertext), dup(v.NextIV)
+	return v
+}
@@ -0,0 +1,174 @@
+/*
+	json.go
+	2026-10, github.com/mixcode
+*/
+
+package vectors
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// Results of a test case of a JSON vector file.
+const (
+	ResultValid      = "valid"      // the message must encrypt to the ciphertext, and vice versa
+	ResultInvalid    = "invalid"    // the message and the ciphertext must not match, or the input must be rejected
+	ResultAcceptable = "acceptable" // either outcome is accepted
+)
+
+// TestFile is a test vector file in the JSON layout of Project Wycheproof.
+//
+//	{
+//	  "algorithm": "AES-CBC-CTS",
+//	  "testGroups": [ {
+//	    "format": "CS3",
+//	    "tests": [ { "tcId": 1, "key": "...", "iv": "...", "msg": "...", "ct": "...", "result": "valid" } ]
+//	  } ]
+//	}
+//
+// Binary fields are hex strings. The key size selects AES-128, AES-192 or AES-256.
+type TestFile struct {
+	Algorithm     string      `json:"algorithm"`
+	NumberOfTests int         `json:"numberOfTests"`
+	Header        []string    `json:"header,omitempty"`
+	TestGroups    []TestGroup `json:"testGroups"`
+}
+
+// TestGroup is a group of test cases sharing the CTS format.
+type TestGroup struct {
+	Type   string     `json:"type"`
+	Format string     `json:"format"` // "CS1", "CS2" or "CS3"
+	Tests  []TestCase `json:"tests"`
+}
+
+// TestCase is a single test case.
+type TestCase struct {
+	TcID    int      `json:"tcId"`
+	Comment string   `json:"comment"`
+	Key     HexBytes `json:"key"`
+	IV      HexBytes `json:"iv"`
+	Msg     HexBytes `json:"msg"`
+	CT      HexBytes `json:"ct"`
+	Result  string   `json:"result"`
+	Flags   []string `json:"flags,omitempty"`
+}
+
+// HexBytes is a byte slice encoded as a hex string in JSON.
+type HexBytes []byte
+
+func (h HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+func (h *HexBytes) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	d, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*h = d
+	return nil
+}
+
+// LoadJSON reads a test vector file.
+func LoadJSON(r io.Reader) (*TestFile, error) {
+	f := new(TestFile)
+	if err := json.NewDecoder(r).Decode(f); err != nil {
+		return nil, err
+	}
+	for i := range f.TestGroups {
+		if _, err := cbccts.ParseFormat(f.TestGroups[i].Format); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// LoadJSONFile reads a test vector file from a path.
+func LoadJSONFile(path string) (*TestFile, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return LoadJSON(fd)
+}
+
+// Run runs all the test cases, and returns an error listing the failed cases.
+func (f *TestFile) Run() error {
+	var failed []string
+	for i := range f.TestGroups {
+		g := &f.TestGroups[i]
+		mode, err := cbccts.ParseFormat(g.Format)
+		if err != nil {
+			return err
+		}
+		for j := range g.Tests {
+			if err := g.Tests[j].Run(mode); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d test cases failed:\n%s", len(failed), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// Run runs a test case in a CTS format, and returns an error if the outcome does not agree with the expected result.
+func (tc *TestCase) Run(mode cbccts.Format) error {
+	match, err := tc.match(mode)
+	switch tc.Result {
+	case ResultValid:
+		if err != nil {
+			return fmt.Errorf("tcId %d: %v", tc.TcID, err)
+		}
+		if !match {
+			return fmt.Errorf("tcId %d: valid test case failed", tc.TcID)
+		}
+	case ResultInvalid:
+		if err == nil && match {
+			return fmt.Errorf("tcId %d: invalid test case passed", tc.TcID)
+		}
+	case ResultAcceptable:
+	default:
+		return fmt.Errorf("tcId %d: unknown result %q", tc.TcID, tc.Result)
+	}
+	return nil
+}
+
+// check whether the message and the ciphertext match in both directions; a rejected input is an error
+func (tc *TestCase) match(mode cbccts.Format) (match bool, err error) {
+	defer func() {
+		// the codec panics on invalid input
+		if r := recover(); r != nil {
+			match, err = false, fmt.Errorf("%v", r)
+		}
+	}()
+	block, err := aes.NewCipher(tc.Key)
+	if err != nil {
+		return false, err
+	}
+	if len(tc.Msg) != len(tc.CT) {
+		return false, nil
+	}
+	out := make([]byte, len(tc.Msg))
+	cbccts.NewCBCCTSEncrypter(block, tc.IV, mode).CryptBlocks(out, tc.Msg)
+	if !bytes.Equal(out, tc.CT) {
+		return false, nil
+	}
+	cbccts.NewCBCCTSDecrypter(block, tc.IV, mode).CryptBlocks(out, tc.CT)
+	return bytes.Equal(out, tc.Msg), nil
+}
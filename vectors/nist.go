@@ -0,0 +1,92 @@
+/*
+	nist.go
+	2026-10, github.com/mixcode
+*/
+
+package vectors
+
+import (
+	"fmt"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+// key, IV and plaintext of the CBC example of NIST SP 800-38A Appendix F.2.1
+const (
+	sp80038aKey       = "2b7e151628aed2a6abf7158809cf4f3c"
+	sp80038aIV        = "000102030405060708090a0b0c0d0e0f"
+	sp80038aPlaintext = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+)
+
+// NIST is the CBC-CS1, CBC-CS2 and CBC-CS3 test vectors of the formats in the Addendum to NIST SP 800-38A.
+// The addendum publishes no test vectors, so these use the key, IV and plaintext prefixes of the SP 800-38A CBC example,
+// at the boundary lengths of one block, one block plus a byte, and around two, three and four blocks.
+// The ciphertexts were generated with the AES-128-CBC-CTS cipher of OpenSSL 3 and its CS1, CS2 and CS3 modes;
+// the aligned CS1 ciphertexts are the CBC ciphertexts of SP 800-38A.
+var NIST = []Vector{
+	nistVector(cbccts.CS1, 16,
+		"7649abac8119b246cee98e9b12e9197d"),
+	nistVector(cbccts.CS1, 17,
+		"76b8d266c62a614f00d7c901dc791ecea9"),
+	nistVector(cbccts.CS1, 31,
+		"7649abac8119b246cee98e9b12e91947937b55f8652154c6e9a6f35bafbb56"),
+	nistVector(cbccts.CS1, 32,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2"),
+	nistVector(cbccts.CS1, 33,
+		"7649abac8119b246cee98e9b12e9197d503bdcc95ab108da144e7f2e5d2eee1325"),
+	nistVector(cbccts.CS1, 47,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a9176789dc43313f849e395374eac6507d949b7"),
+	nistVector(cbccts.CS1, 48,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e22229516"),
+	nistVector(cbccts.CS1, 63,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e222295b600b4b55217e8130e89aa96bec69cca"),
+	nistVector(cbccts.CS1, 64,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7"),
+	nistVector(cbccts.CS2, 16,
+		"7649abac8119b246cee98e9b12e9197d"),
+	nistVector(cbccts.CS2, 17,
+		"b8d266c62a614f00d7c901dc791ecea976"),
+	nistVector(cbccts.CS2, 31,
+		"47937b55f8652154c6e9a6f35bafbb567649abac8119b246cee98e9b12e919"),
+	nistVector(cbccts.CS2, 32,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2"),
+	nistVector(cbccts.CS2, 33,
+		"7649abac8119b246cee98e9b12e9197d3bdcc95ab108da144e7f2e5d2eee132550"),
+	nistVector(cbccts.CS2, 47,
+		"7649abac8119b246cee98e9b12e9197d9dc43313f849e395374eac6507d949b75086cb9b507219ee95db113a917678"),
+	nistVector(cbccts.CS2, 48,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e22229516"),
+	nistVector(cbccts.CS2, 63,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2b600b4b55217e8130e89aa96bec69cca73bed6b8e3c1743b7116e69e222295"),
+	nistVector(cbccts.CS2, 64,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7"),
+	nistVector(cbccts.CS3, 16,
+		"7649abac8119b246cee98e9b12e9197d"),
+	nistVector(cbccts.CS3, 17,
+		"b8d266c62a614f00d7c901dc791ecea976"),
+	nistVector(cbccts.CS3, 31,
+		"47937b55f8652154c6e9a6f35bafbb567649abac8119b246cee98e9b12e919"),
+	nistVector(cbccts.CS3, 32,
+		"5086cb9b507219ee95db113a917678b27649abac8119b246cee98e9b12e9197d"),
+	nistVector(cbccts.CS3, 33,
+		"7649abac8119b246cee98e9b12e9197d3bdcc95ab108da144e7f2e5d2eee132550"),
+	nistVector(cbccts.CS3, 47,
+		"7649abac8119b246cee98e9b12e9197d9dc43313f849e395374eac6507d949b75086cb9b507219ee95db113a917678"),
+	nistVector(cbccts.CS3, 48,
+		"7649abac8119b246cee98e9b12e9197d73bed6b8e3c1743b7116e69e222295165086cb9b507219ee95db113a917678b2"),
+	nistVector(cbccts.CS3, 63,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2b600b4b55217e8130e89aa96bec69cca73bed6b8e3c1743b7116e69e222295"),
+	nistVector(cbccts.CS3, 64,
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b23ff1caa1681fac09120eca307586e1a773bed6b8e3c1743b7116e69e22229516"),
+}
+
+func nistVector(mode cbccts.Format, length int, ciphertext string) Vector {
+	return Vector{
+		Name:       fmt.Sprintf("SP 800-38A addendum CBC-CS%d (%d bytes)", mode, length),
+		Format:     mode,
+		Key:        unhex(sp80038aKey),
+		IV:         unhex(sp80038aIV),
+		Plaintext:  unhex(sp80038aPlaintext)[:length],
+		Ciphertext: unhex(ciphertext),
+	}
+}
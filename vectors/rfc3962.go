@@ -0,0 +1,57 @@
+/*
+	rfc3962.go
+	2026-10, github.com/mixcode
+*/
+
+package vectors
+
+import (
+	"github.com/mixcode/golib-cbccts"
+)
+
+// key and IV of the RFC 3962 vectors: the AES-128 key "chicken teriyaki" and a zero IV
+const (
+	rfc3962Key = "636869636b656e207465726979616b69"
+	rfc3962IV  = "00000000000000000000000000000000"
+)
+
+// RFC3962 is the AES-CTS test vectors of RFC 3962 Appendix B, in the CS3 format used by Kerberos.
+// The plaintexts are prefixes of "I would like the General Gau's Chicken, please, and wonton soup."
+var RFC3962 = []Vector{
+	rfc3962Vector("RFC 3962 B.1 (17 bytes)",
+		"4920776f756c64206c696b652074686520",
+		"c6353568f2bf8cb4d8a580362da7ff7f97",
+		"c6353568f2bf8cb4d8a580362da7ff7f"),
+	rfc3962Vector("RFC 3962 B.2 (31 bytes)",
+		"4920776f756c64206c696b65207468652047656e6572616c20476175277320",
+		"fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5",
+		"fc00783e0efdb2c1d445d4c8eff7ed22"),
+	rfc3962Vector("RFC 3962 B.3 (32 bytes)",
+		"4920776f756c64206c696b65207468652047656e6572616c2047617527732043",
+		"39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584",
+		"39312523a78662d5be7fcbcc98ebf5a8"),
+	rfc3962Vector("RFC 3962 B.4 (47 bytes)",
+		"4920776f756c64206c696b65207468652047656e6572616c20476175277320436869636b656e2c20706c656173652c",
+		"97687268d6ecccc0c07b25e25ecfe584b3fffd940c16a18c1b5549d2f838029e39312523a78662d5be7fcbcc98ebf5",
+		"b3fffd940c16a18c1b5549d2f838029e"),
+	rfc3962Vector("RFC 3962 B.5 (48 bytes)",
+		"4920776f756c64206c696b65207468652047656e6572616c20476175277320436869636b656e2c20706c656173652c20",
+		"97687268d6ecccc0c07b25e25ecfe5849dad8bbb96c4cdc03bc103e1a194bbd839312523a78662d5be7fcbcc98ebf5a8",
+		"9dad8bbb96c4cdc03bc103e1a194bbd8"),
+	rfc3962Vector("RFC 3962 B.6 (64 bytes)",
+		"4920776f756c64206c696b65207468652047656e6572616c20476175277320436869636b656e2c20706c656173652c20616e6420776f6e746f6e20736f75702e",
+		"97687268d6ecccc0c07b25e25ecfe58439312523a78662d5be7fcbcc98ebf5a84807efe836ee89a526730dbc2f7bc8409dad8bbb96c4cdc03bc103e1a194bbd8",
+		"4807efe836ee89a526730dbc2f7bc840"),
+}
+
+func rfc3962Vector(name, plaintext, ciphertext, nextIV string) Vector {
+	return Vector{
+		Name:       name,
+		Format:     cbccts.CS3,
+		Key:        unhex(rfc3962Key),
+		IV:         unhex(rfc3962IV),
+		Plaintext:  unhex(plaintext),
+		Ciphertext: unhex(ciphertext),
+		NextIV:     unhex(nextIV),
+	}
+}
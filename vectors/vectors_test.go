@@ -0,0 +1,116 @@
+package vectors_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+	"github.com/mixcode/golib-cbccts/vectors"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := vectors.SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+
+	// an altered vector must fail
+	for _, v := range append(append([]vectors.Vector{}, vectors.RFC3962...), vectors.NIST...) {
+		ct := append([]byte{}, v.Ciphertext...)
+		ct[len(ct)-1] ^= 1
+		v.Ciphertext = ct
+		if err := v.Verify(); err == nil {
+			t.Errorf("%s: altered ciphertext accepted", v.Name)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+
+	// every file in testdata is run
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no vector files")
+	}
+	for _, name := range files {
+		f, err := vectors.LoadJSONFile(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := f.Run(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestJSONResult(t *testing.T) {
+
+	src := `{"testGroups": [{"format": "cs3", "tests": [
+		{"tcId": 1, "key": "636869636b656e207465726979616b69", "iv": "00000000000000000000000000000000",
+		 "msg": "4920776f756c64206c696b652074686520", "ct": "c6353568f2bf8cb4d8a580362da7ff7f97", "result": "%s"}]}]}`
+
+	for _, c := range []struct {
+		result string
+		ok     bool
+	}{
+		{vectors.ResultValid, true},
+		{vectors.ResultAcceptable, true},
+		{vectors.ResultInvalid, false},
+		{"unknown", false},
+	} {
+		f, err := vectors.LoadJSON(strings.NewReader(fmt.Sprintf(src, c.result)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Run(); (err == nil) != c.ok {
+			t.Errorf("result %q: unexpected outcome %v", c.result, err)
+		}
+	}
+
+	// an unknown format is rejected on loading
+	if _, err := vectors.LoadJSON(strings.NewReader(`{"testGroups": [{"format": "CS4"}]}`)); err == nil {
+		t.Errorf("unknown format accepted")
+	}
+}
+
+func TestAll(t *testing.T) {
+
+	all := vectors.All()
+	if len(all) != len(vectors.RFC3962)+len(vectors.NIST) {
+		t.Fatalf("%d vectors", len(all))
+	}
+	all[0].Ciphertext[0] ^= 1
+	if err := vectors.SelfTest(); err != nil {
+		t.Errorf("the vectors of All are not copies: %v", err)
+	}
+
+	for _, c := range []struct {
+		f    vectors.Filter
+		want int
+	}{
+		{vectors.Filter{}, len(all)},
+		{vectors.Filter{Cipher: "AES-128"}, len(all)},
+		{vectors.Filter{Cipher: "AES-256"}, 0},
+		{vectors.Filter{Format: cbccts.CS3}, len(vectors.RFC3962) + len(vectors.NIST)/3},
+		{vectors.Filter{Length: vectors.SingleBlock}, 3},
+		{vectors.Filter{Format: cbccts.CS1, Length: vectors.Aligned}, 3},
+		{vectors.Filter{Format: cbccts.CS3, Length: vectors.Unaligned}, 3 + 5},
+	} {
+		sel := vectors.Select(c.f)
+		if len(sel) != c.want {
+			t.Errorf("%+v: %d vectors, expected %d", c.f, len(sel), c.want)
+		}
+		for _, v := range sel {
+			if !c.f.Match(&v) {
+				t.Errorf("%+v: %s selected", c.f, v.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+/*
+	wipe.go
+	2026-10, github.com/mixcode
+*/
+
+package cbccts
+
+// Wipe zeroes the scratch buffers and the chaining state of the codec, and drops its reference to the block cipher,
+// so that the cipher and its key schedule may be collected. The codec must not be used after Wipe.
+// The key schedule itself belongs to the cipher.Block and is not zeroed here.
+func (cd *cbccts) Wipe() {
+	if cd.codec != nil {
+		cd.codec.(wiper).wipe()
+	}
+	zero(cd.tail16[:])
+	zero(cd.d16[:])
+	zero(cd.ext)
+	zero(cd.state)
+	if cd.release != nil {
+		cd.release()
+	}
+	cd.block, cd.codec, cd.ext, cd.state, cd.release = nil, nil, nil, nil, nil
+}
+
+// Close wipes the codec, as Wipe does. It always returns nil, and lets a codec be deferred as an io.Closer.
+func (cd *cbccts) Close() error {
+	cd.Wipe()
+	return nil
+}
+
+type wiper interface {
+	wipe()
+}
+
+func (c *encChain) wipe() {
+	c.SetIV(make([]byte, len(c.state)))
+}
+
+func (c *decChain) wipe() {
+	c.SetIV(make([]byte, len(c.state)))
+	if w, ok := c.BlockMode.(wiper); ok {
+		w.wipe()
+	}
+}
+
+func (x *batchCBCDecrypter) wipe() {
+	zero(x.buf[:cap(x.buf)])
+	zero(x.iv)
+	zero(x.lastbuf)
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,63 @@
+package cbccts_test
+
+import (
+	"crypto/aes"
+	"io"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts"
+)
+
+func TestWipe(t *testing.T) {
+
+	ac, _ := aes.NewCipher(make([]byte, 16))
+	iv := []byte("0123456789abcdef")
+	data := make([]byte, 37)
+
+	enc := cbccts.NewCBCCTSEncrypter(ac, iv, cbccts.CS3).(*cbccts.Encrypter)
+	enc.CryptBlocks(data, data)
+	enc.Wipe()
+	for _, c := range enc.CipherState() {
+		if c != 0 {
+			t.Fatalf("the chaining state is not wiped")
+		}
+	}
+
+	var dec io.Closer = cbccts.NewCBCCTSDecrypter(ac, iv, cbccts.CS3).(*cbccts.Decrypter)
+	if err := dec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a wiped codec cannot be used
+	defer func() {
+		if recover() == nil {
+			t.Errorf("a wiped codec was used")
+		}
+	}()
+	enc.CryptBlocks(data, data)
+}
+
+func TestScratchCleared(t *testing.T) {
+
+	// the caller-provided scratch shows what the codec leaves behind
+	b := &wideBlock{}
+	iv := make([]byte, b.BlockSize())
+	scratch := make([]byte, cbccts.ScratchSize(b.BlockSize()))
+	for _, mode := range []cbccts.Format{cbccts.CS1, cbccts.CS2, cbccts.CS3} {
+		for _, ct := range []bool{false, true} {
+			data := []byte("a secret message longer than a block of 32 bytes")
+			enc := cbccts.WithScratch(cbccts.NewCBCCTSEncrypter(b, iv, mode), scratch)
+			dec := cbccts.WithScratch(cbccts.NewCBCCTSDecrypter(b, iv, mode), scratch)
+			if ct {
+				enc, dec = cbccts.WithConstantTime(enc), cbccts.WithConstantTime(dec)
+			}
+			enc.CryptBlocks(data, data)
+			dec.CryptBlocks(data, data)
+			for _, c := range scratch {
+				if c != 0 {
+					t.Fatalf("CS%d: plaintext left in the scratch: %q", mode, scratch)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+	xts.go
+	2026-10, github.com/mixcode
+*/
+
+/*
+	Package xts implements the XTS block cipher mode (IEEE 1619) with ciphertext stealing.
+
+	Unlike golang.org/x/crypto/xts, a data unit (sector) does not have to be aligned to the block size.
+	Any data unit of one block or longer is accepted; a partial final block is handled with
+	the ciphertext stealing scheme defined in IEEE 1619, so the ciphertext is always the same length as the plaintext.
+
+	See https://en.wikipedia.org/wiki/Disk_encryption_theory#XTS for info.
+*/
+package xts
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+)
+
+// XTS works only with 128-bit block ciphers.
+const blockSize = 16
+
+// Cipher contains an expanded key structure. It is safe for concurrent use.
+type Cipher struct {
+	k1, k2 cipher.Block
+}
+
+// NewCipher creates a Cipher given a function for creating the underlying block cipher (e.g. aes.NewCipher).
+// The key must be twice the length of the underlying cipher's key.
+func NewCipher(cipherFunc func([]byte) (cipher.Block, error), key []byte) (c *Cipher, err error) {
+	if len(key)%2 != 0 {
+		return nil, fmt.Errorf("xts: invalid key length %d", len(key))
+	}
+	c = new(Cipher)
+	if c.k1, err = cipherFunc(key[:len(key)/2]); err != nil {
+		return nil, err
+	}
+	if c.k2, err = cipherFunc(key[len(key)/2:]); err != nil {
+		return nil, err
+	}
+	if c.k1.BlockSize() != blockSize {
+		return nil, fmt.Errorf("xts: cipher does not have a block size of %d", blockSize)
+	}
+	return c, nil
+}
+
+// Encrypt encrypts a sector of plaintext and puts the result into ciphertext.
+// Plaintext and ciphertext must overlap entirely or not at all.
+// The sector must be at least one block long, but need not be aligned to the block size.
+func (c *Cipher) Encrypt(ciphertext, plaintext []byte, sectorNum uint64) {
+	c.crypt(ciphertext, plaintext, sectorNum, true)
+}
+
+// Decrypt decrypts a sector of ciphertext and puts the result into plaintext.
+// Plaintext and ciphertext must overlap entirely or not at all.
+// The sector must be at least one block long, but need not be aligned to the block size.
+func (c *Cipher) Decrypt(plaintext, ciphertext []byte, sectorNum uint64) {
+	c.crypt(plaintext, ciphertext, sectorNum, false)
+}
+
+// run the XTS process in either direction
+func (c *Cipher) crypt(dst, src []byte, sectorNum uint64, encrypt bool) {
+	textlen := len(src)
+	if textlen < blockSize {
+		panic(fmt.Errorf("xts: data size too small; must be larger than one block"))
+	}
+	if len(dst) < textlen {
+		panic(fmt.Errorf("xts: output smaller than input"))
+	}
+	leftover := textlen % blockSize
+	full := textlen - leftover // length of the block-aligned part
+
+	// the tweak for the first block is the encrypted sector number
+	var tweak [blockSize]byte
+	binary.LittleEndian.PutUint64(tweak[:8], sectorNum)
+	c.k2.Encrypt(tweak[:], tweak[:])
+
+	// process full blocks; if there is a partial block, the last full block is held back
+	last := full
+	if leftover != 0 {
+		last = full - blockSize
+	}
+	for i := 0; i < last; i += blockSize {
+		c.cryptBlock(dst[i:i+blockSize], src[i:i+blockSize], &tweak, encrypt)
+		mul2(&tweak)
+	}
+	if leftover == 0 {
+		return
+	}
+
+	// ciphertext stealing on the last two blocks.
+	// note that on decryption, the tweaks are used in reverse order.
+	tweak2 := tweak
+	mul2(&tweak2)
+	t1, t2 := &tweak, &tweak2
+	if !encrypt {
+		t1, t2 = t2, t1
+	}
+
+	var tmp [blockSize]byte
+	c.cryptBlock(tmp[:], src[last:full], t1, encrypt)
+
+	// the partial block steals the head of the processed block,
+	// and the tail of the processed block pads the partial block
+	var pp [blockSize]byte
+	copy(pp[:], src[full:])
+	copy(pp[leftover:], tmp[leftover:])
+	copy(dst[full:], tmp[:leftover])
+	c.cryptBlock(dst[last:full], pp[:], t2, encrypt)
+}
+
+// process a single block with a tweak
+func (c *Cipher) cryptBlock(dst, src []byte, tweak *[blockSize]byte, encrypt bool) {
+	var b [blockSize]byte
+	for i := range b {
+		b[i] = src[i] ^ tweak[i]
+	}
+	if encrypt {
+		c.k1.Encrypt(b[:], b[:])
+	} else {
+		c.k1.Decrypt(b[:], b[:])
+	}
+	for i := range b {
+		dst[i] = b[i] ^ tweak[i]
+	}
+}
+
+// mul2 multiplies the tweak by x in GF(2^128), in the little-endian order of IEEE 1619
+func mul2(tweak *[blockSize]byte) {
+	var carryIn byte
+	for j := range tweak {
+		carryOut := tweak[j] >> 7
+		tweak[j] = (tweak[j] << 1) + carryIn
+		carryIn = carryOut
+	}
+	if carryIn != 0 {
+		tweak[0] ^= 0x87 // x^128 = x^7 + x^2 + x + 1
+	}
+}
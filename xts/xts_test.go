@@ -0,0 +1,90 @@
+package xts_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mixcode/golib-cbccts/xts"
+)
+
+func TestXTS(t *testing.T) {
+
+	seq := func(n int, mul byte) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(i) * mul
+		}
+		return b
+	}
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	type testParam struct {
+		key    string
+		sector uint64
+		data   []byte
+		expect string
+	}
+	testCase := []testParam{
+		// IEEE 1619 vector 1
+		{"0000000000000000000000000000000000000000000000000000000000000000", 0, make([]byte, 32),
+			"917cf69ebd68b2ec9b9fe9a3eadda692cd43d2f59598ed858c02c2652fbf922e"},
+
+		// IEEE 1619 vectors 15-18: data units not aligned to the block size
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a, seq(17, 1),
+			"6c1625db4671522d3d7599601de7ca09ed"},
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a, seq(18, 1),
+			"d069444b7a7e0cab09e24447d24deb1fedbf"},
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a, seq(19, 1),
+			"e5df1351c0544ba1350b3363cd8ef4beedbf9d"},
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a, seq(20, 1),
+			"9d84c813f719aa2c7be3f66171c7c5c2edbf9dac"},
+
+		// AES-256, generated with OpenSSL aes-256-xts
+		{hex.EncodeToString(seq(64, 1)), 0xff, seq(100, 7),
+			"47e955d3376327fb8da94e80b0cd9fa269af4acc94482339ef0f21a49aa59e219c6f2fe52156041d84b556ebef57f8ed" +
+				"506c901981722a0685f21d94f3e1a365238ab3806d70493524e9cbd22d263e0a623dec8b76781b118f84dfc8e2aeee4e6f0ffce1"},
+	}
+
+	for i, c := range testCase {
+		xc, err := xts.NewCipher(aes.NewCipher, unhex(c.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := unhex(c.expect)
+
+		enc := make([]byte, len(c.data))
+		xc.Encrypt(enc, c.data, c.sector)
+		if !bytes.Equal(enc, expect) {
+			t.Errorf("encrypt failed: case %d, expected %x, got %x", i, expect, enc)
+		}
+
+		dec := make([]byte, len(enc))
+		xc.Decrypt(dec, enc, c.sector)
+		if !bytes.Equal(dec, c.data) {
+			t.Errorf("decrypt failed: case %d", i)
+		}
+	}
+
+	// round trip, in place, for every length in the first few blocks
+	xc, err := xts.NewCipher(aes.NewCipher, seq(32, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := aes.BlockSize; n < 5*aes.BlockSize; n++ {
+		data := seq(n, 5)
+		buf := append([]byte(nil), data...)
+		xc.Encrypt(buf, buf, uint64(n))
+		xc.Decrypt(buf, buf, uint64(n))
+		if !bytes.Equal(buf, data) {
+			t.Errorf("round trip failed: length %d", n)
+		}
+	}
+}